@@ -1,6 +1,7 @@
 package app_init
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -16,7 +17,13 @@ func RunServer(cfg *server.Config) {
 
 	log.Printf("Server starting...")
 
-	router := Routes(cfg)
+	deps := NewDependencies(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	StartWorkers(ctx, cfg, deps)
+
+	router := Routes(cfg, deps)
 	srv := &http.Server{
 		Addr:        fmt.Sprintf(":%s", cfg.Port),
 		Handler:     router,
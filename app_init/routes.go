@@ -1,8 +1,10 @@
 package app_init
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/juandr89/delivery-notifier-buyer/middleware"
@@ -11,31 +13,296 @@ import (
 	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
 	redisRepository "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/repository"
 	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/sender"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/templates"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
 )
 
-func Routes(cfg *server.Config) *mux.Router {
-	log.Println("Loading routes..")
+// Dependencies holds the services shared by the HTTP handlers and the
+// background workers.
+type Dependencies struct {
+	NotificationRepository domain.NotificationRepository
+	JobRepository          domain.JobRepository
+	DeliveryRepository     domain.DeliveryRepository
+	NotificationSender     domain.NotificationSender
+	SMSSender              domain.NotificationSender
+	WebhookSender          domain.NotificationSender
+	MessageRenderer        *templates.Catalog
+	ForecastService        third_party.IForecastService
+	Geocoder               third_party.Geocoder
+}
+
+func NewDependencies(cfg *server.Config) *Dependencies {
 	notificationSender := NewNotificationSender(cfg)
 	notificationRepository := NewNotificationRepository(cfg)
-	notificationHandler := infrastructure.NewNotificationHandler(notificationRepository, notificationSender, *cfg)
+	forecastService, err := NewForecastService(cfg, notificationRepository)
+	if err != nil {
+		log.Fatalf("Error loading forecast service: %v", err)
+	}
+	if err := ValidateQuietHours(cfg.QuietHoursConfig); err != nil {
+		log.Fatalf("Error loading quiet hours: %v", err)
+	}
+	if _, _, err := infrastructure.DeliverySchedule(*cfg); err != nil {
+		log.Fatalf("Error loading scheduler: %v", err)
+	}
+
+	return &Dependencies{
+		NotificationRepository: notificationRepository,
+		JobRepository:          NewJobRepository(notificationRepository),
+		DeliveryRepository:     NewDeliveryRepository(notificationRepository),
+		NotificationSender:     notificationSender,
+		SMSSender:              NewSMSSender(cfg),
+		WebhookSender:          NewWebhookSender(cfg),
+		MessageRenderer:        NewMessageRenderer(cfg),
+		ForecastService:        forecastService,
+		Geocoder:               NewGeocoder(cfg, notificationRepository),
+	}
+}
+
+// NotificationSettings returns the settings of usecases.SendNotification from
+// the configuration and the shared services.
+func (deps *Dependencies) NotificationSettings(cfg *server.Config) usecases.NotificationSettings {
+	settings := infrastructure.NotificationSettings(*cfg)
+	settings.Geocoder = deps.Geocoder
+	return settings
+}
+
+func Routes(cfg *server.Config, deps *Dependencies) *mux.Router {
+	log.Println("Loading routes..")
+	notificationHandler := infrastructure.NewNotificationHandler(deps.NotificationRepository, deps.MessageRenderer, deps.ForecastService, *cfg)
+	notificationHandler.Geocoder = deps.Geocoder
+	jobHandler := infrastructure.NewJobHandler(deps.JobRepository, deps.ForecastService, *cfg)
+	adminHandler := infrastructure.NewAdminHandler(deps.NotificationRepository)
+	buyerHandler := infrastructure.NewBuyerHandler(deps.NotificationRepository, deps.MessageRenderer, *cfg)
+	deliveryHandler := infrastructure.NewDeliveryHandler(deps.DeliveryRepository, *cfg)
 
 	authMiddleware := middleware.ApiKeyMiddleware(cfg.APIKey)
 
 	router := mux.NewRouter()
 	api := router.PathPrefix("/api/v1").Subrouter()
 
-	router.Use(authMiddleware)
+	api.Use(authMiddleware)
 
+	// The unsubscribe link of the emails is public; its token authenticates it.
+	router.HandleFunc("/unsubscribe", buyerHandler.UnsubscribePage).Methods(http.MethodGet)
+	router.HandleFunc("/unsubscribe", buyerHandler.UnsubscribeOneClick).Methods(http.MethodPost)
+
+	api.HandleFunc("/notifications", jobHandler.NotifyBuyerAsync).Methods(http.MethodPost).Queries("async", "true")
 	api.HandleFunc("/notifications", notificationHandler.NotifyBuyer).Methods(http.MethodPost)
+	api.HandleFunc("/notifications/batch", notificationHandler.NotifyBuyersBatch).Methods(http.MethodPost)
 	api.HandleFunc("/notifications/{email}", notificationHandler.BuyerNotifications).Methods(http.MethodGet)
+	api.HandleFunc("/buyers/{email}/preferences", buyerHandler.GetPreferences).Methods(http.MethodGet)
+	api.HandleFunc("/buyers/{email}/preferences", buyerHandler.SavePreferences).Methods(http.MethodPut)
+	api.HandleFunc("/buyers/{email}/subscription", buyerHandler.Subscribe).Methods(http.MethodPut)
+	api.HandleFunc("/buyers/{email}/subscription", buyerHandler.Unsubscribe).Methods(http.MethodDelete)
+	api.HandleFunc("/jobs/{id}", jobHandler.GetJob).Methods(http.MethodGet)
+	api.HandleFunc("/deliveries", deliveryHandler.RegisterDelivery).Methods(http.MethodPost)
+	api.HandleFunc("/deliveries", deliveryHandler.GetDeliverySchedule).Methods(http.MethodGet)
+	api.HandleFunc("/deliveries/{id}", deliveryHandler.GetDelivery).Methods(http.MethodGet)
+	api.HandleFunc("/deliveries/{id}", deliveryHandler.UpdateDelivery).Methods(http.MethodPatch)
+	api.HandleFunc("/orders/{order_id}", deliveryHandler.GetOrderDelivery).Methods(http.MethodGet)
+	api.HandleFunc("/orders/{order_id}/notifications", deliveryHandler.OrderNotifications).Methods(http.MethodGet)
+	api.HandleFunc("/forecast/cache/stats", notificationHandler.ForecastCacheStats).Methods(http.MethodGet)
+
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.AdminKeyMiddleware(cfg.AdminKeys))
+
+	admin.HandleFunc("/codes", adminHandler.ListCodes).Methods(http.MethodGet)
+	admin.HandleFunc("/codes", adminHandler.AddCode).Methods(http.MethodPost)
+	admin.HandleFunc("/codes", adminHandler.ReplaceCodes).Methods(http.MethodPut)
+	admin.HandleFunc("/codes/{code}", adminHandler.RemoveCode).Methods(http.MethodDelete)
+	admin.HandleFunc("/rules", adminHandler.ListRules).Methods(http.MethodGet)
+	admin.HandleFunc("/rules/{name}", adminHandler.SaveRule).Methods(http.MethodPut)
+	admin.HandleFunc("/rules/{name}", adminHandler.RemoveRule).Methods(http.MethodDelete)
 
 	return router
 }
 
+// ValidateQuietHours checks the global quiet hours, disabled when both ends
+// are empty, and the default timezone.
+func ValidateQuietHours(quietHoursConfig server.QuietHoursConfig) error {
+	if quietHoursConfig.Start != "" || quietHoursConfig.End != "" {
+		quietHours := usecases.QuietHours{Start: quietHoursConfig.Start, End: quietHoursConfig.End}
+		if err := usecases.ValidateQuietHours(quietHours, "quiet_hours"); err != nil {
+			return err
+		}
+	}
+	return usecases.ValidateTimezone(quietHoursConfig.DefaultTimezone, "quiet_hours.default_timezone")
+}
+
 func NewNotificationRepository(cfg *server.Config) domain.NotificationRepository {
 	return redisRepository.NewNotificationRepository(cfg.RedisConfig)
 }
 
+func NewJobRepository(repository domain.NotificationRepository) domain.JobRepository {
+	jobRepository, ok := repository.(domain.JobRepository)
+	if !ok {
+		log.Fatalf("Notification repository does not support jobs")
+	}
+	return jobRepository
+}
+
+func NewDeliveryRepository(repository domain.NotificationRepository) domain.DeliveryRepository {
+	deliveryRepository, ok := repository.(domain.DeliveryRepository)
+	if !ok {
+		log.Fatalf("Notification repository does not support deliveries")
+	}
+	return deliveryRepository
+}
+
+// StartWorkers launches the background workers; they stop when ctx is done.
+func StartWorkers(ctx context.Context, cfg *server.Config, deps *Dependencies) {
+	log.Printf("Starting %d job worker(s)", cfg.JobsConfig.Workers)
+	usecases.RunJobWorkers(ctx, cfg.JobsConfig.Workers, cfg.JobsConfig.MaxAttempts, time.Duration(cfg.JobsConfig.LeaseSeconds)*time.Second, deps.JobRepository, deps.ForecastService, deps.NotificationRepository, deps.MessageRenderer, deps.NotificationSettings(cfg))
+
+	if templatesConfig := cfg.TemplatesConfig; templatesConfig.Dir != "" && templatesConfig.HotReload {
+		log.Printf("Watching message templates in %s", templatesConfig.Dir)
+		deps.MessageRenderer.Watch(ctx, templatesConfig.Dir, time.Duration(templatesConfig.ReloadIntervalSeconds)*time.Second)
+	}
+
+	outbox := cfg.OutboxConfig
+	log.Printf("Starting outbox dispatcher batch %d max attempts %d", outbox.BatchSize, outbox.MaxAttempts)
+	usecases.RunOutboxDispatcher(ctx, usecases.OutboxPolicy{
+		BatchSize:    outbox.BatchSize,
+		MaxAttempts:  outbox.MaxAttempts,
+		Lease:        time.Duration(outbox.LeaseSeconds) * time.Second,
+		RetryBackoff: time.Duration(outbox.RetryBackoffSeconds) * time.Second,
+	}, time.Duration(outbox.PollIntervalMs)*time.Millisecond, deps.NotificationRepository, deps.Senders())
+
+	schedule, location, err := infrastructure.DeliverySchedule(*cfg)
+	if err != nil {
+		log.Printf("Scheduler disabled: %v", err)
+	} else if schedule != nil {
+		scheduler := cfg.SchedulerConfig
+		log.Printf("Starting delivery scheduler %q in %s", scheduler.Cron, location)
+		usecases.RunDeliveryScheduler(ctx, schedule, location, usecases.SweepPolicy{
+			DaysAhead:   scheduler.DaysAhead,
+			Concurrency: scheduler.Concurrency,
+			LockTTL:     time.Duration(scheduler.LockSeconds) * time.Second,
+		}, deps.DeliveryRepository, deps.ForecastService, deps.NotificationRepository, deps.MessageRenderer, deps.NotificationSettings(cfg))
+	}
+}
+
+// Senders maps each notification channel to its configured sender.
+func (deps *Dependencies) Senders() map[string]domain.NotificationSender {
+	senders := map[string]domain.NotificationSender{
+		domain.ChannelEmail: deps.NotificationSender,
+	}
+	if deps.SMSSender != nil {
+		senders[domain.ChannelSMS] = deps.SMSSender
+	}
+	if deps.WebhookSender != nil {
+		senders[domain.ChannelWebhook] = deps.WebhookSender
+	}
+	return senders
+}
+
 func NewNotificationSender(cfg *server.Config) domain.NotificationSender {
-	return sender.NewSmtpClient(cfg.SMTPConfig)
+	switch cfg.NotificationSender {
+	case "", "smtp":
+		smtpClient := sender.NewSmtpClient(cfg.SMTPConfig)
+		if cfg.SMTPConfig.DKIMPrivateKeyPath != "" {
+			signer, err := sender.LoadDKIMSigner(cfg.SMTPConfig.DKIMDomain, cfg.SMTPConfig.DKIMSelector, cfg.SMTPConfig.DKIMPrivateKeyPath)
+			if err != nil {
+				log.Fatalf("Error loading dkim signer: %v", err)
+			}
+			smtpClient.DKIMSigner = signer
+		}
+		return smtpClient
+	case "sendgrid":
+		return sender.NewSendGridClient(cfg.SendGridConfig)
+	default:
+		log.Fatalf("Notification sender %q is not supported", cfg.NotificationSender)
+		return nil
+	}
+}
+
+func NewSMSSender(cfg *server.Config) domain.NotificationSender {
+	if !cfg.SMSConfig.Enabled {
+		return nil
+	}
+	return sender.NewSMSClient(cfg.SMSConfig)
+}
+
+func NewWebhookSender(cfg *server.Config) domain.NotificationSender {
+	if !cfg.WebhookConfig.Enabled {
+		return nil
+	}
+	return sender.NewWebhookClient(cfg.WebhookConfig)
+}
+
+// NewMessageRenderer loads the message templates from templates.dir or, when
+// it is empty, the templates embedded in the binary.
+func NewMessageRenderer(cfg *server.Config) *templates.Catalog {
+	defaultLocale := cfg.TemplatesConfig.DefaultLocale
+	if defaultLocale == "" {
+		defaultLocale = templates.DefaultLocale
+	}
+
+	var catalog *templates.Catalog
+	var err error
+	if cfg.TemplatesConfig.Dir != "" {
+		catalog, err = templates.NewDirCatalog(cfg.TemplatesConfig.Dir, defaultLocale)
+	} else {
+		catalog, err = templates.NewCatalog(templates.Embedded(), defaultLocale)
+	}
+	if err != nil {
+		log.Fatalf("Error loading message templates: %v", err)
+	}
+	return catalog
+}
+
+func NewForecastService(cfg *server.Config, repository domain.NotificationRepository) (third_party.IForecastService, error) {
+	forecastService, err := third_party.NewForecastService(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheConfig := cfg.ForecastServiceConfig.Cache
+	if !cacheConfig.Enabled {
+		return forecastService, nil
+	}
+
+	var cache third_party.ForecastCache
+	redisRepo, isRedis := repository.(*redisRepository.RedisRepository)
+	if cacheConfig.Backend == "redis" && isRedis && redisRepo != nil {
+		cache = third_party.NewRedisForecastCache(redisRepo.Client)
+	} else {
+		if cacheConfig.Backend == "redis" {
+			log.Printf("Redis forecast cache unavailable, falling back to memory")
+		}
+		cache = third_party.NewMemoryForecastCache(cacheConfig.MaxEntries)
+	}
+
+	log.Printf("Loading forecast cache backend %s ttl %ds precision %d", cacheConfig.Backend, cacheConfig.TTLSeconds, cacheConfig.Precision)
+	return third_party.NewCachedForecastService(forecastService, third_party.ForecastProviderName(cfg), cache, time.Duration(cacheConfig.TTLSeconds)*time.Second, cacheConfig.Precision), nil
+}
+
+// NewGeocoder returns the geocoder of the addresses, cached when
+// geocoder.cache is enabled, or nil when geocoding is disabled.
+func NewGeocoder(cfg *server.Config, repository domain.NotificationRepository) third_party.Geocoder {
+	geocoderConfig := cfg.GeocoderConfig
+	if !geocoderConfig.Enabled {
+		return nil
+	}
+	geocoder := third_party.NewNominatimGeocoder(geocoderConfig)
+
+	cacheConfig := geocoderConfig.Cache
+	if !cacheConfig.Enabled {
+		return geocoder
+	}
+
+	var cache third_party.GeocodeCache
+	redisRepo, isRedis := repository.(*redisRepository.RedisRepository)
+	if cacheConfig.Backend == "redis" && isRedis && redisRepo != nil {
+		cache = third_party.NewRedisGeocodeCache(redisRepo.Client)
+	} else {
+		if cacheConfig.Backend == "redis" {
+			log.Printf("Redis geocode cache unavailable, falling back to memory")
+		}
+		cache = third_party.NewMemoryGeocodeCache(cacheConfig.MaxEntries)
+	}
+
+	log.Printf("Loading geocode cache backend %s ttl %ds", cacheConfig.Backend, cacheConfig.TTLSeconds)
+	return third_party.NewCachedGeocoder(geocoder, cache, time.Duration(cacheConfig.TTLSeconds)*time.Second)
 }
@@ -7,12 +7,46 @@ echo
 cat <<EOL > $output_file
 port: $PORT
 api_key: $API_KEY
+admin_keys:
+  ${ADMIN_USER:-admin}: $ADMIN_API_KEY
 notification_sender: $SENDER
 smtp:
   host: $SMTP_HOST
   port: $SMTP_PORT
   username: $SMTP_USERNAME
   password: $SMTP_PASSWORD
+  from: $SMTP_FROM
+  from_name: $SMTP_FROM_NAME
+  reply_to: $SMTP_REPLY_TO
+  list_unsubscribe: $SMTP_LIST_UNSUBSCRIBE
+  tls_mode: ${SMTP_TLS_MODE:-starttls}
+  auth: ${SMTP_AUTH:-plain}
+  pool_size: ${SMTP_POOL_SIZE:-4}
+  dial_timeout_seconds: ${SMTP_DIAL_TIMEOUT_SECONDS:-10}
+  send_timeout_seconds: ${SMTP_SEND_TIMEOUT_SECONDS:-30}
+  idle_timeout_seconds: ${SMTP_IDLE_TIMEOUT_SECONDS:-30}
+  dkim_domain: $SMTP_DKIM_DOMAIN
+  dkim_selector: $SMTP_DKIM_SELECTOR
+  dkim_private_key_path: $SMTP_DKIM_PRIVATE_KEY_PATH
+sendgrid:
+  api_key: $SENDGRID_API_KEY
+  base_url: $SENDGRID_BASE_URL
+  from_email: $SENDGRID_FROM_EMAIL
+  from_name: $SENDGRID_FROM_NAME
+sms:
+  enabled: $SMS_ENABLED
+  base_url: $SMS_BASE_URL
+  account_sid: $SMS_ACCOUNT_SID
+  auth_token: $SMS_AUTH_TOKEN
+  from: $SMS_FROM
+webhook:
+  enabled: $WEBHOOK_ENABLED
+  secret: $WEBHOOK_SECRET
+  allow_private_callbacks: ${WEBHOOK_ALLOW_PRIVATE_CALLBACKS:-false}
+  tenants:
+    ${WEBHOOK_TENANT:-default}:
+      url: $WEBHOOK_TENANT_URL
+      secret: $WEBHOOK_TENANT_SECRET
 redis:
   host: $REDIS_HOST
   port: $REDIS_PORT
@@ -20,8 +54,60 @@ redis:
   db: 1
   tls_enable: $TLS_ENABLE
 forecast_service:
+  provider: $FORECAST_PROVIDER
   base_url: $FORECAST_URL
   api_key: $FORECAST_API_KEY
+  max_days: $FORECAST_MAX_DAYS
+  cache:
+    enabled: $FORECAST_CACHE_ENABLED
+    backend: $FORECAST_CACHE_BACKEND
+    ttl_seconds: $FORECAST_CACHE_TTL_SECONDS
+    precision: $FORECAST_CACHE_PRECISION
+    max_entries: $FORECAST_CACHE_MAX_ENTRIES
+batch:
+  max_concurrency: $BATCH_MAX_CONCURRENCY
+  max_items: $BATCH_MAX_ITEMS
+jobs:
+  workers: $JOBS_WORKERS
+  max_attempts: $JOBS_MAX_ATTEMPTS
+  lease_seconds: ${JOBS_LEASE_SECONDS:-30}
+outbox:
+  poll_interval_ms: $OUTBOX_POLL_INTERVAL_MS
+  batch_size: $OUTBOX_BATCH_SIZE
+  max_attempts: $OUTBOX_MAX_ATTEMPTS
+  lease_seconds: $OUTBOX_LEASE_SECONDS
+  retry_backoff_seconds: $OUTBOX_RETRY_BACKOFF_SECONDS
+templates:
+  dir: $TEMPLATES_DIR
+  default_locale: $TEMPLATES_DEFAULT_LOCALE
+  hot_reload: $TEMPLATES_HOT_RELOAD
+  reload_interval_seconds: $TEMPLATES_RELOAD_INTERVAL_SECONDS
+unsubscribe:
+  secret: $UNSUBSCRIBE_SECRET
+  base_url: $UNSUBSCRIBE_BASE_URL
+dedup:
+  window_seconds: ${DEDUP_WINDOW_SECONDS:-86400}
+  location_precision: ${DEDUP_LOCATION_PRECISION:-2}
+quiet_hours:
+  start: "${QUIET_HOURS_START:-22:00}"
+  end: "${QUIET_HOURS_END:-08:00}"
+  default_timezone: ${QUIET_HOURS_DEFAULT_TIMEZONE:-America/Sao_Paulo}
+scheduler:
+  cron: "${SCHEDULER_CRON-0 18 * * *}"
+  timezone: ${SCHEDULER_TIMEZONE:-America/Sao_Paulo}
+  days_ahead: ${SCHEDULER_DAYS_AHEAD:-1}
+  concurrency: ${SCHEDULER_CONCURRENCY:-4}
+  lock_seconds: ${SCHEDULER_LOCK_SECONDS:-3600}
+geocoder:
+  enabled: ${GEOCODER_ENABLED:-false}
+  base_url: ${GEOCODER_BASE_URL:-https://nominatim.openstreetmap.org}
+  user_agent: ${GEOCODER_USER_AGENT:-delivery-notifier-buyer}
+  country_codes: "${GEOCODER_COUNTRY_CODES}"
+  cache:
+    enabled: ${GEOCODER_CACHE_ENABLED:-true}
+    backend: ${GEOCODER_CACHE_BACKEND:-memory}
+    ttl_seconds: ${GEOCODER_CACHE_TTL_SECONDS:-2592000}
+    max_entries: ${GEOCODER_CACHE_MAX_ENTRIES:-10000}
 EOL
 
 echo "YAML configuration file created at $output_file"
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	_ "time/tzdata"
 
 	"github.com/juandr89/delivery-notifier-buyer/app_init"
 	"github.com/juandr89/delivery-notifier-buyer/server"
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+)
+
+type contextKey string
+
+const adminUserKey contextKey = "admin_user"
+
+// AdminKeyMiddleware authenticates administrators by the x-admin-key header,
+// keys being configured per user so changes can be attributed.
+func AdminKeyMiddleware(adminKeys map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("x-admin-key")
+			for user, key := range adminKeys {
+				if key != "" && subtle.ConstantTimeCompare([]byte(authHeader), []byte(key)) == 1 {
+					ctx := context.WithValue(r.Context(), adminUserKey, user)
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+			}
+			http.Error(w, "Acceso no autorizado", http.StatusUnauthorized)
+		})
+	}
+}
+
+func AdminUserFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(adminUserKey).(string)
+	return user
+}
@@ -9,26 +9,168 @@ import (
 type Config struct {
 	Port                  string                `mapstructure:"port"`
 	APIKey                string                `mapstructure:"api_key"`
+	AdminKeys             map[string]string     `mapstructure:"admin_keys"`
 	NotificationSender    string                `mapstructure:"notification_sender"`
 	SMTPConfig            SMTPConfig            `mapstructure:"smtp"`
+	SendGridConfig        SendGridConfig        `mapstructure:"sendgrid"`
+	SMSConfig             SMSConfig             `mapstructure:"sms"`
+	WebhookConfig         WebhookConfig         `mapstructure:"webhook"`
 	RedisConfig           RedisConfig           `mapstructure:"redis"`
 	ForecastServiceConfig ForecastServiceConfig `mapstructure:"forecast_service"`
+	BatchConfig           BatchConfig           `mapstructure:"batch"`
+	JobsConfig            JobsConfig            `mapstructure:"jobs"`
+	OutboxConfig          OutboxConfig          `mapstructure:"outbox"`
+	TemplatesConfig       TemplatesConfig       `mapstructure:"templates"`
+	UnsubscribeConfig     UnsubscribeConfig     `mapstructure:"unsubscribe"`
+	DedupConfig           DedupConfig           `mapstructure:"dedup"`
+	QuietHoursConfig      QuietHoursConfig      `mapstructure:"quiet_hours"`
+	SchedulerConfig       SchedulerConfig       `mapstructure:"scheduler"`
+	GeocoderConfig        GeocoderConfig        `mapstructure:"geocoder"`
+}
+
+type BatchConfig struct {
+	MaxConcurrency int `mapstructure:"max_concurrency"`
+	MaxItems       int `mapstructure:"max_items"`
+}
+
+type JobsConfig struct {
+	Workers      int `mapstructure:"workers"`
+	MaxAttempts  int `mapstructure:"max_attempts"`
+	LeaseSeconds int `mapstructure:"lease_seconds"`
+}
+
+type OutboxConfig struct {
+	PollIntervalMs      int `mapstructure:"poll_interval_ms"`
+	BatchSize           int `mapstructure:"batch_size"`
+	MaxAttempts         int `mapstructure:"max_attempts"`
+	LeaseSeconds        int `mapstructure:"lease_seconds"`
+	RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds"`
+}
+
+type TemplatesConfig struct {
+	Dir                   string `mapstructure:"dir"`
+	DefaultLocale         string `mapstructure:"default_locale"`
+	HotReload             bool   `mapstructure:"hot_reload"`
+	ReloadIntervalSeconds int    `mapstructure:"reload_interval_seconds"`
+}
+
+// UnsubscribeConfig signs the unsubscribe links of the emails. BaseURL is the
+// public URL of the service; links are disabled when it or Secret is empty.
+type UnsubscribeConfig struct {
+	Secret  string `mapstructure:"secret"`
+	BaseURL string `mapstructure:"base_url"`
+}
+
+// DedupConfig is the window in which a buyer is not warned twice of the same
+// delivery. LocationPrecision is the number of decimals of the coordinates in
+// the dedup key. A zero window disables deduplication.
+type DedupConfig struct {
+	WindowSeconds     int `mapstructure:"window_seconds"`
+	LocationPrecision int `mapstructure:"location_precision"`
+}
+
+// QuietHoursConfig is the global daily range, as HH:MM in the buyer timezone,
+// in which messages are deferred. Buyers may replace it in their preferences.
+// DefaultTimezone is used when the buyer timezone is unknown; an empty range
+// disables the quiet hours.
+type QuietHoursConfig struct {
+	Start           string `mapstructure:"start"`
+	End             string `mapstructure:"end"`
+	DefaultTimezone string `mapstructure:"default_timezone"`
+}
+
+// SchedulerConfig runs the sweep of the registered deliveries at the times of
+// the Cron expression, read in Timezone. Each sweep notifies the deliveries
+// DaysAhead days later. An empty Cron disables the sweep.
+type SchedulerConfig struct {
+	Cron        string `mapstructure:"cron"`
+	Timezone    string `mapstructure:"timezone"`
+	DaysAhead   int    `mapstructure:"days_ahead"`
+	Concurrency int    `mapstructure:"concurrency"`
+	LockSeconds int    `mapstructure:"lock_seconds"`
+}
+
+// GeocoderConfig resolves the address of the requests without coordinates
+// through a Nominatim compatible service at BaseURL. CountryCodes optionally
+// limits the search to a comma separated list of ISO 3166-1 codes.
+type GeocoderConfig struct {
+	Enabled      bool                `mapstructure:"enabled"`
+	BaseURL      string              `mapstructure:"base_url"`
+	UserAgent    string              `mapstructure:"user_agent"`
+	CountryCodes string              `mapstructure:"country_codes"`
+	Cache        GeocoderCacheConfig `mapstructure:"cache"`
+}
+
+type GeocoderCacheConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Backend    string `mapstructure:"backend"`
+	TTLSeconds int    `mapstructure:"ttl_seconds"`
+	MaxEntries int    `mapstructure:"max_entries"`
 }
 
 type SMTPConfig struct {
-	Host     string `mapstructure:"host"`
-	Port     int    `mapstructure:"port"`
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
+	Host               string `mapstructure:"host"`
+	Port               int    `mapstructure:"port"`
+	Username           string `mapstructure:"username"`
+	Password           string `mapstructure:"password"`
+	From               string `mapstructure:"from"`
+	FromName           string `mapstructure:"from_name"`
+	ReplyTo            string `mapstructure:"reply_to"`
+	ListUnsubscribe    string `mapstructure:"list_unsubscribe"`
+	TLSMode            string `mapstructure:"tls_mode"`
+	Auth               string `mapstructure:"auth"`
+	PoolSize           int    `mapstructure:"pool_size"`
+	DialTimeoutSeconds int    `mapstructure:"dial_timeout_seconds"`
+	SendTimeoutSeconds int    `mapstructure:"send_timeout_seconds"`
+	IdleTimeoutSeconds int    `mapstructure:"idle_timeout_seconds"`
+	DKIMDomain         string `mapstructure:"dkim_domain"`
+	DKIMSelector       string `mapstructure:"dkim_selector"`
+	DKIMPrivateKeyPath string `mapstructure:"dkim_private_key_path"`
 }
 
 type ForecastServiceConfig struct {
-	BaseURL string `mapstructure:"base_url"`
-	APIKey  string `mapstructure:"api_key"`
+	Provider string              `mapstructure:"provider"`
+	BaseURL  string              `mapstructure:"base_url"`
+	APIKey   string              `mapstructure:"api_key"`
+	MaxDays  int                 `mapstructure:"max_days"`
+	Cache    ForecastCacheConfig `mapstructure:"cache"`
+}
+
+type ForecastCacheConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Backend    string `mapstructure:"backend"`
+	TTLSeconds int    `mapstructure:"ttl_seconds"`
+	Precision  int    `mapstructure:"precision"`
+	MaxEntries int    `mapstructure:"max_entries"`
 }
 
 type SendGridConfig struct {
-	APIKey string `mapstructure:"api_key"`
+	APIKey    string `mapstructure:"api_key"`
+	BaseURL   string `mapstructure:"base_url"`
+	FromEmail string `mapstructure:"from_email"`
+	FromName  string `mapstructure:"from_name"`
+}
+
+type SMSConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	BaseURL    string `mapstructure:"base_url"`
+	AccountSID string `mapstructure:"account_sid"`
+	AuthToken  string `mapstructure:"auth_token"`
+	From       string `mapstructure:"from"`
+}
+
+type WebhookConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Secret  string `mapstructure:"secret"`
+	// AllowPrivateCallbacks lets callback URLs resolve to private addresses,
+	// for receivers running next to the service in development.
+	AllowPrivateCallbacks bool                           `mapstructure:"allow_private_callbacks"`
+	Tenants               map[string]WebhookTenantConfig `mapstructure:"tenants"`
+}
+
+type WebhookTenantConfig struct {
+	URL    string `mapstructure:"url"`
+	Secret string `mapstructure:"secret"`
 }
 
 type RedisConfig struct {
@@ -2,11 +2,18 @@ package server
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"syscall"
 	"time"
 )
 
+// ErrNonPublicAddress is returned when a PublicTransport is asked to connect
+// to a loopback, private or link-local address.
+var ErrNonPublicAddress = errors.New("address is not public")
+
 type RequestOptions struct {
 	Method         string
 	URL            string
@@ -15,11 +22,14 @@ type RequestOptions struct {
 	MaxRetries     int
 	RetryDelay     time.Duration
 	RequestTimeout time.Duration
+	// Transport defaults to http.DefaultTransport.
+	Transport http.RoundTripper
 }
 
 func DoRequestWithRetry(opts RequestOptions) (*http.Response, error) {
 	client := &http.Client{
-		Timeout: opts.RequestTimeout,
+		Timeout:   opts.RequestTimeout,
+		Transport: opts.Transport,
 	}
 	var resp *http.Response
 	var err error
@@ -36,9 +46,44 @@ func DoRequestWithRetry(opts RequestOptions) (*http.Response, error) {
 		if err == nil && resp.StatusCode != int(500) {
 			return resp, nil
 		}
+		if errors.Is(err, ErrNonPublicAddress) {
+			return nil, err
+		}
 		fmt.Printf("Request failed (attempt %d/%d): %v\n", i+1, opts.MaxRetries, err)
 		time.Sleep(opts.RetryDelay)
 	}
 
 	return nil, fmt.Errorf("request failed after %d attempts: %w", opts.MaxRetries, err)
 }
+
+// IsPublicIP reports whether ip is a global unicast address outside the
+// private ranges.
+func IsPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// PublicTransport returns a transport that only connects to public addresses.
+// The check runs on the resolved address of every connection, redirects
+// included, so a hostname cannot point it at an internal service.
+func PublicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrNonPublicAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the callback, defeating the check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
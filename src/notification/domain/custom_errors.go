@@ -8,7 +8,8 @@ import (
 )
 
 type ErrorResponse struct {
-	Message string `json:"message"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"`
 }
 
 type NotFoundError struct {
@@ -19,16 +20,49 @@ func (e *NotFoundError) Error() string {
 	return fmt.Sprintf("Not Found: %s", e.Message)
 }
 
+type ConflictError struct {
+	Message string `json:"error"`
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("Conflict: %s", e.Message)
+}
+
+// FieldError is the error of one field of a request, named by its JSON path
+// such as location.latitude.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError rejects the field of a request. Fields details the errors
+// of the nested fields, when there are several.
+type ValidationError struct {
+	Field   string       `json:"field"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("Invalid %s: %s", e.Field, e.Message)
+}
+
+// ValidationErrorResponse writes a 400 response with the message of err and
+// its field errors.
+func ValidationErrorResponse(w http.ResponseWriter, module string, err *ValidationError) {
+	writeErrorResponse(w, module, http.StatusBadRequest, ErrorResponse{Message: err.Error(), Errors: err.Fields})
+}
+
 func ErrorResponseF(w http.ResponseWriter, module string, statusCode int, message string) {
-	log.Printf("%s %s", module, message)
+	writeErrorResponse(w, module, statusCode, ErrorResponse{Message: message})
+}
+
+func writeErrorResponse(w http.ResponseWriter, module string, statusCode int, response ErrorResponse) {
+	log.Printf("%s %s", module, response.Message)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 
-	response := ErrorResponse{
-		Message: message,
-	}
-
 	jsonResponse, err := json.Marshal(response)
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Lifecycle of a delivery. Delivered and cancelled deliveries are final and
+// no longer swept.
+const (
+	DeliveryScheduled = "scheduled"
+	DeliveryInTransit = "in_transit"
+	DeliveryDelivered = "delivered"
+	DeliveryCancelled = "cancelled"
+)
+
+const (
+	// DeliverySweepPending deliveries wait for the scheduled sweep of their
+	// delivery date.
+	DeliverySweepPending = "pending"
+	// DeliverySweepEvaluated deliveries had their forecast evaluated; the
+	// notification, when the buyer had to be warned, is in NotificationID.
+	DeliverySweepEvaluated = "evaluated"
+	// DeliverySweepFailed deliveries are evaluated again by the later sweeps
+	// run before their delivery date.
+	DeliverySweepFailed = "failed"
+)
+
+// Delivery is the shipment of an order of the OMS to a buyer. Upcoming
+// deliveries are evaluated by the scheduled sweep, which notifies the buyer
+// without an API call; their notifications reference the delivery ID.
+type Delivery struct {
+	ID          string `json:"id"`
+	OrderID     string `json:"order_id"`
+	Email       string `json:"email"`
+	Address     string `json:"address,omitempty"`
+	Carrier     string `json:"carrier,omitempty"`
+	Status      string `json:"status"`
+	Phone       string `json:"phone,omitempty"`
+	CallbackURL string `json:"callback_url,omitempty"`
+	Tenant      string `json:"tenant,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	// Location is nil until the address of the delivery is geocoded by the
+	// sweep.
+	Location       *DeliveryLocation `json:"location,omitempty"`
+	DeliveryDate   string            `json:"delivery_date"`
+	SweepStatus    string            `json:"sweep_status"`
+	SweepError     string            `json:"sweep_error,omitempty"`
+	SweptAt        *time.Time        `json:"swept_at,omitempty"`
+	NotificationID string            `json:"notification_id,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// Final reports whether the delivery was delivered or cancelled.
+func (d Delivery) Final() bool {
+	return d.Status == DeliveryDelivered || d.Status == DeliveryCancelled
+}
+
+type DeliveryRepository interface {
+	// SaveDelivery stores the delivery and indexes it by date and order.
+	SaveDelivery(ctx context.Context, delivery Delivery) error
+	GetDelivery(ctx context.Context, id string) (*Delivery, error)
+	GetDeliveryByOrder(ctx context.Context, orderID string) (*Delivery, error)
+	// GetDeliveryNotifications returns the notifications that reference the
+	// delivery, oldest first.
+	GetDeliveryNotifications(ctx context.Context, deliveryID string) ([]Notification, error)
+	// ListDeliveries returns the deliveries from one delivery date to another,
+	// both included, ordered by date.
+	ListDeliveries(ctx context.Context, from, to string) ([]Delivery, error)
+	// AcquireLock takes the lock name for ttl and reports false when another
+	// owner holds it.
+	AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+}
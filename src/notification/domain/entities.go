@@ -1,16 +1,143 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
-type DeliveryLocation struct {
-	Longitude string `json:"longitude"`
-	Latitude  string `json:"latitude"`
+const (
+	ChannelEmail   = "email"
+	ChannelSMS     = "sms"
+	ChannelWebhook = "webhook"
+)
+
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusSent    = "sent"
+	DeliveryStatusFailed  = "failed"
+	// DeliveryStatusPartial means some channels were sent and others failed.
+	DeliveryStatusPartial = "partial"
+	// DeliveryStatusSuppressed means the buyer opted out and nothing is sent.
+	DeliveryStatusSuppressed = "suppressed"
+	// DeliveryStatusDuplicate means the buyer was already notified of the
+	// delivery within the dedup window and nothing is sent.
+	DeliveryStatusDuplicate = "duplicate"
+)
+
+// ChannelDelivery is the delivery of a notification through one channel.
+// Recipient is the email, the E.164 phone or the webhook tenant or URL.
+type ChannelDelivery struct {
+	Channel   string `json:"channel"`
+	Recipient string `json:"recipient"`
+	Subject   string `json:"subject,omitempty"`
+	Message   string `json:"message"`
+	HTML      string `json:"html,omitempty"`
+	// UnsubscribeURL is the one-click unsubscribe link of email deliveries.
+	UnsubscribeURL string     `json:"unsubscribe_url,omitempty"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	SentAt         *time.Time `json:"sent_at,omitempty"`
 }
 
+// Notification is stored together with its outbox entry; the dispatcher
+// delivers each pending channel independently and keeps DeliveryStatus in
+// sync with the channels.
 type Notification struct {
-	Email             string           `json:"email"`
-	DeliveryLocation  DeliveryLocation `json:"location"`
-	ForecastCode      float64          `json:"forecast_code"`
-	BuyerNotification bool             `json:"buyer_notification"`
-	Created_at        time.Time        `json:"created_at"`
+	ID                string            `json:"id,omitempty"`
+	DeliveryID        string            `json:"delivery_id,omitempty"`
+	Email             string            `json:"email"`
+	DeliveryLocation  DeliveryLocation  `json:"location"`
+	DeliveryDate      string            `json:"delivery_date,omitempty"`
+	Locale            string            `json:"locale,omitempty"`
+	ForecastCode      float64           `json:"forecast_code"`
+	TriggeredHours    []string          `json:"triggered_hours,omitempty"`
+	FiredRules        []string          `json:"fired_rules,omitempty"`
+	BuyerNotification bool              `json:"buyer_notification"`
+	DeliveryStatus    string            `json:"delivery_status,omitempty"`
+	Channels          []ChannelDelivery `json:"channels,omitempty"`
+	// ScheduledAt defers the delivery of a notification created during the
+	// quiet hours of the buyer until they end.
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	Created_at  time.Time  `json:"created_at"`
+}
+
+// ChannelsStatus summarizes the status of the channels: pending while any
+// channel is pending, otherwise sent, failed or partial, ignoring suppressed
+// channels, or suppressed when every channel was. A notification without
+// channels has nothing to deliver and is failed.
+func (n Notification) ChannelsStatus() string {
+	if len(n.Channels) == 0 {
+		return DeliveryStatusFailed
+	}
+
+	sent, failed := 0, 0
+	for _, channel := range n.Channels {
+		switch channel.Status {
+		case DeliveryStatusPending:
+			return DeliveryStatusPending
+		case DeliveryStatusSent:
+			sent++
+		case DeliveryStatusFailed:
+			failed++
+		}
+	}
+
+	switch {
+	case sent == 0 && failed == 0:
+		return DeliveryStatusSuppressed
+	case failed == 0:
+		return DeliveryStatusSent
+	case sent == 0:
+		return DeliveryStatusFailed
+	default:
+		return DeliveryStatusPartial
+	}
+}
+
+// BuyerPreferences are the channels a buyer wants to be warned through and
+// the addresses used by those channels other than email. QuietHours replace
+// the global quiet hours and are read in Timezone when it is set.
+type BuyerPreferences struct {
+	Email      string      `json:"email"`
+	Channels   []string    `json:"channels"`
+	Phone      string      `json:"phone,omitempty"`
+	Webhook    string      `json:"webhook,omitempty"`
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	Timezone   string      `json:"timezone,omitempty"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+// QuietHours is the daily range, as HH:MM in the buyer timezone, in which no
+// message is sent. The range may cross midnight, like 22:00 to 08:00; an
+// empty range, where Start equals End, has no quiet time.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+const (
+	OptOutSourceAPI  = "api"
+	OptOutSourceLink = "link"
+)
+
+// OptOut records that a buyer asked to stop receiving weather warnings,
+// through the API or the unsubscribe link of an email.
+type OptOut struct {
+	Email     string    `json:"email"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NormalizeEmail returns the email trimmed and lowercased, the form buyers
+// are keyed by in opt-outs, unsubscribe tokens, dedup keys and the history.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+type NotificationCode struct {
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	UpdatedBy   string    `json:"updated_by"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
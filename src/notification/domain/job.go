@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+)
+
+type Job struct {
+	ID        string          `json:"id"`
+	Status    string          `json:"status"`
+	Payload   json.RawMessage `json:"payload"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func (j Job) Finished() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed
+}
+
+type JobRepository interface {
+	SaveJob(ctx context.Context, job Job) error
+	GetJob(ctx context.Context, id string) (*Job, error)
+	EnqueueJob(ctx context.Context, id string) error
+	// DequeueJob blocks up to timeout and returns an empty id when the queue
+	// is empty. The job stays in the processing list of worker until AckJob.
+	DequeueJob(ctx context.Context, worker string, timeout time.Duration) (string, error)
+	AckJob(ctx context.Context, worker string, id string) error
+	// RenewJobLease marks worker as alive for ttl.
+	RenewJobLease(ctx context.Context, worker string, ttl time.Duration) error
+	// RecoverJobs puts back in the queue the jobs left in processing by the
+	// workers whose lease expired before acknowledging them.
+	RecoverJobs(ctx context.Context) (int, error)
+}
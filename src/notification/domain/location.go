@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CoordinatePrecision is the number of decimals coordinates are kept with,
+// about 11 cm.
+const CoordinatePrecision = 6
+
+// DeliveryLocation is a point in decimal degrees.
+type DeliveryLocation struct {
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+}
+
+// NewDeliveryLocation validates the coordinates and rounds them to
+// CoordinatePrecision decimals. The ValidationError lists one FieldError per
+// invalid coordinate.
+func NewDeliveryLocation(latitude, longitude float64) (DeliveryLocation, error) {
+	if fields := ValidateCoordinates(latitude, longitude); len(fields) > 0 {
+		return DeliveryLocation{}, LocationValidationError("location", fields)
+	}
+
+	return DeliveryLocation{
+		Latitude:  roundCoordinate(latitude, CoordinatePrecision),
+		Longitude: roundCoordinate(longitude, CoordinatePrecision),
+	}, nil
+}
+
+// ValidateCoordinates returns the errors of the coordinates, named latitude
+// and longitude.
+func ValidateCoordinates(latitude, longitude float64) []FieldError {
+	var fields []FieldError
+	if message := LatitudeError(latitude); message != "" {
+		fields = append(fields, FieldError{Field: "latitude", Message: message})
+	}
+	if message := LongitudeError(longitude); message != "" {
+		fields = append(fields, FieldError{Field: "longitude", Message: message})
+	}
+	return fields
+}
+
+// LatitudeError describes why value is not a latitude, a finite number within
+// ±90, or returns "".
+func LatitudeError(value float64) string {
+	return coordinateError(value, 90)
+}
+
+// LongitudeError describes why value is not a longitude, a finite number
+// within ±180, or returns "".
+func LongitudeError(value float64) string {
+	return coordinateError(value, 180)
+}
+
+func coordinateError(value, limit float64) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "must be a number"
+	}
+	if value < -limit || value > limit {
+		return fmt.Sprintf("must be between -%g and %g", limit, limit)
+	}
+	return ""
+}
+
+// LocationValidationError returns the ValidationError of field from the errors
+// of its coordinates, prefixing each field with field.
+func LocationValidationError(field string, fields []FieldError) *ValidationError {
+	messages := make([]string, 0, len(fields))
+	prefixed := make([]FieldError, 0, len(fields))
+	for _, fieldError := range fields {
+		messages = append(messages, fieldError.Field+" "+fieldError.Message)
+		prefixed = append(prefixed, FieldError{Field: field + "." + fieldError.Field, Message: fieldError.Message})
+	}
+	return &ValidationError{Field: field, Message: strings.Join(messages, ", "), Fields: prefixed}
+}
+
+// roundCoordinate rounds value to precision decimals.
+func roundCoordinate(value float64, precision int) float64 {
+	factor := math.Pow(10, float64(precision))
+	rounded := math.Round(value*factor) / factor
+	// Normalise -0 so both sides of the equator look the same.
+	if rounded == 0 {
+		return 0
+	}
+	return rounded
+}
+
+// FormatCoordinate formats value with the fewest decimals that represent it.
+func FormatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// UnmarshalJSON also reads the records stored when the coordinates were
+// strings; an empty string is zero.
+func (l *DeliveryLocation) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Longitude json.RawMessage `json:"longitude"`
+		Latitude  json.RawMessage `json:"latitude"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	longitude, err := decodeCoordinate(raw.Longitude)
+	if err != nil {
+		return fmt.Errorf("error decoding longitude: %w", err)
+	}
+	latitude, err := decodeCoordinate(raw.Latitude)
+	if err != nil {
+		return fmt.Errorf("error decoding latitude: %w", err)
+	}
+
+	l.Longitude = longitude
+	l.Latitude = latitude
+	return nil
+}
+
+func decodeCoordinate(data json.RawMessage) (float64, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return 0, nil
+	}
+	if data[0] != '"' {
+		var value float64
+		err := json.Unmarshal(data, &value)
+		return value, err
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(strings.TrimSpace(text), 64)
+}
@@ -0,0 +1,47 @@
+package domain
+
+// MessageData is the data the message templates are executed with.
+type MessageData struct {
+	Email        string
+	DeliveryDate string
+	Tomorrow     bool
+	// ForecastCode is the condition ForecastDescription describes; the
+	// renderer replaces the description with the one of its locale.
+	ForecastCode        float64
+	ForecastDescription string
+	TriggeredHours      []string
+	FiredRules          []string
+	// UnsubscribeURL is empty when unsubscribe links are disabled.
+	UnsubscribeURL string
+}
+
+// RenderedMessage holds the messages of a notification in one locale: the
+// email subject and bodies and the short text used by SMS.
+type RenderedMessage struct {
+	Locale  string
+	Subject string
+	Text    string
+	HTML    string
+	SMS     string
+}
+
+// MessageRenderer renders the notification messages in the requested locale,
+// falling back to its default locale when that one is not available.
+type MessageRenderer interface {
+	Render(locale string, data MessageData) (*RenderedMessage, error)
+}
+
+// UnsubscribePageData is the data of the unsubscribe page: the confirmation
+// form for Token or, once Done, the confirmation.
+type UnsubscribePageData struct {
+	Email  string
+	Token  string
+	Locale string
+	Done   bool
+}
+
+// PageRenderer renders the HTML pages served to buyers in the requested
+// locale, falling back to its default locale.
+type PageRenderer interface {
+	RenderUnsubscribePage(locale string, data UnsubscribePageData) (string, error)
+}
@@ -1,5 +1,18 @@
 package domain
 
+// Message is the delivery of a notification through one channel. Recipient
+// is an email, an E.164 phone or a webhook tenant or URL, depending on
+// Channel. Subject, HTML and UnsubscribeURL are only used by email.
+type Message struct {
+	NotificationID string
+	Channel        string
+	Recipient      string
+	Subject        string
+	Text           string
+	HTML           string
+	UnsubscribeURL string
+}
+
 type NotificationSender interface {
-	Send(email string, text string) error
+	Send(message Message) error
 }
@@ -2,10 +2,36 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 type NotificationRepository interface {
 	SaveNotification(ctx context.Context, notification Notification) error
 	GetNotifications(ctx context.Context, email string) ([]Notification, error)
 	GetNotificationCodes(ctx context.Context) ([]string, error)
+	ListNotificationCodes(ctx context.Context) ([]NotificationCode, error)
+	SaveNotificationCode(ctx context.Context, code NotificationCode) error
+	DeleteNotificationCode(ctx context.Context, code string) error
+	ReplaceNotificationCodes(ctx context.Context, codes []NotificationCode) error
+	GetNotificationRules(ctx context.Context) ([]NotificationRule, error)
+	SaveNotificationRule(ctx context.Context, rule NotificationRule) error
+	DeleteNotificationRule(ctx context.Context, name string) error
+	// ClaimPendingNotifications returns up to limit notifications whose
+	// delivery is due and hides them from other dispatchers for lease.
+	ClaimPendingNotifications(ctx context.Context, limit int, lease time.Duration) ([]Notification, error)
+	// UpdateNotificationDelivery stores the delivery outcome. A zero retryAt
+	// removes the notification from the outbox.
+	UpdateNotificationDelivery(ctx context.Context, notification Notification, retryAt time.Time) error
+	// GetBuyerPreferences returns nil when the buyer has no preferences.
+	GetBuyerPreferences(ctx context.Context, email string) (*BuyerPreferences, error)
+	SaveBuyerPreferences(ctx context.Context, preferences BuyerPreferences) error
+	// GetOptOut returns nil when the buyer did not opt out.
+	GetOptOut(ctx context.Context, email string) (*OptOut, error)
+	SaveOptOut(ctx context.Context, optOut OptOut) error
+	DeleteOptOut(ctx context.Context, email string) error
+	// ReserveDedupKey stores notificationID under key for window unless the
+	// key exists, and returns the id holding the key: notificationID when it
+	// was reserved, the first notification otherwise.
+	ReserveDedupKey(ctx context.Context, key, notificationID string, window time.Duration) (string, error)
+	ReleaseDedupKey(ctx context.Context, key string) error
 }
@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	MetricCode         = "code"
+	MetricPrecipMm     = "precip_mm"
+	MetricWindKph      = "wind_kph"
+	MetricMaxTempC     = "max_temp_c"
+	MetricMinTempC     = "min_temp_c"
+	MetricChanceOfRain = "chance_of_rain"
+	MetricSnowCm       = "snow_cm"
+
+	RuleOperatorAnd = "and"
+	RuleOperatorOr  = "or"
+)
+
+// NotificationRule fires when its conditions hold, all of them for the "and"
+// operator (the default) or at least one for "or".
+type NotificationRule struct {
+	Name       string          `json:"name"`
+	Operator   string          `json:"operator"`
+	Conditions []RuleCondition `json:"conditions"`
+	UpdatedBy  string          `json:"updated_by,omitempty"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// RuleCondition compares one forecast metric. Numeric metrics use gt, gte,
+// lt, lte or eq against Value; the code metric uses "in" against Codes.
+type RuleCondition struct {
+	Metric   string   `json:"metric"`
+	Operator string   `json:"operator"`
+	Value    float64  `json:"value,omitempty"`
+	Codes    []string `json:"codes,omitempty"`
+}
+
+type ForecastMetrics struct {
+	Code         float64
+	PrecipMm     float64
+	WindKph      float64
+	MaxTempC     float64
+	MinTempC     float64
+	ChanceOfRain float64
+	SnowCm       float64
+}
+
+// Validate returns a ValidationError when Matches would reject the rule.
+func (r NotificationRule) Validate() error {
+	if r.Operator != "" && r.Operator != RuleOperatorAnd && r.Operator != RuleOperatorOr {
+		return &ValidationError{Field: "operator", Message: fmt.Sprintf("must be %s or %s", RuleOperatorAnd, RuleOperatorOr)}
+	}
+	if len(r.Conditions) == 0 {
+		return &ValidationError{Field: "conditions", Message: "must not be empty"}
+	}
+	for i, condition := range r.Conditions {
+		if message := condition.validationMessage(); message != "" {
+			return &ValidationError{Field: fmt.Sprintf("conditions[%d]", i), Message: message}
+		}
+	}
+	return nil
+}
+
+func (c RuleCondition) validationMessage() string {
+	if c.Metric == MetricCode {
+		if c.Operator != "in" {
+			return "metric code only supports the in operator"
+		}
+		if len(c.Codes) == 0 {
+			return "codes must not be empty"
+		}
+		return ""
+	}
+
+	if _, err := (ForecastMetrics{}).value(c.Metric); err != nil {
+		return err.Error()
+	}
+	switch c.Operator {
+	case "gt", "gte", "lt", "lte", "eq":
+		return ""
+	}
+	return fmt.Sprintf("unknown comparison %q for metric %s", c.Operator, c.Metric)
+}
+
+func (r NotificationRule) Matches(metrics ForecastMetrics) (bool, error) {
+	if len(r.Conditions) == 0 {
+		return false, fmt.Errorf("rule %s has no conditions", r.Name)
+	}
+
+	matchAny := r.Operator == RuleOperatorOr
+	if !matchAny && r.Operator != "" && r.Operator != RuleOperatorAnd {
+		return false, fmt.Errorf("rule %s: unknown operator %q", r.Name, r.Operator)
+	}
+
+	for _, condition := range r.Conditions {
+		matches, err := condition.Matches(metrics)
+		if err != nil {
+			return false, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		if matchAny && matches {
+			return true, nil
+		}
+		if !matchAny && !matches {
+			return false, nil
+		}
+	}
+
+	return !matchAny, nil
+}
+
+func (c RuleCondition) Matches(metrics ForecastMetrics) (bool, error) {
+	if c.Metric == MetricCode {
+		if c.Operator != "in" {
+			return false, fmt.Errorf("metric code only supports the in operator")
+		}
+		return slices.Contains(c.Codes, strconv.FormatFloat(metrics.Code, 'f', -1, 64)), nil
+	}
+
+	value, err := metrics.value(c.Metric)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.Operator {
+	case "gt":
+		return value > c.Value, nil
+	case "gte":
+		return value >= c.Value, nil
+	case "lt":
+		return value < c.Value, nil
+	case "lte":
+		return value <= c.Value, nil
+	case "eq":
+		return value == c.Value, nil
+	}
+
+	return false, fmt.Errorf("unknown comparison %q for metric %s", c.Operator, c.Metric)
+}
+
+func (m ForecastMetrics) value(metric string) (float64, error) {
+	switch metric {
+	case MetricPrecipMm:
+		return m.PrecipMm, nil
+	case MetricWindKph:
+		return m.WindKph, nil
+	case MetricMaxTempC:
+		return m.MaxTempC, nil
+	case MetricMinTempC:
+		return m.MinTempC, nil
+	case MetricChanceOfRain:
+		return m.ChanceOfRain, nil
+	case MetricSnowCm:
+		return m.SnowCm, nil
+	}
+
+	return 0, fmt.Errorf("unknown metric %q", metric)
+}
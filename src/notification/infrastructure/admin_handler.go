@@ -0,0 +1,171 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/juandr89/delivery-notifier-buyer/middleware"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+)
+
+type AdminHandler struct {
+	NotificationRepository domain.NotificationRepository
+}
+
+func NewAdminHandler(repo domain.NotificationRepository) *AdminHandler {
+	return &AdminHandler{
+		NotificationRepository: repo,
+	}
+}
+
+func (c *AdminHandler) ListCodes(w http.ResponseWriter, r *http.Request) {
+	result, err := usecases.ListNotificationCodes(c.NotificationRepository)
+	if err != nil {
+		domain.ErrorResponseF(w, "ListCodes", http.StatusInternalServerError, "Unexpected error has ocurred")
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (c *AdminHandler) AddCode(w http.ResponseWriter, r *http.Request) {
+	var request usecases.RequestNotificationCode
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		domain.ErrorResponseF(w, "AddCode", http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	user := middleware.AdminUserFromContext(r.Context())
+	log.Printf("AddCode request [%s] %s", user, request.Code)
+
+	result, err := usecases.AddNotificationCode(request, user, c.NotificationRepository)
+	if err != nil {
+		writeServiceError(w, "AddCode", err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonResponse)
+}
+
+func (c *AdminHandler) ReplaceCodes(w http.ResponseWriter, r *http.Request) {
+	var requests []usecases.RequestNotificationCode
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&requests); err != nil {
+		domain.ErrorResponseF(w, "ReplaceCodes", http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	user := middleware.AdminUserFromContext(r.Context())
+	log.Printf("ReplaceCodes request [%s] %d codes", user, len(requests))
+
+	confirm := r.URL.Query().Get("confirm") == "true"
+	result, err := usecases.ReplaceNotificationCodes(requests, confirm, user, c.NotificationRepository)
+	if err != nil {
+		writeServiceError(w, "ReplaceCodes", err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (c *AdminHandler) RemoveCode(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	code := vars["code"]
+
+	user := middleware.AdminUserFromContext(r.Context())
+	log.Printf("RemoveCode request [%s] %s", user, code)
+
+	confirm := r.URL.Query().Get("confirm") == "true"
+	if err := usecases.RemoveNotificationCode(code, confirm, user, c.NotificationRepository); err != nil {
+		writeServiceError(w, "RemoveCode", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *AdminHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	result, err := usecases.ListNotificationRules(c.NotificationRepository)
+	if err != nil {
+		domain.ErrorResponseF(w, "ListRules", http.StatusInternalServerError, "Unexpected error has ocurred")
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (c *AdminHandler) SaveRule(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	var request usecases.RequestNotificationRule
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		domain.ErrorResponseF(w, "SaveRule", http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	user := middleware.AdminUserFromContext(r.Context())
+	log.Printf("SaveRule request [%s] %s", user, name)
+
+	result, err := usecases.SaveNotificationRule(name, request, user, c.NotificationRepository)
+	if err != nil {
+		writeServiceError(w, "SaveRule", err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (c *AdminHandler) RemoveRule(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	user := middleware.AdminUserFromContext(r.Context())
+	log.Printf("RemoveRule request [%s] %s", user, name)
+
+	if err := usecases.RemoveNotificationRule(name, user, c.NotificationRepository); err != nil {
+		writeServiceError(w, "RemoveRule", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeServiceError(w http.ResponseWriter, module string, err error) {
+	switch e := err.(type) {
+	case *domain.ValidationError:
+		domain.ValidationErrorResponse(w, module, e)
+	case *domain.NotFoundError:
+		domain.ErrorResponseF(w, module, http.StatusNotFound, e.Message)
+	case *domain.ConflictError:
+		domain.ErrorResponseF(w, module, http.StatusConflict, e.Message)
+	default:
+		domain.ErrorResponseF(w, module, http.StatusInternalServerError, "Unexpected error has ocurred")
+	}
+}
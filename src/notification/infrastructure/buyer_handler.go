@@ -0,0 +1,162 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+)
+
+// BuyerHandler manages the notification preferences and the subscription of
+// the buyers.
+type BuyerHandler struct {
+	NotificationRepository domain.NotificationRepository
+	PageRenderer           domain.PageRenderer
+	Config                 server.Config
+}
+
+func NewBuyerHandler(repo domain.NotificationRepository, renderer domain.PageRenderer, cfg server.Config) *BuyerHandler {
+	return &BuyerHandler{
+		NotificationRepository: repo,
+		PageRenderer:           renderer,
+		Config:                 cfg,
+	}
+}
+
+func (c *BuyerHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	email := mux.Vars(r)["email"]
+
+	log.Printf("GetPreferences request [%s]", email)
+
+	result, err := usecases.GetBuyerPreferences(email, c.NotificationRepository)
+	if err != nil {
+		writeServiceError(w, "GetPreferences", err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (c *BuyerHandler) SavePreferences(w http.ResponseWriter, r *http.Request) {
+	email := mux.Vars(r)["email"]
+	if !usecases.IsValidEmail(email) {
+		domain.ErrorResponseF(w, "SavePreferences", http.StatusBadRequest, "Invalid email")
+		return
+	}
+
+	var request usecases.BuyerPreferencesRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		domain.ErrorResponseF(w, "SavePreferences", http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	log.Printf("SavePreferences request [%s] %v", email, request.Channels)
+
+	result, err := usecases.SaveBuyerPreferences(email, request, c.NotificationRepository)
+	if err != nil {
+		writeServiceError(w, "SavePreferences", err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+// Subscribe removes the buyer from the opt-out list.
+func (c *BuyerHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	c.updateSubscription(w, r, "Subscribe", func(email string) (*usecases.SubscriptionResponse, error) {
+		return usecases.Resubscribe(email, c.NotificationRepository)
+	})
+}
+
+// Unsubscribe adds the buyer to the opt-out list.
+func (c *BuyerHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	c.updateSubscription(w, r, "Unsubscribe", func(email string) (*usecases.SubscriptionResponse, error) {
+		return usecases.Unsubscribe(email, domain.OptOutSourceAPI, c.NotificationRepository)
+	})
+}
+
+func (c *BuyerHandler) updateSubscription(w http.ResponseWriter, r *http.Request, module string, update func(email string) (*usecases.SubscriptionResponse, error)) {
+	email := mux.Vars(r)["email"]
+	if !usecases.IsValidEmail(email) {
+		domain.ErrorResponseF(w, module, http.StatusBadRequest, "Invalid email")
+		return
+	}
+
+	log.Printf("%s request [%s]", module, email)
+
+	result, err := update(email)
+	if err != nil {
+		writeServiceError(w, module, err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+// UnsubscribePage is the target of the unsubscribe link of the emails. It
+// asks for confirmation, so link scanners opening it do not unsubscribe.
+func (c *BuyerHandler) UnsubscribePage(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	email, err := usecases.VerifyUnsubscribeToken(token, c.Config.UnsubscribeConfig.Secret)
+	if err != nil {
+		domain.ErrorResponseF(w, "UnsubscribePage", http.StatusBadRequest, "Invalid token: is not a valid unsubscribe token")
+		return
+	}
+
+	c.writeUnsubscribePage(w, r, "UnsubscribePage", domain.UnsubscribePageData{Email: email, Token: token})
+}
+
+// UnsubscribeOneClick opts out the buyer of the token. It handles both the
+// confirmation form and the RFC 8058 one-click POST of the mail clients.
+func (c *BuyerHandler) UnsubscribeOneClick(w http.ResponseWriter, r *http.Request) {
+	log.Printf("UnsubscribeOneClick request")
+
+	result, err := usecases.UnsubscribeWithToken(r.URL.Query().Get("token"), c.Config.UnsubscribeConfig.Secret, c.NotificationRepository)
+	if err != nil {
+		writeServiceError(w, "UnsubscribeOneClick", err)
+		return
+	}
+
+	c.writeUnsubscribePage(w, r, "UnsubscribeOneClick", domain.UnsubscribePageData{Email: result.Email, Done: true})
+}
+
+// writeUnsubscribePage renders the page in the locale of the link or, without
+// one, the first language the browser accepts.
+func (c *BuyerHandler) writeUnsubscribePage(w http.ResponseWriter, r *http.Request, module string, data domain.UnsubscribePageData) {
+	data.Locale = r.URL.Query().Get("locale")
+	locale := data.Locale
+	if locale == "" {
+		locale, _, _ = strings.Cut(r.Header.Get("Accept-Language"), ",")
+		locale, _, _ = strings.Cut(locale, ";")
+	}
+
+	page, err := c.PageRenderer.RenderUnsubscribePage(locale, data)
+	if err != nil {
+		log.Printf("%s: %v", module, err)
+		domain.ErrorResponseF(w, module, http.StatusInternalServerError, "Unexpected error has ocurred")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(page))
+}
@@ -0,0 +1,188 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+)
+
+type DeliveryHandler struct {
+	DeliveryRepository domain.DeliveryRepository
+	Config             server.Config
+}
+
+func NewDeliveryHandler(deliveryRepository domain.DeliveryRepository, cfg server.Config) *DeliveryHandler {
+	return &DeliveryHandler{
+		DeliveryRepository: deliveryRepository,
+		Config:             cfg,
+	}
+}
+
+// DeliverySchedule returns the cron schedule of the sweep and the timezone it
+// is read in, or a nil schedule when the sweep is disabled.
+func DeliverySchedule(cfg server.Config) (*usecases.CronSchedule, *time.Location, error) {
+	if cfg.SchedulerConfig.Cron == "" {
+		return nil, nil, nil
+	}
+
+	schedule, err := usecases.ParseCronSchedule(cfg.SchedulerConfig.Cron)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	location := time.UTC
+	if cfg.SchedulerConfig.Timezone != "" {
+		location, err = time.LoadLocation(cfg.SchedulerConfig.Timezone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid scheduler timezone %q", cfg.SchedulerConfig.Timezone)
+		}
+	}
+	return schedule, location, nil
+}
+
+func (c *DeliveryHandler) RegisterDelivery(w http.ResponseWriter, r *http.Request) {
+	var request usecases.RequestDelivery
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		domain.ErrorResponseF(w, "RegisterDelivery", http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	if !usecases.IsValidEmail(request.Email) {
+		domain.ErrorResponseF(w, "RegisterDelivery", http.StatusBadRequest, "Invalid email")
+		return
+	}
+
+	if request.Phone != "" && !usecases.IsValidPhone(request.Phone) {
+		domain.ErrorResponseF(w, "RegisterDelivery", http.StatusBadRequest, "Invalid phone")
+		return
+	}
+
+	if request.CallbackURL != "" && !usecases.IsValidCallbackURL(request.CallbackURL) {
+		domain.ErrorResponseF(w, "RegisterDelivery", http.StatusBadRequest, "Invalid callback_url")
+		return
+	}
+
+	log.Printf("RegisterDelivery request [%s] order %s %s", request.Email, request.OrderID, request.DeliveryDate)
+
+	result, err := usecases.RegisterDelivery(request, c.DeliveryRepository)
+	if err != nil {
+		writeServiceError(w, "RegisterDelivery", err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(jsonResponse)
+}
+
+// GetDeliverySchedule lists the registered deliveries between the from and to
+// query dates together with the time of the next sweep.
+func (c *DeliveryHandler) GetDeliverySchedule(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	result, err := usecases.ListDeliverySchedule(query.Get("from"), query.Get("to"), c.DeliveryRepository)
+	if err != nil {
+		writeServiceError(w, "GetDeliverySchedule", err)
+		return
+	}
+
+	if schedule, location, err := DeliverySchedule(c.Config); err == nil && schedule != nil {
+		if next := schedule.Next(time.Now().In(location)); !next.IsZero() {
+			result.NextSweepAt = &next
+		}
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (c *DeliveryHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	result, err := usecases.GetDelivery(id, c.DeliveryRepository)
+	if err != nil {
+		writeServiceError(w, "GetDelivery", err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+// UpdateDelivery changes the status, delivery date or carrier of a delivery.
+// It serves PATCH /deliveries/{id}.
+func (c *DeliveryHandler) UpdateDelivery(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var request usecases.RequestUpdateDelivery
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		domain.ErrorResponseF(w, "UpdateDelivery", http.StatusBadRequest, "Invalid JSON data")
+		return
+	}
+
+	log.Printf("UpdateDelivery request [%s] status %s", id, request.Status)
+
+	result, err := usecases.UpdateDelivery(id, request, c.DeliveryRepository)
+	if err != nil {
+		writeServiceError(w, "UpdateDelivery", err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (c *DeliveryHandler) GetOrderDelivery(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["order_id"]
+
+	result, err := usecases.GetOrderDelivery(orderID, c.DeliveryRepository)
+	if err != nil {
+		writeServiceError(w, "GetOrderDelivery", err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+func (c *DeliveryHandler) OrderNotifications(w http.ResponseWriter, r *http.Request) {
+	orderID := mux.Vars(r)["order_id"]
+
+	log.Printf("OrderNotifications request [%s]", orderID)
+
+	result, err := usecases.GetOrderNotifications(orderID, c.DeliveryRepository)
+	if err != nil {
+		writeServiceError(w, "OrderNotifications", err)
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"regexp"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/juandr89/delivery-notifier-buyer/server"
@@ -15,50 +15,71 @@ import (
 
 type NotificationHandler struct {
 	NotificationRepository domain.NotificationRepository
-	NotificationSender     domain.NotificationSender
-	Config                 server.Config
+	MessageRenderer        domain.MessageRenderer
+	ForecastService        third_party.IForecastService
+	// Geocoder resolves the address of the requests without coordinates; nil
+	// disables geocoding.
+	Geocoder third_party.Geocoder
+	Config   server.Config
 }
 
-func NewNotificationHandler(repo domain.NotificationRepository, sender domain.NotificationSender, cfg server.Config) *NotificationHandler {
+func NewNotificationHandler(repo domain.NotificationRepository, renderer domain.MessageRenderer, forecastService third_party.IForecastService, cfg server.Config) *NotificationHandler {
 	return &NotificationHandler{
 		NotificationRepository: repo,
-		NotificationSender:     sender,
+		MessageRenderer:        renderer,
+		ForecastService:        forecastService,
 		Config:                 cfg,
 	}
 }
 
-func isValidEmail(email string) bool {
-	var emailRegex = regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
-	return emailRegex.MatchString(email)
+// NotificationSettings returns the settings of usecases.SendNotification from
+// the configuration.
+func NotificationSettings(cfg server.Config) usecases.NotificationSettings {
+	return usecases.NotificationSettings{
+		Unsubscribe: usecases.UnsubscribeLinks{
+			Secret:  cfg.UnsubscribeConfig.Secret,
+			BaseURL: cfg.UnsubscribeConfig.BaseURL,
+		},
+		DedupWindow:    time.Duration(cfg.DedupConfig.WindowSeconds) * time.Second,
+		DedupPrecision: cfg.DedupConfig.LocationPrecision,
+		QuietHours: domain.QuietHours{
+			Start: cfg.QuietHoursConfig.Start,
+			End:   cfg.QuietHoursConfig.End,
+		},
+		DefaultTimezone: cfg.QuietHoursConfig.DefaultTimezone,
+	}
 }
 
-func (c *NotificationHandler) NotifyBuyer(w http.ResponseWriter, r *http.Request) {
+func (c *NotificationHandler) notificationSettings() usecases.NotificationSettings {
+	settings := NotificationSettings(c.Config)
+	settings.Geocoder = c.Geocoder
+	return settings
+}
 
-	var requestDataNotification usecases.RequestDataNotification
-	decoder := json.NewDecoder(r.Body)
-	decoder.DisallowUnknownFields()
-	if err := decoder.Decode(&requestDataNotification); err != nil {
-		domain.ErrorResponseF(w, "NotifyBuyer", http.StatusBadRequest, "Invalid JSON data")
-		return
-	}
+func (c *NotificationHandler) NotifyBuyer(w http.ResponseWriter, r *http.Request) {
 
-	if !isValidEmail(requestDataNotification.Email) {
-		domain.ErrorResponseF(w, "NotifyBuyer", http.StatusBadRequest, "Invalid email")
+	requestDataNotification, ok := decodeNotificationRequest(w, r, "NotifyBuyer")
+	if !ok {
 		return
 	}
 
 	log.Printf("NotifyBuyer request [%s] %s", requestDataNotification.Email, requestDataNotification.Location)
 
-	forecastService, err := third_party.NewForecastService(&c.Config)
+	forecastService, err := c.forecastService(r)
 	if err != nil {
 		domain.ErrorResponseF(w, "NotifyBuyer", http.StatusInternalServerError, err.Error())
 		return
 	}
 	log.Printf("NotifyBuyer request %s", forecastService)
 
-	result, err := usecases.SendNotification(requestDataNotification, forecastService, c.NotificationRepository, c.NotificationSender)
+	result, err := usecases.SendNotification(requestDataNotification, forecastService, c.NotificationRepository, c.MessageRenderer, c.notificationSettings())
 
 	if err != nil {
+		if validationErr, ok := err.(*domain.ValidationError); ok {
+			domain.ValidationErrorResponse(w, "NotifyBuyer", validationErr)
+			return
+		}
+
 		domain.ErrorResponseF(w, "NotifyBuyer", http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -71,6 +92,89 @@ func (c *NotificationHandler) NotifyBuyer(w http.ResponseWriter, r *http.Request
 	w.Write(jsonResponse)
 }
 
+// decodeNotificationRequest reads a RequestDataNotification from the body,
+// writing a 400 response when it is malformed.
+func decodeNotificationRequest(w http.ResponseWriter, r *http.Request, module string) (usecases.RequestDataNotification, bool) {
+	var requestDataNotification usecases.RequestDataNotification
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&requestDataNotification); err != nil {
+		domain.ErrorResponseF(w, module, http.StatusBadRequest, "Invalid JSON data")
+		return requestDataNotification, false
+	}
+
+	if !usecases.IsValidEmail(requestDataNotification.Email) {
+		domain.ErrorResponseF(w, module, http.StatusBadRequest, "Invalid email")
+		return requestDataNotification, false
+	}
+
+	if requestDataNotification.Phone != "" && !usecases.IsValidPhone(requestDataNotification.Phone) {
+		domain.ErrorResponseF(w, module, http.StatusBadRequest, "Invalid phone")
+		return requestDataNotification, false
+	}
+
+	if requestDataNotification.CallbackURL != "" && !usecases.IsValidCallbackURL(requestDataNotification.CallbackURL) {
+		domain.ErrorResponseF(w, module, http.StatusBadRequest, "Invalid callback_url")
+		return requestDataNotification, false
+	}
+
+	if err := usecases.ValidateRequestLocation(requestDataNotification); err != nil {
+		writeServiceError(w, module, err)
+		return requestDataNotification, false
+	}
+
+	return requestDataNotification, true
+}
+
+func (c *NotificationHandler) NotifyBuyersBatch(w http.ResponseWriter, r *http.Request) {
+	items, err := usecases.ParseBatchRequests(r.Body, c.Config.BatchConfig.MaxItems)
+	if err != nil {
+		if validationErr, ok := err.(*domain.ValidationError); ok {
+			domain.ValidationErrorResponse(w, "NotifyBuyersBatch", validationErr)
+			return
+		}
+
+		domain.ErrorResponseF(w, "NotifyBuyersBatch", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Printf("NotifyBuyersBatch request %d items", len(items))
+
+	forecastService, err := c.forecastService(r)
+	if err != nil {
+		domain.ErrorResponseF(w, "NotifyBuyersBatch", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := usecases.SendBatchNotifications(r.Context(), items, c.Config.BatchConfig.MaxConcurrency, forecastService, c.NotificationRepository, c.MessageRenderer, c.notificationSettings())
+	if err := r.Context().Err(); err != nil {
+		log.Printf("NotifyBuyersBatch cancelled after sent %d skipped %d invalid %d failed %d cancelled %d: %s", result.Sent, result.Skipped, result.Invalid, result.Failed, result.Cancelled, err)
+		return
+	}
+
+	log.Printf("NotifyBuyersBatch response total %d sent %d skipped %d invalid %d failed %d", result.Total, result.Sent, result.Skipped, result.Invalid, result.Failed)
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
+
+// forecastService returns the shared forecast service, skipping its cache
+// when the request carries "Cache-Control: no-cache".
+func (c *NotificationHandler) forecastService(r *http.Request) (third_party.IForecastService, error) {
+	if c.ForecastService == nil {
+		return third_party.NewForecastService(&c.Config)
+	}
+
+	if cached, ok := c.ForecastService.(*third_party.CachedForecastService); ok && r.Header.Get("Cache-Control") == "no-cache" {
+		return cached.Bypass(), nil
+	}
+
+	return c.ForecastService, nil
+}
+
 func (c *NotificationHandler) BuyerNotifications(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	email := vars["email"]
@@ -97,3 +201,17 @@ func (c *NotificationHandler) BuyerNotifications(w http.ResponseWriter, r *http.
 	w.Write(jsonResponse)
 
 }
+
+func (c *NotificationHandler) ForecastCacheStats(w http.ResponseWriter, r *http.Request) {
+	cached, ok := c.ForecastService.(*third_party.CachedForecastService)
+	if !ok {
+		domain.ErrorResponseF(w, "ForecastCacheStats", http.StatusNotFound, "Forecast cache is disabled")
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(cached.Stats())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
@@ -0,0 +1,89 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+)
+
+type JobHandler struct {
+	JobRepository   domain.JobRepository
+	ForecastService third_party.IForecastService
+	Config          server.Config
+}
+
+func NewJobHandler(jobRepository domain.JobRepository, forecastService third_party.IForecastService, cfg server.Config) *JobHandler {
+	return &JobHandler{
+		JobRepository:   jobRepository,
+		ForecastService: forecastService,
+		Config:          cfg,
+	}
+}
+
+// NotifyBuyerAsync queues the notification and answers 202 with the job that
+// tracks it. It serves POST /notifications?async=true.
+func (c *JobHandler) NotifyBuyerAsync(w http.ResponseWriter, r *http.Request) {
+	requestDataNotification, ok := decodeNotificationRequest(w, r, "NotifyBuyerAsync")
+	if !ok {
+		return
+	}
+
+	log.Printf("NotifyBuyerAsync request [%s] %s", requestDataNotification.Email, requestDataNotification.Location)
+
+	forecastService := c.ForecastService
+	if forecastService == nil {
+		var err error
+		forecastService, err = third_party.NewForecastService(&c.Config)
+		if err != nil {
+			domain.ErrorResponseF(w, "NotifyBuyerAsync", http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	result, err := usecases.EnqueueNotificationJob(requestDataNotification, forecastService, c.JobRepository)
+	if err != nil {
+		if validationErr, ok := err.(*domain.ValidationError); ok {
+			domain.ValidationErrorResponse(w, "NotifyBuyerAsync", validationErr)
+			return
+		}
+
+		domain.ErrorResponseF(w, "NotifyBuyerAsync", http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	log.Printf("NotifyBuyerAsync response %s", jsonResponse)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/jobs/%s", result.JobID))
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(jsonResponse)
+}
+
+func (c *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	result, err := usecases.GetJob(id, c.JobRepository)
+	if err != nil {
+		if notFoundErr, ok := err.(*domain.NotFoundError); ok {
+			domain.ErrorResponseF(w, "GetJob", http.StatusNotFound, notFoundErr.Message)
+			return
+		}
+
+		domain.ErrorResponseF(w, "GetJob", http.StatusInternalServerError, "Unexpected error has ocurred")
+		return
+	}
+
+	jsonResponse, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonResponse)
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/juandr89/delivery-notifier-buyer/server"
@@ -49,14 +52,46 @@ func NewNotificationRepository(redisConfig server.RedisConfig) *RedisRepository
 	}
 }
 
+// claimOutboxScript moves the due entries of the outbox forward by the lease
+// so concurrent dispatchers never claim the same notification twice.
+const claimOutboxScript = `
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, id in ipairs(ids) do
+	redis.call('ZADD', KEYS[1], ARGV[2], id)
+end
+return ids
+`
+
+func notificationRecordKey(id string) string {
+	return fmt.Sprintf("notification:records:%s", id)
+}
+
+// SaveNotification stores the notification, indexes it by email and, when it
+// is pending, adds it to the outbox in the same transaction. A scheduled
+// notification becomes due in the outbox at ScheduledAt. Notifications of a
+// delivery are indexed by it too.
 func (r *RedisRepository) SaveNotification(ctx context.Context, notification domain.Notification) error {
 	data, err := json.Marshal(notification)
 	if err != nil {
 		return fmt.Errorf("error when try to map Notification it JSON: %w", err)
 	}
 
-	key := fmt.Sprintf("notifications:%s", notification.Email)
-	err = r.Client.RPush(ctx, key, data).Err()
+	key := notificationsKey(notification.Email)
+	_, err = r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, notificationRecordKey(notification.ID), data, 0)
+		pipe.RPush(ctx, key, notification.ID)
+		if notification.DeliveryID != "" {
+			pipe.RPush(ctx, deliveryNotificationsKey(notification.DeliveryID), notification.ID)
+		}
+		if notification.DeliveryStatus == domain.DeliveryStatusPending {
+			dueAt := time.Now()
+			if notification.ScheduledAt != nil {
+				dueAt = *notification.ScheduledAt
+			}
+			pipe.ZAdd(ctx, "notification:outbox", redis.Z{Score: float64(dueAt.UnixMilli()), Member: notification.ID})
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("error while saving Notification in Redis: %w", err)
 	}
@@ -64,8 +99,14 @@ func (r *RedisRepository) SaveNotification(ctx context.Context, notification dom
 	return nil
 }
 
+func notificationsKey(email string) string {
+	return fmt.Sprintf("notifications:%s", domain.NormalizeEmail(email))
+}
+
+// GetNotifications returns the history of the email. Entries saved before the
+// outbox existed hold the whole notification instead of its id.
 func (r *RedisRepository) GetNotifications(ctx context.Context, email string) ([]domain.Notification, error) {
-	emailKey := fmt.Sprintf("notifications:%s", email)
+	emailKey := notificationsKey(email)
 	values, err := r.Client.LRange(ctx, emailKey, 0, -1).Result()
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener el historial de notificaciones de Redis: %w", err)
@@ -75,26 +116,351 @@ func (r *RedisRepository) GetNotifications(ctx context.Context, email string) ([
 		return nil, &domain.NotFoundError{Message: fmt.Sprintf("Notifications with email %s not found", email)}
 	}
 
-	notifications := make([]domain.Notification, len(values))
-	for i, value := range values {
+	var ids []string
+	for _, value := range values {
+		if !strings.HasPrefix(value, "{") {
+			ids = append(ids, value)
+		}
+	}
+	records, err := r.getNotificationRecords(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]domain.Notification, len(records))
+	for _, record := range records {
+		byID[record.ID] = record
+	}
+
+	notifications := make([]domain.Notification, 0, len(values))
+	for _, value := range values {
+		if !strings.HasPrefix(value, "{") {
+			if notification, ok := byID[value]; ok {
+				notifications = append(notifications, notification)
+			}
+			continue
+		}
+
 		var notification domain.Notification
 		if err := json.Unmarshal([]byte(value), &notification); err != nil {
 			return nil, fmt.Errorf("error decoding notification: %w", err)
 		}
-		notifications[i] = notification
+		notifications = append(notifications, notification)
 	}
 	return notifications, nil
 }
 
+func (r *RedisRepository) getNotificationRecords(ctx context.Context, ids []string) ([]domain.Notification, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = notificationRecordKey(id)
+	}
+
+	values, err := r.Client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting notifications from Redis: %w", err)
+	}
+
+	notifications := make([]domain.Notification, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		var notification domain.Notification
+		if err := json.Unmarshal([]byte(data), &notification); err != nil {
+			return nil, fmt.Errorf("error decoding notification %s: %w", ids[i], err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+func (r *RedisRepository) ClaimPendingNotifications(ctx context.Context, limit int, lease time.Duration) ([]domain.Notification, error) {
+	now := time.Now()
+	ids, err := r.Client.Eval(ctx, claimOutboxScript, []string{"notification:outbox"}, now.UnixMilli(), now.Add(lease).UnixMilli(), limit).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("error claiming outbox notifications in Redis: %w", err)
+	}
+
+	return r.getNotificationRecords(ctx, ids)
+}
+
+func (r *RedisRepository) UpdateNotificationDelivery(ctx context.Context, notification domain.Notification, retryAt time.Time) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("error when try to map Notification it JSON: %w", err)
+	}
+
+	_, err = r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, notificationRecordKey(notification.ID), data, 0)
+		if retryAt.IsZero() {
+			pipe.ZRem(ctx, "notification:outbox", notification.ID)
+		} else {
+			pipe.ZAdd(ctx, "notification:outbox", redis.Z{Score: float64(retryAt.UnixMilli()), Member: notification.ID})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error while updating Notification delivery in Redis: %w", err)
+	}
+
+	return nil
+}
+
+// GetNotificationCodes returns the registered codes; an empty list means no
+// forecast code triggers a notification.
 func (r *RedisRepository) GetNotificationCodes(ctx context.Context) ([]string, error) {
 	values, err := r.Client.LRange(ctx, "notification:codes", 0, -1).Result()
 	if err != nil {
 		return nil, fmt.Errorf("error getting notification history from Redis: %w", err)
 	}
 
-	if len(values) < 1 {
-		return nil, &domain.NotFoundError{Message: "Notification codes not found"}
+	return values, nil
+}
+
+func (r *RedisRepository) ListNotificationCodes(ctx context.Context) ([]domain.NotificationCode, error) {
+	values, err := r.Client.LRange(ctx, "notification:codes", 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting notification codes from Redis: %w", err)
 	}
 
-	return values, nil
+	metadata, err := r.Client.HGetAll(ctx, "notification:codes:meta").Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting notification codes metadata from Redis: %w", err)
+	}
+
+	codes := make([]domain.NotificationCode, len(values))
+	for i, value := range values {
+		codes[i] = domain.NotificationCode{Code: value}
+		if data, ok := metadata[value]; ok {
+			if err := json.Unmarshal([]byte(data), &codes[i]); err != nil {
+				return nil, fmt.Errorf("error decoding notification code %s: %w", value, err)
+			}
+		}
+	}
+
+	return codes, nil
+}
+
+func (r *RedisRepository) SaveNotificationCode(ctx context.Context, code domain.NotificationCode) error {
+	data, err := json.Marshal(code)
+	if err != nil {
+		return fmt.Errorf("error when try to map NotificationCode to JSON: %w", err)
+	}
+
+	_, err = r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, "notification:codes", 0, code.Code)
+		pipe.RPush(ctx, "notification:codes", code.Code)
+		pipe.HSet(ctx, "notification:codes:meta", code.Code, data)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error while saving NotificationCode in Redis: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisRepository) DeleteNotificationCode(ctx context.Context, code string) error {
+	var removed *redis.IntCmd
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.LRem(ctx, "notification:codes", 0, code)
+		pipe.HDel(ctx, "notification:codes:meta", code)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error while deleting NotificationCode in Redis: %w", err)
+	}
+
+	if removed.Val() == 0 {
+		return &domain.NotFoundError{Message: fmt.Sprintf("Notification code %s not found", code)}
+	}
+
+	return nil
+}
+
+func (r *RedisRepository) ReplaceNotificationCodes(ctx context.Context, codes []domain.NotificationCode) error {
+	values := make([]interface{}, len(codes))
+	metadata := make(map[string]interface{}, len(codes))
+	for i, code := range codes {
+		data, err := json.Marshal(code)
+		if err != nil {
+			return fmt.Errorf("error when try to map NotificationCode to JSON: %w", err)
+		}
+		values[i] = code.Code
+		metadata[code.Code] = data
+	}
+
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, "notification:codes", "notification:codes:meta")
+		if len(codes) > 0 {
+			pipe.RPush(ctx, "notification:codes", values...)
+			pipe.HSet(ctx, "notification:codes:meta", metadata)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error while replacing NotificationCodes in Redis: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisRepository) GetNotificationRules(ctx context.Context) ([]domain.NotificationRule, error) {
+	values, err := r.Client.HGetAll(ctx, "notification:rules").Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting notification rules from Redis: %w", err)
+	}
+
+	rules := make([]domain.NotificationRule, 0, len(values))
+	for name, value := range values {
+		var rule domain.NotificationRule
+		if err := json.Unmarshal([]byte(value), &rule); err != nil {
+			return nil, fmt.Errorf("error decoding notification rule %s: %w", name, err)
+		}
+		rule.Name = name
+		rules = append(rules, rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+
+	return rules, nil
+}
+
+func (r *RedisRepository) SaveNotificationRule(ctx context.Context, rule domain.NotificationRule) error {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("error when try to map NotificationRule to JSON: %w", err)
+	}
+
+	if err := r.Client.HSet(ctx, "notification:rules", rule.Name, data).Err(); err != nil {
+		return fmt.Errorf("error while saving NotificationRule in Redis: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisRepository) DeleteNotificationRule(ctx context.Context, name string) error {
+	removed, err := r.Client.HDel(ctx, "notification:rules", name).Result()
+	if err != nil {
+		return fmt.Errorf("error while deleting NotificationRule in Redis: %w", err)
+	}
+
+	if removed == 0 {
+		return &domain.NotFoundError{Message: fmt.Sprintf("Notification rule %s not found", name)}
+	}
+	return nil
+}
+
+func buyerPreferencesKey(email string) string {
+	return fmt.Sprintf("buyers:%s:preferences", domain.NormalizeEmail(email))
+}
+
+func (r *RedisRepository) GetBuyerPreferences(ctx context.Context, email string) (*domain.BuyerPreferences, error) {
+	value, err := r.Client.Get(ctx, buyerPreferencesKey(email)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting buyer preferences from Redis: %w", err)
+	}
+
+	var preferences domain.BuyerPreferences
+	if err := json.Unmarshal([]byte(value), &preferences); err != nil {
+		return nil, fmt.Errorf("error decoding buyer preferences of %s: %w", email, err)
+	}
+	return &preferences, nil
+}
+
+func (r *RedisRepository) SaveBuyerPreferences(ctx context.Context, preferences domain.BuyerPreferences) error {
+	data, err := json.Marshal(preferences)
+	if err != nil {
+		return fmt.Errorf("error when try to map BuyerPreferences it JSON: %w", err)
+	}
+
+	if err := r.Client.Set(ctx, buyerPreferencesKey(preferences.Email), data, 0).Err(); err != nil {
+		return fmt.Errorf("error while saving BuyerPreferences in Redis: %w", err)
+	}
+	return nil
+}
+
+// optOutsKey holds the opt-outs by normalized email.
+const optOutsKey = "buyers:opt_outs"
+
+func (r *RedisRepository) GetOptOut(ctx context.Context, email string) (*domain.OptOut, error) {
+	value, err := r.Client.HGet(ctx, optOutsKey, domain.NormalizeEmail(email)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting opt-out from Redis: %w", err)
+	}
+
+	var optOut domain.OptOut
+	if err := json.Unmarshal([]byte(value), &optOut); err != nil {
+		return nil, fmt.Errorf("error decoding opt-out of %s: %w", email, err)
+	}
+	return &optOut, nil
+}
+
+func (r *RedisRepository) SaveOptOut(ctx context.Context, optOut domain.OptOut) error {
+	data, err := json.Marshal(optOut)
+	if err != nil {
+		return fmt.Errorf("error when try to map OptOut it JSON: %w", err)
+	}
+
+	if err := r.Client.HSet(ctx, optOutsKey, domain.NormalizeEmail(optOut.Email), data).Err(); err != nil {
+		return fmt.Errorf("error while saving OptOut in Redis: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisRepository) DeleteOptOut(ctx context.Context, email string) error {
+	if err := r.Client.HDel(ctx, optOutsKey, domain.NormalizeEmail(email)).Err(); err != nil {
+		return fmt.Errorf("error while deleting OptOut in Redis: %w", err)
+	}
+	return nil
+}
+
+func dedupKey(key string) string {
+	return fmt.Sprintf("notification:dedup:%s", key)
+}
+
+// ReserveDedupKey uses SET NX so concurrent requests for the same delivery
+// reserve the key only once.
+func (r *RedisRepository) ReserveDedupKey(ctx context.Context, key, notificationID string, window time.Duration) (string, error) {
+	// The key may expire between SET NX and GET; then reserving is retried.
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := r.Client.SetNX(ctx, dedupKey(key), notificationID, window).Result()
+		if err != nil {
+			return "", fmt.Errorf("error reserving dedup key in Redis: %w", err)
+		}
+		if reserved {
+			return notificationID, nil
+		}
+
+		holder, err := r.Client.Get(ctx, dedupKey(key)).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("error getting dedup key from Redis: %w", err)
+		}
+		return holder, nil
+	}
+	return "", fmt.Errorf("error reserving dedup key %s in Redis", key)
+}
+
+func (r *RedisRepository) ReleaseDedupKey(ctx context.Context, key string) error {
+	if err := r.Client.Del(ctx, dedupKey(key)).Err(); err != nil {
+		return fmt.Errorf("error while deleting dedup key in Redis: %w", err)
+	}
+	return nil
 }
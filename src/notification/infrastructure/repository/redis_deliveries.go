@@ -0,0 +1,161 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+func deliveryRecordKey(id string) string {
+	return fmt.Sprintf("deliveries:records:%s", id)
+}
+
+func deliveryNotificationsKey(id string) string {
+	return fmt.Sprintf("deliveries:notifications:%s", id)
+}
+
+// deliveryDateScore orders the deliveries of the schedule by date: 2024-10-11
+// scores 20241011.
+func deliveryDateScore(date string) (float64, error) {
+	score, err := strconv.Atoi(strings.ReplaceAll(date, "-", ""))
+	if err != nil {
+		return 0, fmt.Errorf("invalid delivery date %q", date)
+	}
+	return float64(score), nil
+}
+
+// saveDeliveryScript maps the order to the delivery unless the order already
+// belongs to another one, then stores the record and indexes it in the
+// schedule. It returns 0 without writing when the order is taken.
+const saveDeliveryScript = `
+if ARGV[4] ~= '' then
+	local owner = redis.call('HGET', KEYS[3], ARGV[4])
+	if owner and owner ~= ARGV[3] then
+		return 0
+	end
+	redis.call('HSET', KEYS[3], ARGV[4], ARGV[3])
+end
+redis.call('SET', KEYS[1], ARGV[1])
+redis.call('ZADD', KEYS[2], ARGV[2], ARGV[3])
+return 1
+`
+
+// SaveDelivery stores the delivery, indexes it by date in the schedule and
+// reserves its order in one script, so an order registered concurrently by
+// another delivery is a ConflictError and a failed save reserves nothing.
+func (r *RedisRepository) SaveDelivery(ctx context.Context, delivery domain.Delivery) error {
+	score, err := deliveryDateScore(delivery.DeliveryDate)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("error when try to map Delivery to JSON: %w", err)
+	}
+
+	keys := []string{deliveryRecordKey(delivery.ID), "deliveries:schedule", "deliveries:orders"}
+	saved, err := r.Client.Eval(ctx, saveDeliveryScript, keys, string(data), score, delivery.ID, delivery.OrderID).Int()
+	if err != nil {
+		return fmt.Errorf("error while saving Delivery in Redis: %w", err)
+	}
+	if saved == 0 {
+		return &domain.ConflictError{Message: fmt.Sprintf("Delivery of order %s already registered", delivery.OrderID)}
+	}
+	return nil
+}
+
+func (r *RedisRepository) GetDelivery(ctx context.Context, id string) (*domain.Delivery, error) {
+	value, err := r.Client.Get(ctx, deliveryRecordKey(id)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, &domain.NotFoundError{Message: fmt.Sprintf("Delivery %s not found", id)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting Delivery from Redis: %w", err)
+	}
+
+	var delivery domain.Delivery
+	if err := json.Unmarshal([]byte(value), &delivery); err != nil {
+		return nil, fmt.Errorf("error decoding delivery: %w", err)
+	}
+	return &delivery, nil
+}
+
+func (r *RedisRepository) GetDeliveryByOrder(ctx context.Context, orderID string) (*domain.Delivery, error) {
+	id, err := r.Client.HGet(ctx, "deliveries:orders", orderID).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, &domain.NotFoundError{Message: fmt.Sprintf("Delivery of order %s not found", orderID)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting the delivery of order %s from Redis: %w", orderID, err)
+	}
+	return r.GetDelivery(ctx, id)
+}
+
+func (r *RedisRepository) GetDeliveryNotifications(ctx context.Context, deliveryID string) ([]domain.Notification, error) {
+	ids, err := r.Client.LRange(ctx, deliveryNotificationsKey(deliveryID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the notifications of delivery %s from Redis: %w", deliveryID, err)
+	}
+	return r.getNotificationRecords(ctx, ids)
+}
+
+func (r *RedisRepository) ListDeliveries(ctx context.Context, from, to string) ([]domain.Delivery, error) {
+	min, err := deliveryDateScore(from)
+	if err != nil {
+		return nil, err
+	}
+	max, err := deliveryDateScore(to)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := r.Client.ZRangeByScore(ctx, "deliveries:schedule", &redis.ZRangeBy{
+		Min: strconv.FormatFloat(min, 'f', 0, 64),
+		Max: strconv.FormatFloat(max, 'f', 0, 64),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the delivery schedule from Redis: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = deliveryRecordKey(id)
+	}
+	values, err := r.Client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("error getting Deliveries from Redis: %w", err)
+	}
+
+	deliveries := make([]domain.Delivery, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var delivery domain.Delivery
+		if err := json.Unmarshal([]byte(data), &delivery); err != nil {
+			return nil, fmt.Errorf("error decoding delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+func (r *RedisRepository) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	acquired, err := r.Client.SetNX(ctx, fmt.Sprintf("locks:%s", name), owner, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("error acquiring lock %s in Redis: %w", name, err)
+	}
+	return acquired, nil
+}
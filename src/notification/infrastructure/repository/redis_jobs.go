@@ -0,0 +1,130 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+const jobTTL = 7 * 24 * time.Hour
+
+func (r *RedisRepository) SaveJob(ctx context.Context, job domain.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("error when try to map Job to JSON: %w", err)
+	}
+
+	key := fmt.Sprintf("jobs:%s", job.ID)
+	if err := r.Client.Set(ctx, key, data, jobTTL).Err(); err != nil {
+		return fmt.Errorf("error while saving Job in Redis: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisRepository) GetJob(ctx context.Context, id string) (*domain.Job, error) {
+	key := fmt.Sprintf("jobs:%s", id)
+	value, err := r.Client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, &domain.NotFoundError{Message: fmt.Sprintf("Job %s not found", id)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting Job from Redis: %w", err)
+	}
+
+	var job domain.Job
+	if err := json.Unmarshal([]byte(value), &job); err != nil {
+		return nil, fmt.Errorf("error decoding job: %w", err)
+	}
+
+	return &job, nil
+}
+
+func (r *RedisRepository) EnqueueJob(ctx context.Context, id string) error {
+	if err := r.Client.LPush(ctx, "jobs:queue", id).Err(); err != nil {
+		return fmt.Errorf("error while enqueuing Job in Redis: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisRepository) DequeueJob(ctx context.Context, worker string, timeout time.Duration) (string, error) {
+	id, err := r.Client.BLMove(ctx, "jobs:queue", jobsProcessingKey(worker), "RIGHT", "LEFT", timeout).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error while dequeuing Job from Redis: %w", err)
+	}
+	return id, nil
+}
+
+func (r *RedisRepository) AckJob(ctx context.Context, worker string, id string) error {
+	if err := r.Client.LRem(ctx, jobsProcessingKey(worker), 1, id).Err(); err != nil {
+		return fmt.Errorf("error while acknowledging Job in Redis: %w", err)
+	}
+	return nil
+}
+
+// RenewJobLease keeps jobs:lease:{worker} alive for ttl and registers the
+// worker in jobs:workers so RecoverJobs finds its processing list.
+func (r *RedisRepository) RenewJobLease(ctx context.Context, worker string, ttl time.Duration) error {
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, jobsLeaseKey(worker), 1, ttl)
+		pipe.SAdd(ctx, "jobs:workers", worker)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error while renewing Job lease in Redis: %w", err)
+	}
+	return nil
+}
+
+// RecoverJobs queues again the processing list of every registered worker
+// whose lease expired. Workers still holding a lease are left alone.
+func (r *RedisRepository) RecoverJobs(ctx context.Context) (int, error) {
+	workers, err := r.Client.SMembers(ctx, "jobs:workers").Result()
+	if err != nil {
+		return 0, fmt.Errorf("error getting Job workers from Redis: %w", err)
+	}
+
+	recovered := 0
+	for _, worker := range workers {
+		alive, err := r.Client.Exists(ctx, jobsLeaseKey(worker)).Result()
+		if err != nil {
+			return recovered, fmt.Errorf("error getting Job lease from Redis: %w", err)
+		}
+		if alive > 0 {
+			continue
+		}
+
+		for {
+			_, err := r.Client.LMove(ctx, jobsProcessingKey(worker), "jobs:queue", "RIGHT", "RIGHT").Result()
+			if errors.Is(err, redis.Nil) {
+				break
+			}
+			if err != nil {
+				return recovered, fmt.Errorf("error while recovering Jobs in Redis: %w", err)
+			}
+			recovered++
+		}
+
+		if err := r.Client.SRem(ctx, "jobs:workers", worker).Err(); err != nil {
+			return recovered, fmt.Errorf("error while removing Job worker in Redis: %w", err)
+		}
+	}
+
+	return recovered, nil
+}
+
+func jobsProcessingKey(worker string) string {
+	return fmt.Sprintf("jobs:processing:%s", worker)
+}
+
+func jobsLeaseKey(worker string) string {
+	return fmt.Sprintf("jobs:lease:%s", worker)
+}
@@ -0,0 +1,184 @@
+package sender
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DKIMSignedHeaders are the headers signed when present in the message. When
+// a header is repeated its last occurrence is signed.
+var DKIMSignedHeaders = []string{
+	"From", "To", "Reply-To", "Subject", "Date", "Message-ID", "List-Unsubscribe",
+	"List-Unsubscribe-Post", "MIME-Version", "Content-Type", "Content-Transfer-Encoding",
+}
+
+// DKIMSigner adds an RFC 6376 DKIM-Signature header (rsa-sha256 with
+// relaxed/relaxed canonicalization) to the outgoing messages.
+type DKIMSigner struct {
+	Domain   string
+	Selector string
+	key      *rsa.PrivateKey
+}
+
+func NewDKIMSigner(domain, selector string, key *rsa.PrivateKey) *DKIMSigner {
+	return &DKIMSigner{Domain: domain, Selector: selector, key: key}
+}
+
+// LoadDKIMSigner reads the PEM encoded RSA private key (PKCS #1 or PKCS #8)
+// at keyPath.
+func LoadDKIMSigner(domain, selector, keyPath string) (*DKIMSigner, error) {
+	if domain == "" || selector == "" {
+		return nil, errors.New("dkim domain and selector are required")
+	}
+
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading dkim private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("error reading dkim private key: no PEM data in %s", keyPath)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewDKIMSigner(domain, selector, key), nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing dkim private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("error parsing dkim private key: only RSA keys are supported")
+	}
+	return NewDKIMSigner(domain, selector, key), nil
+}
+
+// Sign returns message with a DKIM-Signature header prepended. message must
+// use CRLF line endings.
+func (s *DKIMSigner) Sign(message []byte) ([]byte, error) {
+	headerBlock, body, found := bytes.Cut(message, []byte("\r\n\r\n"))
+	if !found {
+		return nil, errors.New("error signing message: no header separator")
+	}
+
+	bodyHash := sha256.Sum256([]byte(relaxedBody(string(body))))
+	fields := splitHeaderFields(string(headerBlock))
+
+	var signedNames []string
+	hash := sha256.New()
+	for _, name := range DKIMSignedHeaders {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if strings.EqualFold(fieldName(fields[i]), name) {
+				hash.Write([]byte(relaxedHeader(fields[i]) + "\r\n"))
+				signedNames = append(signedNames, strings.ToLower(name))
+				break
+			}
+		}
+	}
+	if len(signedNames) == 0 || signedNames[0] != "from" {
+		return nil, errors.New("error signing message: the From header is required")
+	}
+
+	value := fmt.Sprintf("v=1; a=rsa-sha256; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		s.Domain, s.Selector, time.Now().Unix(), strings.Join(signedNames, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]))
+	hash.Write([]byte(relaxedHeader("DKIM-Signature: " + value)))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash.Sum(nil))
+	if err != nil {
+		return nil, fmt.Errorf("error signing message: %w", err)
+	}
+
+	var signed bytes.Buffer
+	signed.WriteString("DKIM-Signature: " + value + foldBase64(base64.StdEncoding.EncodeToString(signature)) + "\r\n")
+	signed.Write(message)
+	return signed.Bytes(), nil
+}
+
+// foldBase64 splits the signature over several header lines so the header
+// stays below the recommended line length.
+func foldBase64(value string) string {
+	const width = 72
+	var folded strings.Builder
+	for len(value) > width {
+		folded.WriteString(value[:width] + "\r\n ")
+		value = value[width:]
+	}
+	folded.WriteString(value)
+	return folded.String()
+}
+
+// splitHeaderFields returns the header fields of headerBlock, each with its
+// continuation lines.
+func splitHeaderFields(headerBlock string) []string {
+	var fields []string
+	for _, line := range strings.Split(headerBlock, "\r\n") {
+		if len(fields) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			fields[len(fields)-1] += "\r\n" + line
+			continue
+		}
+		fields = append(fields, line)
+	}
+	return fields
+}
+
+func fieldName(field string) string {
+	name, _, _ := strings.Cut(field, ":")
+	return strings.TrimSpace(name)
+}
+
+// relaxedHeader canonicalizes a header field with the relaxed algorithm
+// of RFC 6376 section 3.4.2, without the trailing CRLF.
+func relaxedHeader(field string) string {
+	name, value, _ := strings.Cut(field, ":")
+	value = strings.NewReplacer("\r\n", "").Replace(value)
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(compressWhitespace(value))
+}
+
+// relaxedBody canonicalizes a body with the relaxed algorithm of
+// RFC 6376 section 3.4.4.
+func relaxedBody(body string) string {
+	lines := strings.Split(body, "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(compressWhitespace(line), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func compressWhitespace(value string) string {
+	var compressed strings.Builder
+	space := false
+	for _, r := range value {
+		if r == ' ' || r == '\t' {
+			space = true
+			continue
+		}
+		if space {
+			compressed.WriteByte(' ')
+			space = false
+		}
+		compressed.WriteRune(r)
+	}
+	if space {
+		compressed.WriteByte(' ')
+	}
+	return compressed.String()
+}
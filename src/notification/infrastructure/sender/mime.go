@@ -0,0 +1,119 @@
+package sender
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+// Mail is an email ready to be encoded as an RFC 5322 message.
+type Mail struct {
+	From            mail.Address
+	To              mail.Address
+	ReplyTo         string
+	Subject         string
+	Text            string
+	HTML            string
+	MessageID       string
+	Date            time.Time
+	ListUnsubscribe []string
+	// OneClickUnsubscribe adds the RFC 8058 List-Unsubscribe-Post header; the
+	// first List-Unsubscribe target must then be an https URL.
+	OneClickUnsubscribe bool
+}
+
+// NewMessageID returns a unique Message-ID in the domain of the sender.
+func NewMessageID(from string) string {
+	_, host, found := strings.Cut(from, "@")
+	if !found || host == "" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("<%s@%s>", domain.NewID(), host)
+}
+
+// BuildMIMEMessage encodes m as a UTF-8 message. With an HTML body it is a
+// multipart/alternative message with the text first, otherwise a text/plain
+// one. Bodies are quoted-printable and non-ASCII headers are Q-encoded.
+func BuildMIMEMessage(m Mail) ([]byte, error) {
+	var message bytes.Buffer
+	header := func(name, value string) {
+		message.WriteString(name + ": " + value + "\r\n")
+	}
+
+	header("From", m.From.String())
+	header("To", m.To.String())
+	if m.ReplyTo != "" {
+		header("Reply-To", m.ReplyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("UTF-8", m.Subject))
+	header("Date", m.Date.Format(time.RFC1123Z))
+	header("Message-ID", m.MessageID)
+	if len(m.ListUnsubscribe) > 0 {
+		targets := make([]string, len(m.ListUnsubscribe))
+		for i, target := range m.ListUnsubscribe {
+			targets[i] = "<" + target + ">"
+		}
+		header("List-Unsubscribe", strings.Join(targets, ", "))
+		if m.OneClickUnsubscribe {
+			header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+		}
+	}
+	header("MIME-Version", "1.0")
+
+	if m.HTML == "" {
+		header("Content-Type", "text/plain; charset=UTF-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		message.WriteString("\r\n")
+		if err := writeQuotedPrintable(&message, m.Text); err != nil {
+			return nil, err
+		}
+		return message.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", m.Text},
+		{"text/html; charset=UTF-8", m.HTML},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error building mime part: %w", err)
+		}
+		if err := writeQuotedPrintable(writer, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("error building mime message: %w", err)
+	}
+
+	header("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": parts.Boundary()}))
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	writer := quotedprintable.NewWriter(w)
+	content = strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := writer.Write([]byte(content)); err != nil {
+		return fmt.Errorf("error encoding mime body: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error encoding mime body: %w", err)
+	}
+	return nil
+}
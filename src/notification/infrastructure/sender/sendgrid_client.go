@@ -0,0 +1,129 @@
+package sender
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+const SendGridDefaultBaseURL = "https://api.sendgrid.com"
+
+// SendGridClient sends notifications through the SendGrid v3 mail send API.
+type SendGridClient struct {
+	configSendGrid server.SendGridConfig
+	baseURL        string
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridMail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+}
+
+type sendGridErrorResponse struct {
+	Errors []struct {
+		Message string `json:"message"`
+		Field   string `json:"field"`
+	} `json:"errors"`
+}
+
+func NewSendGridClient(configSendGrid server.SendGridConfig) *SendGridClient {
+	baseURL := strings.TrimRight(configSendGrid.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = SendGridDefaultBaseURL
+	}
+
+	log.Printf("Loading sendgrid config base url %s from %s", baseURL, configSendGrid.FromEmail)
+	return &SendGridClient{
+		configSendGrid: configSendGrid,
+		baseURL:        baseURL,
+	}
+}
+
+func (sendGridClient *SendGridClient) Send(message domain.Message) error {
+	mail := sendGridMail{
+		Personalizations: []sendGridPersonalization{{To: []sendGridAddress{{Email: message.Recipient}}}},
+		From:             sendGridAddress{Email: sendGridClient.configSendGrid.FromEmail, Name: sendGridClient.configSendGrid.FromName},
+		Subject:          message.Subject,
+		Content:          []sendGridContent{{Type: "text/plain", Value: message.Text}},
+	}
+	if message.HTML != "" {
+		mail.Content = append(mail.Content, sendGridContent{Type: "text/html", Value: message.HTML})
+	}
+	if message.UnsubscribeURL != "" {
+		mail.Headers = map[string]string{"List-Unsubscribe": "<" + message.UnsubscribeURL + ">"}
+		if strings.HasPrefix(message.UnsubscribeURL, "https://") {
+			mail.Headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+		}
+	}
+
+	body, err := json.Marshal(mail)
+	if err != nil {
+		return fmt.Errorf("error encoding sendgrid mail: %w", err)
+	}
+
+	options := server.RequestOptions{
+		Method: http.MethodPost,
+		URL:    sendGridClient.baseURL + "/v3/mail/send",
+		Body:   body,
+		Headers: map[string]string{
+			"Authorization": "Bearer " + sendGridClient.configSendGrid.APIKey,
+			"Content-Type":  "application/json",
+		},
+		MaxRetries:     3,
+		RetryDelay:     2 * time.Second,
+		RequestTimeout: 10 * time.Second,
+	}
+
+	resp, err := server.DoRequestWithRetry(options)
+	if err != nil {
+		log.Printf("Send sendgrid: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return sendGridError(resp)
+	}
+
+	log.Println("Email Sent Successfully!")
+	return nil
+}
+
+func sendGridError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var errorResponse sendGridErrorResponse
+	if err := json.Unmarshal(data, &errorResponse); err == nil && len(errorResponse.Errors) > 0 {
+		messages := make([]string, len(errorResponse.Errors))
+		for i, e := range errorResponse.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("sendgrid returned http status %d: %s", resp.StatusCode, strings.Join(messages, "; "))
+	}
+
+	return fmt.Errorf("sendgrid returned http status %d", resp.StatusCode)
+}
@@ -0,0 +1,76 @@
+package sender
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+// SMSClient sends text messages through an HTTP gateway compatible with the
+// Twilio Messages API. The recipient of the messages is an E.164 phone number.
+type SMSClient struct {
+	configSMS server.SMSConfig
+	baseURL   string
+}
+
+type smsErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewSMSClient(configSMS server.SMSConfig) *SMSClient {
+	log.Printf("Loading sms config base url %s from %s", configSMS.BaseURL, configSMS.From)
+	return &SMSClient{
+		configSMS: configSMS,
+		baseURL:   strings.TrimRight(configSMS.BaseURL, "/"),
+	}
+}
+
+func (smsClient *SMSClient) Send(message domain.Message) error {
+	form := url.Values{}
+	form.Set("To", message.Recipient)
+	form.Set("From", smsClient.configSMS.From)
+	form.Set("Body", message.Text)
+
+	credentials := smsClient.configSMS.AccountSID + ":" + smsClient.configSMS.AuthToken
+	options := server.RequestOptions{
+		Method: http.MethodPost,
+		URL:    fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", smsClient.baseURL, url.PathEscape(smsClient.configSMS.AccountSID)),
+		Body:   []byte(form.Encode()),
+		Headers: map[string]string{
+			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials)),
+			"Content-Type":  "application/x-www-form-urlencoded",
+		},
+		MaxRetries:     3,
+		RetryDelay:     2 * time.Second,
+		RequestTimeout: 10 * time.Second,
+	}
+
+	resp, err := server.DoRequestWithRetry(options)
+	if err != nil {
+		log.Printf("Send sms: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		var errorResponse smsErrorResponse
+		if err := json.Unmarshal(data, &errorResponse); err == nil && errorResponse.Message != "" {
+			return fmt.Errorf("sms gateway returned http status %d: %s (code %d)", resp.StatusCode, errorResponse.Message, errorResponse.Code)
+		}
+		return fmt.Errorf("sms gateway returned http status %d", resp.StatusCode)
+	}
+
+	log.Println("SMS Sent Successfully!")
+	return nil
+}
@@ -3,40 +3,70 @@ package sender
 import (
 	"fmt"
 	"log"
-	"net/smtp"
+	"net/mail"
+	"strings"
+	"time"
 
 	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
 )
 
 type SmtpClient struct {
+	// DKIMSigner signs the outgoing messages when set.
+	DKIMSigner *DKIMSigner
+
 	configSMTP server.SMTPConfig
+	pool       *SMTPPool
 }
 
 func NewSmtpClient(configSMTP server.SMTPConfig) *SmtpClient {
 	log.Printf("Loading smtp config host %s port %d", configSMTP.Host, configSMTP.Port)
 	return &SmtpClient{
 		configSMTP: configSMTP,
+		pool:       NewSMTPPool(configSMTP),
 	}
 }
 
-func (smtpClient *SmtpClient) Send(email string, text string) error {
+func (smtpClient *SmtpClient) Send(message domain.Message) error {
 
-	from := smtpClient.configSMTP.Username
-	password := smtpClient.configSMTP.Password
+	from := smtpClient.from()
 
-	to := []string{email}
+	to := []string{message.Recipient}
 
 	smtpHost := smtpClient.configSMTP.Host
 	smtpPort := fmt.Sprint(smtpClient.configSMTP.Port)
 
 	log.Printf("Send email host %s  port %s", smtpHost, smtpPort)
 
-	auth := smtp.PlainAuth("", from, password, smtpHost)
-	message := []byte("To: " + to[0] + "\r\n" +
-		"From: " + from + "\r\n" +
-		"Subject: " + "Entrega retrasada por clima" + "\r\n" +
-		"\r\n" + text)
-	err_sending := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, to, message)
+	var listUnsubscribe []string
+	if message.UnsubscribeURL != "" {
+		listUnsubscribe = append(listUnsubscribe, message.UnsubscribeURL)
+	}
+	if smtpClient.configSMTP.ListUnsubscribe != "" {
+		listUnsubscribe = append(listUnsubscribe, smtpClient.configSMTP.ListUnsubscribe)
+	}
+	body, err := BuildMIMEMessage(Mail{
+		From:                mail.Address{Name: smtpClient.configSMTP.FromName, Address: from},
+		To:                  mail.Address{Address: message.Recipient},
+		ReplyTo:             smtpClient.configSMTP.ReplyTo,
+		Subject:             message.Subject,
+		Text:                message.Text,
+		HTML:                message.HTML,
+		MessageID:           NewMessageID(from),
+		Date:                time.Now(),
+		ListUnsubscribe:     listUnsubscribe,
+		OneClickUnsubscribe: strings.HasPrefix(message.UnsubscribeURL, "https://"),
+	})
+	if err != nil {
+		return err
+	}
+	if smtpClient.DKIMSigner != nil {
+		if body, err = smtpClient.DKIMSigner.Sign(body); err != nil {
+			return err
+		}
+	}
+
+	err_sending := smtpClient.pool.Send(from, to, body)
 	if err_sending != nil {
 		log.Println(err_sending)
 		return err_sending
@@ -44,3 +74,11 @@ func (smtpClient *SmtpClient) Send(email string, text string) error {
 	log.Println("Email Sent Successfully!")
 	return nil
 }
+
+// from returns the sender address, the SMTP username unless from is set.
+func (smtpClient *SmtpClient) from() string {
+	if smtpClient.configSMTP.From != "" {
+		return smtpClient.configSMTP.From
+	}
+	return smtpClient.configSMTP.Username
+}
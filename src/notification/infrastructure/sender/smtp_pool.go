@@ -0,0 +1,302 @@
+package sender
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+)
+
+// TLS modes of the SMTP connections.
+const (
+	SMTPTLSStartTLS         = "starttls"
+	SMTPTLSStartTLSRequired = "starttls_required"
+	SMTPTLSImplicit         = "implicit"
+	SMTPTLSNone             = "none"
+)
+
+// Authentication mechanisms of the SMTP connections.
+const (
+	SMTPAuthPlain   = "plain"
+	SMTPAuthLogin   = "login"
+	SMTPAuthCRAMMD5 = "cram-md5"
+)
+
+const (
+	defaultSMTPPoolSize    = 4
+	defaultSMTPDialTimeout = 10 * time.Second
+	defaultSMTPSendTimeout = 30 * time.Second
+	defaultSMTPIdleTimeout = 30 * time.Second
+)
+
+type smtpConn struct {
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
+}
+
+// SMTPPool keeps up to PoolSize authenticated SMTP connections open and
+// reuses them between messages. A connection that fails is discarded.
+type SMTPPool struct {
+	// TLSConfig is used for implicit TLS and STARTTLS. When nil the server
+	// certificate is verified against the configured host.
+	TLSConfig *tls.Config
+
+	configSMTP  server.SMTPConfig
+	tlsMode     string
+	dialTimeout time.Duration
+	sendTimeout time.Duration
+	idleTimeout time.Duration
+
+	slots chan struct{}
+	mu    sync.Mutex
+	idle  []*smtpConn
+}
+
+func NewSMTPPool(configSMTP server.SMTPConfig) *SMTPPool {
+	tlsMode := strings.ToLower(configSMTP.TLSMode)
+	if tlsMode == "" {
+		tlsMode = SMTPTLSStartTLS
+		if configSMTP.Port == 465 {
+			tlsMode = SMTPTLSImplicit
+		}
+	}
+
+	poolSize := configSMTP.PoolSize
+	if poolSize < 1 {
+		poolSize = defaultSMTPPoolSize
+	}
+
+	return &SMTPPool{
+		configSMTP:  configSMTP,
+		tlsMode:     tlsMode,
+		dialTimeout: secondsOr(configSMTP.DialTimeoutSeconds, defaultSMTPDialTimeout),
+		sendTimeout: secondsOr(configSMTP.SendTimeoutSeconds, defaultSMTPSendTimeout),
+		idleTimeout: secondsOr(configSMTP.IdleTimeoutSeconds, defaultSMTPIdleTimeout),
+		slots:       make(chan struct{}, poolSize),
+	}
+}
+
+func secondsOr(seconds int, fallback time.Duration) time.Duration {
+	if seconds <= 0 {
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Send delivers message from the sender to the recipients through a pooled
+// connection, waiting for a free one when PoolSize messages are in flight.
+func (pool *SMTPPool) Send(from string, to []string, message []byte) error {
+	pool.slots <- struct{}{}
+	defer func() { <-pool.slots }()
+
+	conn, err := pool.acquire()
+	if err != nil {
+		return err
+	}
+
+	if err := pool.send(conn, from, to, message); err != nil {
+		conn.client.Close()
+		return err
+	}
+
+	pool.release(conn)
+	return nil
+}
+
+func (pool *SMTPPool) send(conn *smtpConn, from string, to []string, message []byte) error {
+	if err := conn.conn.SetDeadline(time.Now().Add(pool.sendTimeout)); err != nil {
+		return err
+	}
+	if err := conn.client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := conn.client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	writer, err := conn.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// acquire returns an idle connection that still answers RSET or a new one.
+func (pool *SMTPPool) acquire() (*smtpConn, error) {
+	for {
+		pool.mu.Lock()
+		if len(pool.idle) == 0 {
+			pool.mu.Unlock()
+			return pool.dial()
+		}
+		conn := pool.idle[len(pool.idle)-1]
+		pool.idle = pool.idle[:len(pool.idle)-1]
+		pool.mu.Unlock()
+
+		if time.Since(conn.lastUsed) < pool.idleTimeout {
+			conn.conn.SetDeadline(time.Now().Add(pool.sendTimeout))
+			if err := conn.client.Reset(); err == nil {
+				return conn, nil
+			}
+		}
+		conn.client.Close()
+	}
+}
+
+func (pool *SMTPPool) release(conn *smtpConn) {
+	conn.lastUsed = time.Now()
+	conn.conn.SetDeadline(time.Time{})
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if len(pool.idle) >= cap(pool.slots) {
+		conn.client.Quit()
+		return
+	}
+	pool.idle = append(pool.idle, conn)
+}
+
+// Close quits the idle connections.
+func (pool *SMTPPool) Close() {
+	pool.mu.Lock()
+	idle := pool.idle
+	pool.idle = nil
+	pool.mu.Unlock()
+
+	for _, conn := range idle {
+		conn.client.Quit()
+	}
+}
+
+func (pool *SMTPPool) dial() (*smtpConn, error) {
+	host := pool.configSMTP.Host
+	addr := net.JoinHostPort(host, strconv.Itoa(pool.configSMTP.Port))
+	dialer := &net.Dialer{Timeout: pool.dialTimeout}
+
+	var conn net.Conn
+	var err error
+	if pool.tlsMode == SMTPTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, pool.tlsConfig())
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to smtp server %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(pool.sendTimeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error connecting to smtp server %s: %w", addr, err)
+	}
+
+	if err := pool.handshake(client); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	log.Printf("SMTPPool connected to %s tls %s", addr, pool.tlsMode)
+	return &smtpConn{conn: conn, client: client}, nil
+}
+
+func (pool *SMTPPool) handshake(client *smtp.Client) error {
+	if pool.tlsMode == SMTPTLSStartTLS || pool.tlsMode == SMTPTLSStartTLSRequired {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(pool.tlsConfig()); err != nil {
+				return fmt.Errorf("error starting tls with smtp server: %w", err)
+			}
+		} else if pool.tlsMode == SMTPTLSStartTLSRequired {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+	}
+
+	if pool.configSMTP.Username == "" {
+		return nil
+	}
+	if ok, _ := client.Extension("AUTH"); !ok {
+		return nil
+	}
+
+	auth, err := pool.auth()
+	if err != nil {
+		return err
+	}
+	if err := client.Auth(auth); err != nil {
+		return fmt.Errorf("error authenticating with smtp server: %w", err)
+	}
+	return nil
+}
+
+func (pool *SMTPPool) auth() (smtp.Auth, error) {
+	username, password, host := pool.configSMTP.Username, pool.configSMTP.Password, pool.configSMTP.Host
+	switch strings.ToLower(pool.configSMTP.Auth) {
+	case "", SMTPAuthPlain:
+		return smtp.PlainAuth("", username, password, host), nil
+	case SMTPAuthLogin:
+		return LoginAuth(username, password, host), nil
+	case SMTPAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(username, password), nil
+	default:
+		return nil, fmt.Errorf("smtp auth %q is not supported", pool.configSMTP.Auth)
+	}
+}
+
+func (pool *SMTPPool) tlsConfig() *tls.Config {
+	if pool.TLSConfig != nil {
+		return pool.TLSConfig
+	}
+	return &tls.Config{ServerName: pool.configSMTP.Host}
+}
+
+type loginAuth struct {
+	username, password, host string
+}
+
+// LoginAuth returns an smtp.Auth that implements the LOGIN mechanism. Like
+// smtp.PlainAuth, it only sends the credentials over TLS or to localhost.
+func LoginAuth(username, password, host string) smtp.Auth {
+	return &loginAuth{username: username, password: password, host: host}
+}
+
+func (a *loginAuth) Start(serverInfo *smtp.ServerInfo) (string, []byte, error) {
+	if !serverInfo.TLS && !isLocalhost(serverInfo.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if serverInfo.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
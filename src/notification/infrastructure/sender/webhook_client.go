@@ -0,0 +1,110 @@
+package sender
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/webhook"
+)
+
+// WebhookClient posts signed JSON payloads to partner callbacks. The
+// recipient of the messages is either the name of a tenant configured in
+// webhook.tenants, matched without case since viper lowercases the keys, or
+// an absolute callback URL, which is signed with the default secret and may
+// only reach public addresses.
+type WebhookClient struct {
+	configWebhook   server.WebhookConfig
+	tenants         map[string]server.WebhookTenantConfig
+	publicTransport http.RoundTripper
+}
+
+func NewWebhookClient(configWebhook server.WebhookConfig) *WebhookClient {
+	log.Printf("Loading webhook config with %d tenant(s)", len(configWebhook.Tenants))
+	tenants := make(map[string]server.WebhookTenantConfig, len(configWebhook.Tenants))
+	for name, tenant := range configWebhook.Tenants {
+		tenants[strings.ToLower(name)] = tenant
+	}
+
+	var publicTransport http.RoundTripper
+	if !configWebhook.AllowPrivateCallbacks {
+		publicTransport = server.PublicTransport()
+	}
+
+	return &WebhookClient{
+		configWebhook:   configWebhook,
+		tenants:         tenants,
+		publicTransport: publicTransport,
+	}
+}
+
+func (webhookClient *WebhookClient) Send(message domain.Message) error {
+	callbackURL, secret, transport, err := webhookClient.resolve(message.Recipient)
+	if err != nil {
+		return err
+	}
+	if !json.Valid([]byte(message.Text)) {
+		return fmt.Errorf("webhook payload is not valid JSON")
+	}
+
+	body := []byte(message.Text)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	options := server.RequestOptions{
+		Method: http.MethodPost,
+		URL:    callbackURL,
+		Body:   body,
+		Headers: map[string]string{
+			"Content-Type":               "application/json",
+			webhook.TimestampHeader:      timestamp,
+			webhook.NotificationIDHeader: message.NotificationID,
+			webhook.SignatureHeader:      webhook.Sign(secret, timestamp, body),
+		},
+		MaxRetries:     3,
+		RetryDelay:     2 * time.Second,
+		RequestTimeout: 10 * time.Second,
+		Transport:      transport,
+	}
+
+	resp, err := server.DoRequestWithRetry(options)
+	if err != nil {
+		log.Printf("Send webhook: %v", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned http status %d", resp.StatusCode)
+	}
+
+	log.Printf("Webhook %s Sent Successfully!", message.NotificationID)
+	return nil
+}
+
+// resolve returns the URL, secret and transport of target. Tenant URLs are
+// configured by the operator and use the default transport.
+func (webhookClient *WebhookClient) resolve(target string) (string, string, http.RoundTripper, error) {
+	if tenant, ok := webhookClient.tenants[strings.ToLower(target)]; ok {
+		secret := tenant.Secret
+		if secret == "" {
+			secret = webhookClient.configWebhook.Secret
+		}
+		return tenant.URL, secret, nil, nil
+	}
+
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", "", nil, fmt.Errorf("webhook tenant %q is not configured", target)
+	}
+	if webhookClient.configWebhook.Secret == "" {
+		return "", "", nil, fmt.Errorf("webhook secret is not configured")
+	}
+
+	return target, webhookClient.configWebhook.Secret, webhookClient.publicTransport, nil
+}
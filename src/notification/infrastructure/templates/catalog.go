@@ -0,0 +1,313 @@
+package templates
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+// Every locale is a directory holding these templates. The HTML body is
+// optional; the others are required. The unsubscribe page falls back to the
+// one of the default locale.
+const (
+	subjectTemplate     = "subject.txt.tmpl"
+	textTemplate        = "email.txt.tmpl"
+	htmlTemplate        = "email.html.tmpl"
+	smsTemplate         = "sms.txt.tmpl"
+	unsubscribeTemplate = "unsubscribe.html.tmpl"
+)
+
+// conditionsFile optionally maps forecast condition codes to the description
+// shown in the locale. The codes of WeatherAPI (1000 and above) and the WMO
+// codes of Open-Meteo (below 100) do not overlap, so one file covers both.
+// Codes without a description keep the one of the provider.
+const conditionsFile = "conditions.json"
+
+// DefaultLocale is used when the configuration sets no default locale.
+const DefaultLocale = "es"
+
+//go:embed locales
+var embedded embed.FS
+
+// Embedded returns the templates shipped with the binary, one directory per
+// locale.
+func Embedded() fs.FS {
+	locales, _ := fs.Sub(embedded, "locales")
+	return locales
+}
+
+type localeTemplates struct {
+	subject     *template.Template
+	text        *template.Template
+	html        *htmltemplate.Template
+	sms         *template.Template
+	unsubscribe *htmltemplate.Template
+	conditions  map[string]string
+}
+
+// Catalog renders the notification messages from the templates of each
+// locale. Templates loaded from a directory can be reloaded while running.
+type Catalog struct {
+	mu            sync.RWMutex
+	fsys          fs.FS
+	defaultLocale string
+	locales       map[string]*localeTemplates
+}
+
+// NewCatalog loads the templates of every locale in fsys. The default locale
+// must be one of them.
+func NewCatalog(fsys fs.FS, defaultLocale string) (*Catalog, error) {
+	catalog := &Catalog{
+		fsys:          fsys,
+		defaultLocale: normalizeLocale(defaultLocale),
+	}
+	if err := catalog.Reload(); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+// NewDirCatalog loads the templates from dir, one subdirectory per locale.
+func NewDirCatalog(dir string, defaultLocale string) (*Catalog, error) {
+	return NewCatalog(os.DirFS(dir), defaultLocale)
+}
+
+// Reload parses the templates again. On error the loaded ones are kept.
+func (c *Catalog) Reload() error {
+	entries, err := fs.ReadDir(c.fsys, ".")
+	if err != nil {
+		return fmt.Errorf("error reading templates: %w", err)
+	}
+
+	locales := make(map[string]*localeTemplates)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		templates, err := parseLocale(c.fsys, entry.Name())
+		if err != nil {
+			return err
+		}
+		locales[normalizeLocale(entry.Name())] = templates
+	}
+
+	if _, ok := locales[c.defaultLocale]; !ok {
+		return fmt.Errorf("templates for default locale %q not found", c.defaultLocale)
+	}
+
+	c.mu.Lock()
+	c.locales = locales
+	c.mu.Unlock()
+
+	log.Printf("Loaded message templates for %d locale(s), default %s", len(locales), c.defaultLocale)
+	return nil
+}
+
+func parseLocale(fsys fs.FS, dir string) (*localeTemplates, error) {
+	var templates localeTemplates
+	var err error
+	if templates.subject, err = parseText(fsys, dir, subjectTemplate); err != nil {
+		return nil, err
+	}
+	if templates.text, err = parseText(fsys, dir, textTemplate); err != nil {
+		return nil, err
+	}
+	if templates.sms, err = parseText(fsys, dir, smsTemplate); err != nil {
+		return nil, err
+	}
+
+	if templates.html, err = parseOptionalHTML(fsys, dir, htmlTemplate); err != nil {
+		return nil, err
+	}
+	if templates.unsubscribe, err = parseOptionalHTML(fsys, dir, unsubscribeTemplate); err != nil {
+		return nil, err
+	}
+
+	name := path.Join(dir, conditionsFile)
+	if data, readErr := fs.ReadFile(fsys, name); readErr == nil {
+		if err := json.Unmarshal(data, &templates.conditions); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", name, err)
+		}
+	}
+
+	return &templates, nil
+}
+
+func parseOptionalHTML(fsys fs.FS, dir, file string) (*htmltemplate.Template, error) {
+	name := path.Join(dir, file)
+	if _, err := fs.Stat(fsys, name); err != nil {
+		return nil, nil
+	}
+	parsed, err := htmltemplate.New(file).Option("missingkey=error").ParseFS(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %w", name, err)
+	}
+	return parsed, nil
+}
+
+func parseText(fsys fs.FS, dir, file string) (*template.Template, error) {
+	name := path.Join(dir, file)
+	parsed, err := template.New(file).Option("missingkey=error").ParseFS(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %w", name, err)
+	}
+	return parsed, nil
+}
+
+// resolve returns the loaded locale that matches locale: the exact one, then
+// its language ("pt" for "pt-BR"), then the default locale.
+func (c *Catalog) resolve(locale string) string {
+	locale = normalizeLocale(locale)
+	if _, ok := c.locales[locale]; ok {
+		return locale
+	}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		if _, ok := c.locales[language]; ok {
+			return language
+		}
+	}
+	return c.defaultLocale
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+}
+
+// Render executes the templates of locale with data. The forecast description
+// is translated when the locale describes the condition code, and shortened
+// when needed so the SMS fits in one segment.
+func (c *Catalog) Render(locale string, data domain.MessageData) (*domain.RenderedMessage, error) {
+	c.mu.RLock()
+	locale = c.resolve(locale)
+	templates := c.locales[locale]
+	c.mu.RUnlock()
+
+	if description, ok := templates.conditions[strconv.FormatFloat(data.ForecastCode, 'f', -1, 64)]; ok {
+		data.ForecastDescription = description
+	}
+
+	message := domain.RenderedMessage{Locale: locale}
+	var err error
+	if message.Subject, err = execute(templates.subject, data); err != nil {
+		return nil, err
+	}
+	if message.Text, err = execute(templates.text, data); err != nil {
+		return nil, err
+	}
+	if message.SMS, err = renderSMS(templates.sms, data); err != nil {
+		return nil, err
+	}
+	if templates.html != nil {
+		var buffer bytes.Buffer
+		if err := templates.html.Execute(&buffer, data); err != nil {
+			return nil, fmt.Errorf("error rendering template %s/%s: %w", locale, htmlTemplate, err)
+		}
+		message.HTML = buffer.String()
+	}
+
+	return &message, nil
+}
+
+// RenderUnsubscribePage executes the unsubscribe page of locale, or of the
+// default locale when locale has none.
+func (c *Catalog) RenderUnsubscribePage(locale string, data domain.UnsubscribePageData) (string, error) {
+	c.mu.RLock()
+	locale = c.resolve(locale)
+	page := c.locales[locale].unsubscribe
+	if page == nil {
+		locale = c.defaultLocale
+		page = c.locales[locale].unsubscribe
+	}
+	c.mu.RUnlock()
+
+	if page == nil {
+		return "", fmt.Errorf("template %s not found for locale %q", unsubscribeTemplate, locale)
+	}
+
+	var buffer bytes.Buffer
+	if err := page.Execute(&buffer, data); err != nil {
+		return "", fmt.Errorf("error rendering template %s/%s: %w", locale, unsubscribeTemplate, err)
+	}
+	return buffer.String(), nil
+}
+
+func execute(tmpl *template.Template, data domain.MessageData) (string, error) {
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return "", fmt.Errorf("error rendering template %s: %w", tmpl.Name(), err)
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
+
+// Watch reloads the templates of dir every interval when any file changed,
+// until ctx is done.
+func (c *Catalog) Watch(ctx context.Context, dir string, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	go func() {
+		lastModified, _ := lastModification(dir)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			modified, err := lastModification(dir)
+			if err != nil {
+				log.Printf("Watch templates: %v", err)
+				continue
+			}
+			if !modified.After(lastModified) {
+				continue
+			}
+
+			if err := c.Reload(); err != nil {
+				log.Printf("Watch templates: keeping the loaded templates: %v", err)
+				continue
+			}
+			lastModified = modified
+		}
+	}()
+}
+
+func lastModification(dir string) (time.Time, error) {
+	var latest time.Time
+	err := fs.WalkDir(os.DirFS(dir), ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, err
+}
@@ -0,0 +1,86 @@
+package templates
+
+import (
+	"strings"
+	"text/template"
+	"unicode/utf16"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+// SMSMaxLength is the length of a single SMS segment in the GSM 7-bit
+// alphabet.
+const SMSMaxLength = 160
+
+// SMSMaxLengthUCS2 is the length of a single SMS segment of a text with
+// characters outside the GSM alphabet, which is sent as UCS-2.
+const SMSMaxLengthUCS2 = 70
+
+// smsEllipsis marks a shortened text; "…" is not in the GSM alphabet.
+const smsEllipsis = "..."
+
+// gsm7Basic and gsm7Extension are the characters of the GSM 03.38 default
+// alphabet; the extension ones take two septets.
+const (
+	gsm7Basic     = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7Extension = "\f^{}\\[~]|€"
+)
+
+// SMSLength returns the length of text in its encoding and the length of a
+// single segment of that encoding: septets and SMSMaxLength when every
+// character is in the GSM alphabet, UTF-16 units and SMSMaxLengthUCS2
+// otherwise.
+func SMSLength(text string) (length, limit int) {
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(gsm7Basic, r):
+			length++
+		case strings.ContainsRune(gsm7Extension, r):
+			length += 2
+		default:
+			return len(utf16.Encode([]rune(text))), SMSMaxLengthUCS2
+		}
+	}
+	return length, SMSMaxLength
+}
+
+// renderSMS executes the SMS template, shortening the forecast description
+// when the text does not fit in one segment, and cutting the text itself when
+// that is not enough.
+func renderSMS(tmpl *template.Template, data domain.MessageData) (string, error) {
+	text, err := execute(tmpl, data)
+	if err != nil {
+		return "", err
+	}
+
+	length, limit := SMSLength(text)
+	if length <= limit {
+		return text, nil
+	}
+
+	runes := []rune(data.ForecastDescription)
+	keep := max(len(runes)-(length-limit)-len(smsEllipsis), 0)
+	data.ForecastDescription = strings.TrimSpace(string(runes[:keep])) + smsEllipsis
+	text, err = execute(tmpl, data)
+	if err != nil {
+		return "", err
+	}
+
+	if length, limit := SMSLength(text); length > limit {
+		return truncateSMS(text), nil
+	}
+	return text, nil
+}
+
+// truncateSMS cuts text, ending it with smsEllipsis, so it fits in one
+// segment.
+func truncateSMS(text string) string {
+	runes := []rune(text)
+	for n := len(runes); n > 0; n-- {
+		candidate := strings.TrimSpace(string(runes[:n])) + smsEllipsis
+		if length, limit := SMSLength(candidate); length <= limit {
+			return candidate
+		}
+	}
+	return smsEllipsis
+}
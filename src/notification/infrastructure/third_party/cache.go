@@ -0,0 +1,149 @@
+package infrastructure
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache stores values of T by key for a ttl. Get returns a copy of the value.
+type Cache[T any] interface {
+	Get(ctx context.Context, key string) (*T, bool, error)
+	Set(ctx context.Context, key string, value T, ttl time.Duration) error
+}
+
+type ForecastCache = Cache[ForecastServiceResponse]
+
+// MemoryForecastCache keeps forecasts in process.
+type MemoryForecastCache = MemoryCache[ForecastServiceResponse]
+
+func NewMemoryForecastCache(maxEntries int) *MemoryForecastCache {
+	return NewMemoryCache[ForecastServiceResponse](maxEntries)
+}
+
+// RedisForecastCache shares forecasts between replicas through Redis.
+type RedisForecastCache = RedisCache[ForecastServiceResponse]
+
+func NewRedisForecastCache(client *redis.Client) *RedisForecastCache {
+	return NewRedisCache[ForecastServiceResponse](client, "forecast")
+}
+
+type memoryCacheEntry[T any] struct {
+	key       string
+	value     T
+	expiresAt time.Time
+}
+
+// MemoryCache is an in-process LRU cache bounded by maxEntries.
+type MemoryCache[T any] struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+func NewMemoryCache[T any](maxEntries int) *MemoryCache[T] {
+	return &MemoryCache[T]{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *MemoryCache[T]) Get(ctx context.Context, key string) (*T, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryCacheEntry[T])
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(element)
+	value := entry.value
+	return &value, true, nil
+}
+
+func (c *MemoryCache[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*memoryCacheEntry[T])
+		entry.value = value
+		entry.expiresAt = time.Now().Add(ttl)
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryCacheEntry[T]{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+	})
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry[T]).key)
+	}
+
+	return nil
+}
+
+// RedisCache shares values between replicas through Redis, stored as JSON.
+// The name of the values is used in the errors.
+type RedisCache[T any] struct {
+	Client *redis.Client
+	name   string
+}
+
+func NewRedisCache[T any](client *redis.Client, name string) *RedisCache[T] {
+	return &RedisCache[T]{
+		Client: client,
+		name:   name,
+	}
+}
+
+func (c *RedisCache[T]) Get(ctx context.Context, key string) (*T, bool, error) {
+	value, err := c.Client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting %s from Redis: %w", c.name, err)
+	}
+
+	var result T
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		return nil, false, fmt.Errorf("error decoding cached %s: %w", c.name, err)
+	}
+
+	return &result, true, nil
+}
+
+func (c *RedisCache[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error when try to map %s to JSON: %w", c.name, err)
+	}
+
+	if err := c.Client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("error while saving %s in Redis: %w", c.name, err)
+	}
+
+	return nil
+}
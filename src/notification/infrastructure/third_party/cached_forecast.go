@@ -0,0 +1,122 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+type CacheStats struct {
+	Hits     uint64 `json:"hits"`
+	Misses   uint64 `json:"misses"`
+	Bypasses uint64 `json:"bypasses"`
+}
+
+// CachedForecastService decorates an IForecastService, sharing forecasts
+// between requests whose coordinates fall in the same bucket. Keys include the
+// provider, whose condition codes differ, so switching providers does not
+// serve the codes of the previous one.
+type CachedForecastService struct {
+	next      IForecastService
+	provider  string
+	cache     ForecastCache
+	ttl       time.Duration
+	precision int
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	bypasses  atomic.Uint64
+}
+
+func NewCachedForecastService(next IForecastService, provider string, cache ForecastCache, ttl time.Duration, precision int) *CachedForecastService {
+	return &CachedForecastService{
+		next:      next,
+		provider:  provider,
+		cache:     cache,
+		ttl:       ttl,
+		precision: precision,
+	}
+}
+
+func (s *CachedForecastService) FetchForecastByLocation(location domain.DeliveryLocation, date string) (*ForecastServiceResponse, error) {
+	key := s.cacheKey(location, date)
+	ctx := context.Background()
+	cached, found, err := s.cache.Get(ctx, key)
+	if err != nil {
+		log.Printf("FetchForecastByLocation cache: %s", err)
+	}
+	if found {
+		s.hits.Add(1)
+		return cached, nil
+	}
+
+	s.misses.Add(1)
+	return s.fetchAndStore(ctx, key, location, date)
+}
+
+// Bypass returns a view of the service that always asks the upstream
+// provider and refreshes the cached entry with the result.
+func (s *CachedForecastService) Bypass() IForecastService {
+	return &bypassForecastService{cached: s}
+}
+
+func (s *CachedForecastService) MaxForecastDays() int {
+	return s.next.MaxForecastDays()
+}
+
+func (s *CachedForecastService) Stats() CacheStats {
+	return CacheStats{
+		Hits:     s.hits.Load(),
+		Misses:   s.misses.Load(),
+		Bypasses: s.bypasses.Load(),
+	}
+}
+
+func (s *CachedForecastService) fetchAndStore(ctx context.Context, key string, location domain.DeliveryLocation, date string) (*ForecastServiceResponse, error) {
+	forecast, err := s.next.FetchForecastByLocation(location, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.Set(ctx, key, *forecast, s.ttl); err != nil {
+		log.Printf("FetchForecastByLocation cache: %s", err)
+	}
+
+	return forecast, nil
+}
+
+func (s *CachedForecastService) cacheKey(location domain.DeliveryLocation, date string) string {
+	return fmt.Sprintf("forecast:%s:%s:%s:%s", s.provider, RoundCoordinate(location.Latitude, s.precision), RoundCoordinate(location.Longitude, s.precision), date)
+}
+
+// RoundCoordinate formats value rounded to precision decimals, the bucket
+// nearby locations share.
+func RoundCoordinate(value float64, precision int) string {
+	factor := math.Pow(10, float64(precision))
+	rounded := math.Round(value*factor) / factor
+	// Normalise -0 so both sides of the equator share a bucket.
+	if rounded == 0 {
+		rounded = 0
+	}
+	return strconv.FormatFloat(rounded, 'f', precision, 64)
+}
+
+type bypassForecastService struct {
+	cached *CachedForecastService
+}
+
+func (b *bypassForecastService) FetchForecastByLocation(location domain.DeliveryLocation, date string) (*ForecastServiceResponse, error) {
+	b.cached.bypasses.Add(1)
+
+	key := b.cached.cacheKey(location, date)
+	return b.cached.fetchAndStore(context.Background(), key, location, date)
+}
+
+func (b *bypassForecastService) MaxForecastDays() int {
+	return b.cached.MaxForecastDays()
+}
@@ -0,0 +1,77 @@
+package infrastructure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type GeocodeCache = Cache[GeocodeResult]
+
+// MemoryGeocodeCache keeps places in process.
+type MemoryGeocodeCache = MemoryCache[GeocodeResult]
+
+func NewMemoryGeocodeCache(maxEntries int) *MemoryGeocodeCache {
+	return NewMemoryCache[GeocodeResult](maxEntries)
+}
+
+// RedisGeocodeCache shares places between replicas through Redis.
+type RedisGeocodeCache = RedisCache[GeocodeResult]
+
+func NewRedisGeocodeCache(client *redis.Client) *RedisGeocodeCache {
+	return NewRedisCache[GeocodeResult](client, "place")
+}
+
+// CachedGeocoder decorates a Geocoder, sharing the place of an address
+// between requests. Addresses that differ only in case or spacing share an
+// entry. Unknown addresses are not cached.
+type CachedGeocoder struct {
+	next  Geocoder
+	cache GeocodeCache
+	ttl   time.Duration
+}
+
+func NewCachedGeocoder(next Geocoder, cache GeocodeCache, ttl time.Duration) *CachedGeocoder {
+	return &CachedGeocoder{
+		next:  next,
+		cache: cache,
+		ttl:   ttl,
+	}
+}
+
+func (g *CachedGeocoder) Geocode(address string) (*GeocodeResult, error) {
+	ctx := context.Background()
+	key := GeocodeCacheKey(address)
+
+	cached, found, err := g.cache.Get(ctx, key)
+	if err != nil {
+		log.Printf("Geocode cache: %s", err)
+	}
+	if found {
+		return cached, nil
+	}
+
+	result, err := g.next.Geocode(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.cache.Set(ctx, key, *result, g.ttl); err != nil {
+		log.Printf("Geocode cache: %s", err)
+	}
+
+	return result, nil
+}
+
+// GeocodeCacheKey returns "geocode:<sha256>" of the address lowercased with
+// its whitespace collapsed.
+func GeocodeCacheKey(address string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(address), " "))
+	sum := sha256.Sum256([]byte(normalized))
+	return "geocode:" + hex.EncodeToString(sum[:])
+}
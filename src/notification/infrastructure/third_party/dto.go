@@ -1,6 +1,26 @@
 package infrastructure
 
 type ForecastServiceResponse struct {
-	Code        float64 `json:"code"`
-	Description string  `json:"description"`
+	Code          float64          `json:"code"`
+	Description   string           `json:"description"`
+	Date          string           `json:"date,omitempty"`
+	Timezone      string           `json:"timezone,omitempty"`
+	MaxTempC      float64          `json:"max_temp_c"`
+	MinTempC      float64          `json:"min_temp_c"`
+	MaxWindKph    float64          `json:"max_wind_kph"`
+	TotalPrecipMm float64          `json:"total_precip_mm"`
+	TotalSnowCm   float64          `json:"total_snow_cm"`
+	ChanceOfRain  float64          `json:"chance_of_rain"`
+	Hours         []HourlyForecast `json:"hours,omitempty"`
+}
+
+type HourlyForecast struct {
+	Time         string  `json:"time"`
+	Code         float64 `json:"code"`
+	Description  string  `json:"description"`
+	TempC        float64 `json:"temp_c"`
+	WindKph      float64 `json:"wind_kph"`
+	PrecipMm     float64 `json:"precip_mm"`
+	SnowCm       float64 `json:"snow_cm"`
+	ChanceOfRain float64 `json:"chance_of_rain"`
 }
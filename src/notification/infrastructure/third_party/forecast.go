@@ -5,33 +5,73 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"time"
 
 	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
 )
 
+// IForecastService fetches the forecast of a single day, date being an ISO
+// date (2006-01-02) within the provider's horizon.
 type IForecastService interface {
-	FetchForecastByLocation(longitude, latitude, days string) (*ForecastServiceResponse, error)
+	FetchForecastByLocation(location domain.DeliveryLocation, date string) (*ForecastServiceResponse, error)
+	MaxForecastDays() int
 }
 
+const WeatherAPIMaxForecastDays = 14
+
 type ForecastService struct {
 	BaseURL string
 	APIKey  string
+	MaxDays int
 }
 
-func NewForecastService(cfg *server.Config) (*ForecastService, error) {
-	baseURL := cfg.ForecastServiceConfig.BaseURL
-	apiKey := cfg.ForecastServiceConfig.APIKey
+func NewWeatherAPIForecastService(cfg server.ForecastServiceConfig) (IForecastService, error) {
 	return &ForecastService{
-		BaseURL: baseURL,
-		APIKey:  apiKey,
+		BaseURL: cfg.BaseURL,
+		APIKey:  cfg.APIKey,
+		MaxDays: cfg.MaxDays,
 	}, nil
 }
 
-func (forecast *ForecastService) FetchForecastByLocation(longitude, latitude, days string) (*ForecastServiceResponse, error) {
-	url := forecast.BaseURL + "/forecast.json?key=" + forecast.APIKey + "&q=" + latitude + "," + longitude + "&days=" + days + "&aqi=no&alerts=no&lang=es"
-	log.Printf("URL: %s", url)
+func (forecast *ForecastService) MaxForecastDays() int {
+	if forecast.MaxDays > 0 {
+		return forecast.MaxDays
+	}
+	return WeatherAPIMaxForecastDays
+}
+
+func (forecast *ForecastService) FetchForecastByLocation(location domain.DeliveryLocation, date string) (*ForecastServiceResponse, error) {
+	query := url.Values{}
+	query.Set("key", forecast.APIKey)
+	query.Set("q", domain.FormatCoordinate(location.Latitude)+","+domain.FormatCoordinate(location.Longitude))
+	query.Set("dt", date)
+	query.Set("aqi", "no")
+	query.Set("alerts", "no")
+	log.Printf("URL: %s/forecast.json?q=%s&dt=%s", forecast.BaseURL, query.Get("q"), date)
+
+	body, err := fetchForecastBody(forecast.BaseURL + "/forecast.json?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
 
+	var document WeatherAPIForecastDocument
+	if err := json.Unmarshal(body, &document); err != nil {
+		log.Printf("FetchForecastByLocation: %s", err)
+		return nil, err
+	}
+
+	forecastServiceResponse, err := MapperToDTO(document, date)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return forecastServiceResponse, nil
+}
+
+func fetchForecastBody(url string) ([]byte, error) {
 	options := server.RequestOptions{
 		Method:         "GET",
 		URL:            url,
@@ -58,17 +98,5 @@ func (forecast *ForecastService) FetchForecastByLocation(longitude, latitude, da
 		return nil, err
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		log.Printf("FetchDataUbica: %s", err)
-		return nil, err
-	}
-
-	forecastServiceResponse, err := MapperToDTO(result)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return forecastServiceResponse, nil
+	return body, nil
 }
@@ -0,0 +1,55 @@
+package infrastructure
+
+// WeatherAPIForecastDocument is the body returned by the WeatherAPI-style
+// /forecast.json endpoint. Sections are pointers so a missing block can be
+// told apart from a zero valued one.
+type WeatherAPIForecastDocument struct {
+	Location *WeatherAPILocation `json:"location"`
+	Forecast *WeatherAPIForecast `json:"forecast"`
+}
+
+type WeatherAPILocation struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lon"`
+	TzID      string  `json:"tz_id"`
+}
+
+type WeatherAPIForecast struct {
+	ForecastDay []WeatherAPIForecastDay `json:"forecastday"`
+}
+
+type WeatherAPIForecastDay struct {
+	Date string           `json:"date"`
+	Day  *WeatherAPIDay   `json:"day"`
+	Hour []WeatherAPIHour `json:"hour"`
+}
+
+type WeatherAPIDay struct {
+	MaxTempC          float64              `json:"maxtemp_c"`
+	MinTempC          float64              `json:"mintemp_c"`
+	AvgTempC          float64              `json:"avgtemp_c"`
+	MaxWindKph        float64              `json:"maxwind_kph"`
+	TotalPrecipMm     float64              `json:"totalprecip_mm"`
+	TotalSnowCm       float64              `json:"totalsnow_cm"`
+	DailyChanceOfRain float64              `json:"daily_chance_of_rain"`
+	DailyChanceOfSnow float64              `json:"daily_chance_of_snow"`
+	Condition         *WeatherAPICondition `json:"condition"`
+}
+
+type WeatherAPIHour struct {
+	TimeEpoch    int64                `json:"time_epoch"`
+	Time         string               `json:"time"`
+	TempC        float64              `json:"temp_c"`
+	WindKph      float64              `json:"wind_kph"`
+	PrecipMm     float64              `json:"precip_mm"`
+	SnowCm       float64              `json:"snow_cm"`
+	ChanceOfRain float64              `json:"chance_of_rain"`
+	ChanceOfSnow float64              `json:"chance_of_snow"`
+	Condition    *WeatherAPICondition `json:"condition"`
+}
+
+type WeatherAPICondition struct {
+	Text string  `json:"text"`
+	Code float64 `json:"code"`
+}
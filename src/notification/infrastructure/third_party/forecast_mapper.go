@@ -2,41 +2,89 @@ package infrastructure
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 )
 
-func MapperToDTO(body map[string]interface{}) (*ForecastServiceResponse, error) {
+func MapperToDTO(document WeatherAPIForecastDocument, date string) (*ForecastServiceResponse, error) {
 
-	forecast, ok := body["forecast"].(map[string]interface{})
+	if document.Forecast == nil {
+		return nil, fmt.Errorf("forecast section is missing")
+	}
 
-	if !ok {
-		return nil, fmt.Errorf("forecast is not a nested JSON object")
+	forecastDays := document.Forecast.ForecastDay
+	index := slices.IndexFunc(forecastDays, func(forecastDay WeatherAPIForecastDay) bool {
+		return forecastDay.Date == date
+	})
+	if index < 0 {
+		return nil, fmt.Errorf("forecast.forecastday has %d day(s), forecast for %s is missing", len(forecastDays), date)
 	}
 
-	forecastday, ok := forecast["forecastday"].([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("forecastday is not a nested JSON object")
+	forecastDay := forecastDays[index]
+	section := fmt.Sprintf("forecast.forecastday[%d]", index)
+
+	day := forecastDay.Day
+	if day == nil {
+		return nil, fmt.Errorf("%s.day section is missing", section)
 	}
 
-	secondElement, ok := forecastday[1].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("day is not a nested JSON object")
+	if day.Condition == nil {
+		return nil, fmt.Errorf("%s.day.condition section is missing", section)
 	}
 
-	day, ok := secondElement["day"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("condition is not a nested JSON object")
+	if day.Condition.Code == 0 || day.Condition.Text == "" {
+		return nil, fmt.Errorf("%s.day.condition requires code and text", section)
 	}
 
-	var forecastServiceResponse ForecastServiceResponse
+	hours, err := mapWeatherAPIHours(section, forecastDay.Hour)
+	if err != nil {
+		return nil, err
+	}
 
-	if condition, ok := day["condition"].(map[string]interface{}); ok {
-		forecastServiceResponse.Code = condition["code"].(float64)
-		forecastServiceResponse.Description = condition["text"].(string)
+	forecastServiceResponse := ForecastServiceResponse{
+		Code:          day.Condition.Code,
+		Description:   day.Condition.Text,
+		Date:          forecastDay.Date,
+		MaxTempC:      day.MaxTempC,
+		MinTempC:      day.MinTempC,
+		MaxWindKph:    day.MaxWindKph,
+		TotalPrecipMm: day.TotalPrecipMm,
+		TotalSnowCm:   day.TotalSnowCm,
+		ChanceOfRain:  day.DailyChanceOfRain,
+		Hours:         hours,
 	}
 
-	if forecastServiceResponse.Code == 0 || forecastServiceResponse.Description == "" {
-		return nil, fmt.Errorf("forecast response could not be map succesfully")
+	if document.Location != nil {
+		forecastServiceResponse.Timezone = document.Location.TzID
 	}
 
 	return &forecastServiceResponse, nil
 }
+
+func mapWeatherAPIHours(section string, entries []WeatherAPIHour) ([]HourlyForecast, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	hours := make([]HourlyForecast, len(entries))
+	for i, entry := range entries {
+		if entry.Time == "" {
+			return nil, fmt.Errorf("%s.hour[%d].time is missing", section, i)
+		}
+		if entry.Condition == nil {
+			return nil, fmt.Errorf("%s.hour[%d].condition section is missing", section, i)
+		}
+		hours[i] = HourlyForecast{
+			Time:         strings.Replace(entry.Time, " ", "T", 1),
+			Code:         entry.Condition.Code,
+			Description:  entry.Condition.Text,
+			TempC:        entry.TempC,
+			WindKph:      entry.WindKph,
+			PrecipMm:     entry.PrecipMm,
+			SnowCm:       entry.SnowCm,
+			ChanceOfRain: entry.ChanceOfRain,
+		}
+	}
+
+	return hours, nil
+}
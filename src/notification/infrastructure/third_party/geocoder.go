@@ -0,0 +1,114 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+)
+
+const (
+	NominatimDefaultBaseURL   = "https://nominatim.openstreetmap.org"
+	NominatimDefaultUserAgent = "delivery-notifier-buyer"
+)
+
+// ErrAddressNotFound is returned by a Geocoder when the address matches no
+// place.
+var ErrAddressNotFound = errors.New("address not found")
+
+// GeocodeResult is the place an address resolves to; the coordinates keep
+// the decimal strings of the provider.
+type GeocodeResult struct {
+	Latitude    string `json:"latitude"`
+	Longitude   string `json:"longitude"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// Geocoder resolves a postal address to coordinates.
+type Geocoder interface {
+	Geocode(address string) (*GeocodeResult, error)
+}
+
+// NominatimGeocoder resolves addresses with the search API of Nominatim or a
+// compatible service. Its usage policy requires an identifying User-Agent.
+type NominatimGeocoder struct {
+	BaseURL      string
+	UserAgent    string
+	CountryCodes string
+}
+
+func NewNominatimGeocoder(cfg server.GeocoderConfig) *NominatimGeocoder {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = NominatimDefaultBaseURL
+	}
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = NominatimDefaultUserAgent
+	}
+	return &NominatimGeocoder{
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		UserAgent:    userAgent,
+		CountryCodes: cfg.CountryCodes,
+	}
+}
+
+type nominatimPlace struct {
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	DisplayName string `json:"display_name"`
+}
+
+func (geocoder *NominatimGeocoder) Geocode(address string) (*GeocodeResult, error) {
+	query := url.Values{}
+	query.Set("q", address)
+	query.Set("format", "jsonv2")
+	query.Set("limit", "1")
+	if geocoder.CountryCodes != "" {
+		query.Set("countrycodes", geocoder.CountryCodes)
+	}
+
+	resp, err := server.DoRequestWithRetry(server.RequestOptions{
+		Method:         "GET",
+		URL:            geocoder.BaseURL + "/search?" + query.Encode(),
+		Headers:        map[string]string{"User-Agent": geocoder.UserAgent, "Accept": "application/json"},
+		MaxRetries:     3,
+		RetryDelay:     2 * time.Second,
+		RequestTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("Geocode: got http status %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to communicate with the geocoding service")
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var places []nominatimPlace
+	if err := json.Unmarshal(body, &places); err != nil {
+		log.Printf("Geocode: %s", err)
+		return nil, fmt.Errorf("error decoding geocoding response: %w", err)
+	}
+	if len(places) == 0 || places[0].Lat == "" || places[0].Lon == "" {
+		return nil, ErrAddressNotFound
+	}
+
+	return &GeocodeResult{
+		Latitude:    places[0].Lat,
+		Longitude:   places[0].Lon,
+		DisplayName: places[0].DisplayName,
+	}, nil
+}
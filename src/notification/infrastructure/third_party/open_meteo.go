@@ -0,0 +1,70 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"log"
+	"net/url"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+const (
+	OpenMeteoDefaultBaseURL  = "https://api.open-meteo.com"
+	OpenMeteoMaxForecastDays = 16
+)
+
+type OpenMeteoForecastService struct {
+	BaseURL string
+	MaxDays int
+}
+
+func NewOpenMeteoForecastService(cfg server.ForecastServiceConfig) (IForecastService, error) {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = OpenMeteoDefaultBaseURL
+	}
+	return &OpenMeteoForecastService{
+		BaseURL: baseURL,
+		MaxDays: cfg.MaxDays,
+	}, nil
+}
+
+func (forecast *OpenMeteoForecastService) MaxForecastDays() int {
+	if forecast.MaxDays > 0 {
+		return forecast.MaxDays
+	}
+	return OpenMeteoMaxForecastDays
+}
+
+func (forecast *OpenMeteoForecastService) FetchForecastByLocation(location domain.DeliveryLocation, date string) (*ForecastServiceResponse, error) {
+	query := url.Values{}
+	query.Set("latitude", domain.FormatCoordinate(location.Latitude))
+	query.Set("longitude", domain.FormatCoordinate(location.Longitude))
+	query.Set("daily", openMeteoDailyFields)
+	query.Set("hourly", openMeteoHourlyFields)
+	query.Set("start_date", date)
+	query.Set("end_date", date)
+	query.Set("timezone", "auto")
+	requestURL := forecast.BaseURL + "/v1/forecast?" + query.Encode()
+	log.Printf("URL: %s", requestURL)
+
+	body, err := fetchForecastBody(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var document OpenMeteoForecastDocument
+	if err := json.Unmarshal(body, &document); err != nil {
+		log.Printf("FetchForecastByLocation: %s", err)
+		return nil, err
+	}
+
+	forecastServiceResponse, err := OpenMeteoMapperToDTO(document, date)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return forecastServiceResponse, nil
+}
@@ -0,0 +1,38 @@
+package infrastructure
+
+// OpenMeteoForecastDocument is the body returned by the Open-Meteo-style
+// /v1/forecast endpoint. Each block holds parallel arrays indexed by time.
+type OpenMeteoForecastDocument struct {
+	Latitude         float64          `json:"latitude"`
+	Longitude        float64          `json:"longitude"`
+	Timezone         string           `json:"timezone"`
+	UTCOffsetSeconds int              `json:"utc_offset_seconds"`
+	Daily            *OpenMeteoDaily  `json:"daily"`
+	Hourly           *OpenMeteoHourly `json:"hourly"`
+}
+
+type OpenMeteoDaily struct {
+	Time                        []string  `json:"time"`
+	WeatherCode                 []float64 `json:"weather_code"`
+	TemperatureMax              []float64 `json:"temperature_2m_max"`
+	TemperatureMin              []float64 `json:"temperature_2m_min"`
+	PrecipitationSum            []float64 `json:"precipitation_sum"`
+	SnowfallSum                 []float64 `json:"snowfall_sum"`
+	PrecipitationProbabilityMax []float64 `json:"precipitation_probability_max"`
+	WindSpeedMax                []float64 `json:"wind_speed_10m_max"`
+}
+
+type OpenMeteoHourly struct {
+	Time                     []string  `json:"time"`
+	WeatherCode              []float64 `json:"weather_code"`
+	Temperature              []float64 `json:"temperature_2m"`
+	Precipitation            []float64 `json:"precipitation"`
+	PrecipitationProbability []float64 `json:"precipitation_probability"`
+	Snowfall                 []float64 `json:"snowfall"`
+	WindSpeed                []float64 `json:"wind_speed_10m"`
+}
+
+const (
+	openMeteoDailyFields  = "weather_code,temperature_2m_max,temperature_2m_min,precipitation_sum,snowfall_sum,precipitation_probability_max,wind_speed_10m_max"
+	openMeteoHourlyFields = "weather_code,temperature_2m,precipitation,precipitation_probability,snowfall,wind_speed_10m"
+)
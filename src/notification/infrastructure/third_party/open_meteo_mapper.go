@@ -0,0 +1,171 @@
+package infrastructure
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// wmoDescriptions maps the WMO weather interpretation codes reported by
+// Open-Meteo to an English description; templates translate it per locale
+// through conditions.json.
+var wmoDescriptions = map[int]string{
+	0:  "Clear sky",
+	1:  "Mainly clear",
+	2:  "Partly cloudy",
+	3:  "Overcast",
+	45: "Fog",
+	48: "Depositing rime fog",
+	51: "Light drizzle",
+	53: "Moderate drizzle",
+	55: "Dense drizzle",
+	56: "Light freezing drizzle",
+	57: "Dense freezing drizzle",
+	61: "Slight rain",
+	63: "Moderate rain",
+	65: "Heavy rain",
+	66: "Light freezing rain",
+	67: "Heavy freezing rain",
+	71: "Slight snowfall",
+	73: "Moderate snowfall",
+	75: "Heavy snowfall",
+	77: "Snow grains",
+	80: "Slight rain showers",
+	81: "Moderate rain showers",
+	82: "Violent rain showers",
+	85: "Slight snow showers",
+	86: "Heavy snow showers",
+	95: "Thunderstorm",
+	96: "Thunderstorm with slight hail",
+	99: "Thunderstorm with heavy hail",
+}
+
+func WMODescription(code int) (string, bool) {
+	description, ok := wmoDescriptions[code]
+	return description, ok
+}
+
+type openMeteoSeries struct {
+	name   string
+	values []float64
+}
+
+func OpenMeteoMapperToDTO(document OpenMeteoForecastDocument, date string) (*ForecastServiceResponse, error) {
+
+	daily := document.Daily
+	if daily == nil {
+		return nil, fmt.Errorf("daily section is missing")
+	}
+
+	days := len(daily.WeatherCode)
+	if len(daily.Time) != days {
+		return nil, fmt.Errorf("daily.time has %d value(s), expected %d", len(daily.Time), days)
+	}
+
+	if err := checkOpenMeteoSeries("daily", days, []openMeteoSeries{
+		{"temperature_2m_max", daily.TemperatureMax},
+		{"temperature_2m_min", daily.TemperatureMin},
+		{"precipitation_sum", daily.PrecipitationSum},
+		{"snowfall_sum", daily.SnowfallSum},
+		{"precipitation_probability_max", daily.PrecipitationProbabilityMax},
+		{"wind_speed_10m_max", daily.WindSpeedMax},
+	}); err != nil {
+		return nil, err
+	}
+
+	index := slices.Index(daily.Time, date)
+	if index < 0 {
+		return nil, fmt.Errorf("daily.time has %d day(s), forecast for %s is missing", days, date)
+	}
+
+	code := daily.WeatherCode[index]
+	description, ok := WMODescription(int(code))
+	if !ok {
+		return nil, fmt.Errorf("daily.weather_code[%d] %v is not a known WMO code", index, code)
+	}
+
+	hours, err := mapOpenMeteoHours(document.Hourly, daily.Time[index])
+	if err != nil {
+		return nil, err
+	}
+
+	return &ForecastServiceResponse{
+		Code:          code,
+		Description:   description,
+		Date:          daily.Time[index],
+		Timezone:      document.Timezone,
+		MaxTempC:      seriesValue(daily.TemperatureMax, index),
+		MinTempC:      seriesValue(daily.TemperatureMin, index),
+		MaxWindKph:    seriesValue(daily.WindSpeedMax, index),
+		TotalPrecipMm: seriesValue(daily.PrecipitationSum, index),
+		TotalSnowCm:   seriesValue(daily.SnowfallSum, index),
+		ChanceOfRain:  seriesValue(daily.PrecipitationProbabilityMax, index),
+		Hours:         hours,
+	}, nil
+}
+
+// mapOpenMeteoHours returns the hourly entries that belong to date.
+func mapOpenMeteoHours(hourly *OpenMeteoHourly, date string) ([]HourlyForecast, error) {
+	if hourly == nil || len(hourly.Time) == 0 {
+		return nil, nil
+	}
+
+	entries := len(hourly.Time)
+	if err := checkOpenMeteoSeries("hourly", entries, []openMeteoSeries{
+		{"weather_code", hourly.WeatherCode},
+		{"temperature_2m", hourly.Temperature},
+		{"precipitation", hourly.Precipitation},
+		{"precipitation_probability", hourly.PrecipitationProbability},
+		{"snowfall", hourly.Snowfall},
+		{"wind_speed_10m", hourly.WindSpeed},
+	}); err != nil {
+		return nil, err
+	}
+	if len(hourly.WeatherCode) == 0 {
+		return nil, fmt.Errorf("hourly.weather_code is missing")
+	}
+
+	var hours []HourlyForecast
+	for i, hourTime := range hourly.Time {
+		if !strings.HasPrefix(hourTime, date) {
+			continue
+		}
+
+		code := hourly.WeatherCode[i]
+		description, ok := WMODescription(int(code))
+		if !ok {
+			return nil, fmt.Errorf("hourly.weather_code[%d] %v is not a known WMO code", i, code)
+		}
+
+		hours = append(hours, HourlyForecast{
+			Time:         hourTime,
+			Code:         code,
+			Description:  description,
+			TempC:        seriesValue(hourly.Temperature, i),
+			WindKph:      seriesValue(hourly.WindSpeed, i),
+			PrecipMm:     seriesValue(hourly.Precipitation, i),
+			SnowCm:       seriesValue(hourly.Snowfall, i),
+			ChanceOfRain: seriesValue(hourly.PrecipitationProbability, i),
+		})
+	}
+
+	return hours, nil
+}
+
+// checkOpenMeteoSeries verifies that every requested series lines up with the
+// time axis of its block. Series the upstream omitted entirely are allowed.
+func checkOpenMeteoSeries(section string, length int, series []openMeteoSeries) error {
+	for _, s := range series {
+		if len(s.values) != 0 && len(s.values) != length {
+			return fmt.Errorf("%s.%s has %d value(s), expected %d", section, s.name, len(s.values), length)
+		}
+	}
+	return nil
+}
+
+func seriesValue(values []float64, index int) float64 {
+	if index < len(values) {
+		return values[index]
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+)
+
+const (
+	WeatherAPIProvider = "weatherapi"
+	OpenMeteoProvider  = "openmeteo"
+	DefaultProvider    = WeatherAPIProvider
+)
+
+type ForecastProviderFactory func(cfg server.ForecastServiceConfig) (IForecastService, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ForecastProviderFactory{
+		WeatherAPIProvider: NewWeatherAPIForecastService,
+		OpenMeteoProvider:  NewOpenMeteoForecastService,
+	}
+)
+
+func RegisterForecastProvider(name string, factory ForecastProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[strings.ToLower(name)] = factory
+}
+
+// ForecastProviderName returns the registry name of the configured provider.
+func ForecastProviderName(cfg *server.Config) string {
+	name := strings.ToLower(cfg.ForecastServiceConfig.Provider)
+	if name == "" {
+		return DefaultProvider
+	}
+	return name
+}
+
+func NewForecastService(cfg *server.Config) (IForecastService, error) {
+	name := ForecastProviderName(cfg)
+
+	providersMu.RLock()
+	factory, ok := providers[name]
+	providersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("forecast provider %q is not registered", cfg.ForecastServiceConfig.Provider)
+	}
+
+	return factory(cfg.ForecastServiceConfig)
+}
@@ -0,0 +1,229 @@
+package usecases
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+)
+
+const (
+	BatchStatusSent       = "sent"
+	BatchStatusSkipped    = "skipped"
+	BatchStatusSuppressed = "suppressed"
+	BatchStatusDuplicate  = "duplicate"
+	BatchStatusInvalid    = "invalid"
+	BatchStatusFailed     = "failed"
+	BatchStatusCancelled  = "cancelled"
+)
+
+// BatchItem is one entry of a batch. Line is the line number for
+// newline-delimited input and the 1-based position for a JSON array.
+type BatchItem struct {
+	Line    int
+	Request RequestDataNotification
+	Err     error
+}
+
+// ParseBatchRequests reads a JSON array or newline-delimited JSON of
+// RequestDataNotification. Entries that cannot be decoded are returned with
+// Err set so the rest of the batch can still be processed.
+func ParseBatchRequests(body io.Reader, maxItems int) ([]BatchItem, error) {
+	reader := bufio.NewReader(body)
+	first, err := peekFirstNonSpace(reader)
+	if err == io.EOF {
+		return nil, &domain.ValidationError{Field: "body", Message: "batch is empty"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []BatchItem
+	if first == '[' {
+		items, err = parseJSONArrayBatch(reader, maxItems)
+	} else {
+		items, err = parseNDJSONBatch(reader, maxItems)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, &domain.ValidationError{Field: "body", Message: "batch is empty"}
+	}
+
+	return items, nil
+}
+
+func parseJSONArrayBatch(reader io.Reader, maxItems int) ([]BatchItem, error) {
+	decoder := json.NewDecoder(reader)
+	if _, err := decoder.Token(); err != nil {
+		return nil, &domain.ValidationError{Field: "body", Message: "must be a JSON array or newline-delimited JSON"}
+	}
+
+	var items []BatchItem
+	for position := 1; decoder.More(); position++ {
+		if maxItems > 0 && position > maxItems {
+			return nil, &domain.ValidationError{Field: "body", Message: fmt.Sprintf("batch exceeds %d items", maxItems)}
+		}
+
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, &domain.ValidationError{Field: "body", Message: fmt.Sprintf("malformed JSON array at item %d", position)}
+		}
+		items = append(items, decodeBatchItem(position, raw))
+	}
+
+	return items, nil
+}
+
+func parseNDJSONBatch(reader io.Reader, maxItems int) ([]BatchItem, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var items []BatchItem
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		if maxItems > 0 && len(items) >= maxItems {
+			return nil, &domain.ValidationError{Field: "body", Message: fmt.Sprintf("batch exceeds %d items", maxItems)}
+		}
+		items = append(items, decodeBatchItem(line, raw))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading batch: %w", err)
+	}
+
+	return items, nil
+}
+
+func decodeBatchItem(line int, raw []byte) BatchItem {
+	item := BatchItem{Line: line}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&item.Request); err != nil {
+		item.Err = &domain.ValidationError{Field: "body", Message: "invalid JSON data"}
+		return item
+	}
+
+	if !IsValidEmail(item.Request.Email) {
+		item.Err = &domain.ValidationError{Field: "email", Message: "must be a valid email"}
+	} else if item.Request.Phone != "" && !IsValidPhone(item.Request.Phone) {
+		item.Err = &domain.ValidationError{Field: "phone", Message: "must be an E.164 number"}
+	} else if item.Request.CallbackURL != "" && !IsValidCallbackURL(item.Request.CallbackURL) {
+		item.Err = &domain.ValidationError{Field: "callback_url", Message: "must be an absolute http(s) URL"}
+	}
+
+	return item
+}
+
+func peekFirstNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, reader.UnreadByte()
+		}
+	}
+}
+
+// SendBatchNotifications runs SendNotification for every valid item with at
+// most concurrency items in flight. Results keep the order of the items. Once
+// ctx is done no more items are started; the remaining ones are cancelled.
+func SendBatchNotifications(ctx context.Context, items []BatchItem, concurrency int, forecastService third_party.IForecastService, repository domain.NotificationRepository, renderer domain.MessageRenderer, settings NotificationSettings) *BatchServiceResponse {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]BatchItemResult, len(items))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		results[i] = BatchItemResult{Line: item.Line, Email: item.Request.Email}
+		if item.Err != nil {
+			results[i].Status = BatchStatusInvalid
+			results[i].Error = item.Err.Error()
+			continue
+		}
+
+		if ctx.Err() == nil {
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			results[i].Status = BatchStatusCancelled
+			results[i].Error = err.Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *BatchItemResult, request RequestDataNotification) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			response, err := SendNotification(request, forecastService, repository, renderer, settings)
+			switch {
+			case err != nil:
+				result.Status = BatchStatusFailed
+				if _, ok := err.(*domain.ValidationError); ok {
+					result.Status = BatchStatusInvalid
+				}
+				result.Error = err.Error()
+			case response.DeliveryStatus == domain.DeliveryStatusSuppressed:
+				result.Status = BatchStatusSuppressed
+				result.Result = response
+			case response.DeliveryStatus == domain.DeliveryStatusDuplicate:
+				result.Status = BatchStatusDuplicate
+				result.Result = response
+			case response.BuyerNotification:
+				result.Status = BatchStatusSent
+				result.Result = response
+			default:
+				result.Status = BatchStatusSkipped
+				result.Result = response
+			}
+		}(&results[i], item.Request)
+	}
+
+	wg.Wait()
+
+	batchResponse := BatchServiceResponse{
+		Total:   len(results),
+		Results: results,
+	}
+	for _, result := range results {
+		switch result.Status {
+		case BatchStatusSent:
+			batchResponse.Sent++
+		case BatchStatusSkipped:
+			batchResponse.Skipped++
+		case BatchStatusSuppressed:
+			batchResponse.Suppressed++
+		case BatchStatusDuplicate:
+			batchResponse.Duplicate++
+		case BatchStatusInvalid:
+			batchResponse.Invalid++
+		case BatchStatusFailed:
+			batchResponse.Failed++
+		case BatchStatusCancelled:
+			batchResponse.Cancelled++
+		}
+	}
+
+	return &batchResponse
+}
@@ -0,0 +1,146 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+// NormalizeNotificationCode formats a code the same way forecast codes are
+// compared in RequireBuyerNotification, so "1063.0" and "1063" are one code.
+func NormalizeNotificationCode(code string) (string, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(code), 64)
+	if err != nil {
+		return "", &domain.ValidationError{Field: "code", Message: "must be a numeric forecast code"}
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64), nil
+}
+
+func ListNotificationCodes(repository domain.NotificationRepository) (*NotificationCodesServiceResponse, error) {
+	ctx := context.Background()
+	codes, err := repository.ListNotificationCodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotificationCodesServiceResponse{
+		Codes: MapNotificationCodesToDTOs(codes),
+	}, nil
+}
+
+func AddNotificationCode(request RequestNotificationCode, user string, repository domain.NotificationRepository) (*NotificationCodeDetail, error) {
+	code, err := newNotificationCode(request, user, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if err := repository.SaveNotificationCode(ctx, *code); err != nil {
+		return nil, err
+	}
+	log.Printf("AddNotificationCode %s by %s", code.Code, user)
+
+	detail := NotificationCodeToDTO(*code)
+	return &detail, nil
+}
+
+// RemoveNotificationCode deletes a code. Deleting the last one disables the
+// code-based notifications, so it requires confirm.
+func RemoveNotificationCode(code string, confirm bool, user string, repository domain.NotificationRepository) error {
+	normalized, err := NormalizeNotificationCode(code)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if !confirm {
+		codes, err := repository.GetNotificationCodes(ctx)
+		if err != nil {
+			return err
+		}
+		if len(codes) == 1 && codes[0] == normalized {
+			return &domain.ValidationError{Field: "confirm", Message: "must be true to remove the last code"}
+		}
+	}
+
+	if err := repository.DeleteNotificationCode(ctx, normalized); err != nil {
+		return err
+	}
+	log.Printf("RemoveNotificationCode %s by %s", normalized, user)
+
+	return nil
+}
+
+// ReplaceNotificationCodes replaces every code. An empty list disables the
+// code-based notifications, so it requires confirm.
+func ReplaceNotificationCodes(requests []RequestNotificationCode, confirm bool, user string, repository domain.NotificationRepository) (*NotificationCodesServiceResponse, error) {
+	if len(requests) == 0 && !confirm {
+		return nil, &domain.ValidationError{Field: "confirm", Message: "must be true to remove every code"}
+	}
+
+	now := time.Now()
+	codes := make([]domain.NotificationCode, len(requests))
+	seen := make(map[string]bool, len(requests))
+	for i, request := range requests {
+		code, err := newNotificationCode(request, user, now)
+		if err != nil {
+			return nil, err
+		}
+		if seen[code.Code] {
+			return nil, &domain.ValidationError{Field: "code", Message: fmt.Sprintf("%s is duplicated", code.Code)}
+		}
+		seen[code.Code] = true
+		codes[i] = *code
+	}
+
+	ctx := context.Background()
+	if err := repository.ReplaceNotificationCodes(ctx, codes); err != nil {
+		return nil, err
+	}
+	log.Printf("ReplaceNotificationCodes %d codes by %s", len(codes), user)
+
+	return &NotificationCodesServiceResponse{
+		Codes: MapNotificationCodesToDTOs(codes),
+	}, nil
+}
+
+func newNotificationCode(request RequestNotificationCode, user string, now time.Time) (*domain.NotificationCode, error) {
+	code, err := NormalizeNotificationCode(request.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	description := strings.TrimSpace(request.Description)
+	if description == "" {
+		return nil, &domain.ValidationError{Field: "description", Message: "is required"}
+	}
+
+	return &domain.NotificationCode{
+		Code:        code,
+		Description: description,
+		UpdatedBy:   user,
+		UpdatedAt:   now,
+	}, nil
+}
+
+func NotificationCodeToDTO(code domain.NotificationCode) NotificationCodeDetail {
+	return NotificationCodeDetail{
+		Code:        code.Code,
+		Description: code.Description,
+		UpdatedBy:   code.UpdatedBy,
+		UpdatedAt:   code.UpdatedAt,
+	}
+}
+
+func MapNotificationCodesToDTOs(codes []domain.NotificationCode) []NotificationCodeDetail {
+	dtos := make([]NotificationCodeDetail, len(codes))
+	for i, code := range codes {
+		dtos[i] = NotificationCodeToDTO(code)
+	}
+	return dtos
+}
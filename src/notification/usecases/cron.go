@@ -0,0 +1,153 @@
+package usecases
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a standard five field cron expression: minute, hour, day of
+// month, month and day of week. Fields accept *, numbers, ranges (1-5), steps
+// (*/15, 8-18/2) and lists of them; Sunday is 0 or 7.
+type CronSchedule struct {
+	minutes, hours, days, months, weekdays uint64
+	// Like cron, when both days fields are restricted a day matching either
+	// of them matches. A field is unrestricted when it holds every value,
+	// however it is written.
+	anyDay, anyWeekday bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCronSchedule parses expression, such as "0 18 * * *" for every day at
+// 18:00.
+func ParseCronSchedule(expression string) (*CronSchedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected %d fields", expression, len(cronFields))
+	}
+
+	var bits [5]uint64
+	for i, field := range fields {
+		value, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expression, err)
+		}
+		bits[i] = value
+	}
+	// Sunday may be written as 7.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &CronSchedule{
+		minutes:    bits[0],
+		hours:      bits[1],
+		days:       bits[2],
+		months:     bits[3],
+		weekdays:   bits[4],
+		anyDay:     bits[2] == cronRange(1, 31),
+		anyWeekday: bits[4]&cronRange(0, 6) == cronRange(0, 6),
+	}, nil
+}
+
+// cronRange returns the bits of the values from to to.
+func cronRange(from, to int) uint64 {
+	return (1<<uint(to+1) - 1) &^ (1<<uint(from) - 1)
+}
+
+func parseCronField(value string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepPart, field.name)
+			}
+		}
+
+		from, to := field.min, field.max
+		if rangePart != "*" {
+			start, end, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if from, err = strconv.Atoi(start); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s", part, field.name)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(end); err != nil {
+					return 0, fmt.Errorf("invalid value %q in %s", part, field.name)
+				}
+			} else if hasStep {
+				to = field.max
+			}
+		}
+		if from < field.min || to > field.max || from > to {
+			return 0, fmt.Errorf("%s %q out of range %d-%d", field.name, part, field.min, field.max)
+		}
+
+		for i := from; i <= to; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time after after that matches the schedule, read in
+// the location of after.
+func (s *CronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	// Every valid expression matches within a few years; February 29 on a
+	// given weekday is the worst case.
+	limit := t.AddDate(30, 0, 0)
+	for t.Before(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
@@ -0,0 +1,20 @@
+package usecases
+
+import (
+	"strings"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+)
+
+// DedupKey identifies the notifications of the same buyer, delivery date and
+// location bucket. Coordinates are rounded to precision decimals, so nearby
+// addresses share a key.
+func DedupKey(email, deliveryDate string, location domain.DeliveryLocation, precision int) string {
+	return strings.Join([]string{
+		domain.NormalizeEmail(email),
+		deliveryDate,
+		third_party.RoundCoordinate(location.Latitude, precision),
+		third_party.RoundCoordinate(location.Longitude, precision),
+	}, ":")
+}
@@ -0,0 +1,398 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+)
+
+const defaultScheduleDays = 7
+
+// deliveryStatusTransitions are the statuses each status of a delivery may
+// move to.
+var deliveryStatusTransitions = map[string][]string{
+	domain.DeliveryScheduled: {domain.DeliveryInTransit, domain.DeliveryDelivered, domain.DeliveryCancelled},
+	domain.DeliveryInTransit: {domain.DeliveryDelivered, domain.DeliveryCancelled},
+}
+
+// RegisterDelivery validates the delivery of an order and adds it to the
+// schedule of its delivery date, tomorrow when it is empty. The date may be
+// beyond the forecast horizon; the forecast is evaluated by the sweep of that
+// date. A delivery without coordinates is geocoded from its address by the
+// sweep. An order has a single delivery.
+func RegisterDelivery(request RequestDelivery, repository domain.DeliveryRepository) (*DeliveryResponse, error) {
+	now := time.Now()
+	if strings.TrimSpace(request.OrderID) == "" {
+		return nil, &domain.ValidationError{Field: "order_id", Message: "is required"}
+	}
+	deliveryDate, err := validateScheduledDate(request.DeliveryDate, now)
+	if err != nil {
+		return nil, err
+	}
+	hasCoordinates := request.Location.Latitude != "" || request.Location.Longitude != ""
+	if !hasCoordinates && strings.TrimSpace(request.Address) == "" {
+		return nil, &domain.ValidationError{Field: "location", Message: "latitude and longitude or an address are required"}
+	}
+	var location *domain.DeliveryLocation
+	if hasCoordinates {
+		parsed, err := ParseLocation(request.Location, "location")
+		if err != nil {
+			return nil, err
+		}
+		location = &parsed
+	}
+	if err := ValidateTimezone(request.Timezone, "timezone"); err != nil {
+		return nil, err
+	}
+
+	delivery := domain.Delivery{
+		ID:           domain.NewID(),
+		OrderID:      request.OrderID,
+		Email:        request.Email,
+		Address:      request.Address,
+		Carrier:      request.Carrier,
+		Status:       domain.DeliveryScheduled,
+		Phone:        request.Phone,
+		CallbackURL:  request.CallbackURL,
+		Tenant:       request.Tenant,
+		Locale:       request.Locale,
+		Timezone:     request.Timezone,
+		Location:     location,
+		DeliveryDate: deliveryDate,
+		SweepStatus:  domain.DeliverySweepPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	// SaveDelivery reserves the order, returning a ConflictError when it
+	// already has a delivery.
+	if err := repository.SaveDelivery(context.Background(), delivery); err != nil {
+		return nil, err
+	}
+	log.Printf("RegisterDelivery %s order %s for %s on %s", delivery.ID, delivery.OrderID, delivery.Email, delivery.DeliveryDate)
+
+	response := DeliveryEntityToDTO(delivery)
+	return &response, nil
+}
+
+// validateScheduledDate returns the delivery date, tomorrow when it is empty,
+// making sure it is not in the past.
+func validateScheduledDate(deliveryDate string, now time.Time) (string, error) {
+	if deliveryDate == "" {
+		return now.UTC().AddDate(0, 0, 1).Format(DeliveryDateLayout), nil
+	}
+	date, err := time.Parse(DeliveryDateLayout, deliveryDate)
+	if err != nil {
+		return "", &domain.ValidationError{Field: "delivery_date", Message: "must be an ISO date (YYYY-MM-DD)"}
+	}
+	if date.Format(DeliveryDateLayout) < now.UTC().Format(DeliveryDateLayout) {
+		return "", &domain.ValidationError{Field: "delivery_date", Message: "must not be in the past"}
+	}
+	return date.Format(DeliveryDateLayout), nil
+}
+
+func GetDelivery(id string, repository domain.DeliveryRepository) (*DeliveryResponse, error) {
+	delivery, err := repository.GetDelivery(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := DeliveryEntityToDTO(*delivery)
+	return &response, nil
+}
+
+func GetOrderDelivery(orderID string, repository domain.DeliveryRepository) (*DeliveryResponse, error) {
+	delivery, err := repository.GetDeliveryByOrder(context.Background(), orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := DeliveryEntityToDTO(*delivery)
+	return &response, nil
+}
+
+// UpdateDelivery moves the delivery along its lifecycle. A new delivery date
+// puts the delivery back in the schedule, to be swept on that date.
+func UpdateDelivery(id string, request RequestUpdateDelivery, repository domain.DeliveryRepository) (*DeliveryResponse, error) {
+	ctx := context.Background()
+	delivery, err := repository.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Status != "" && request.Status != delivery.Status {
+		if !slices.Contains(deliveryStatusTransitions[delivery.Status], request.Status) {
+			return nil, &domain.ValidationError{Field: "status", Message: fmt.Sprintf("cannot change from %s to %q", delivery.Status, request.Status)}
+		}
+		delivery.Status = request.Status
+	}
+
+	now := time.Now()
+	if request.DeliveryDate != "" && request.DeliveryDate != delivery.DeliveryDate {
+		if delivery.Final() {
+			return nil, &domain.ValidationError{Field: "delivery_date", Message: fmt.Sprintf("cannot change a %s delivery", delivery.Status)}
+		}
+		deliveryDate, err := validateScheduledDate(request.DeliveryDate, now)
+		if err != nil {
+			return nil, err
+		}
+		delivery.DeliveryDate = deliveryDate
+		delivery.SweepStatus = domain.DeliverySweepPending
+		delivery.SweepError = ""
+	}
+
+	if request.Carrier != "" {
+		delivery.Carrier = request.Carrier
+	}
+	delivery.UpdatedAt = now
+
+	if err := repository.SaveDelivery(ctx, *delivery); err != nil {
+		return nil, err
+	}
+	log.Printf("UpdateDelivery %s status %s on %s", delivery.ID, delivery.Status, delivery.DeliveryDate)
+
+	response := DeliveryEntityToDTO(*delivery)
+	return &response, nil
+}
+
+// GetOrderNotifications returns the notifications of the delivery of an
+// order, oldest first.
+func GetOrderNotifications(orderID string, repository domain.DeliveryRepository) (*OrderNotificationsResponse, error) {
+	ctx := context.Background()
+	delivery, err := repository.GetDeliveryByOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications, err := repository.GetDeliveryNotifications(ctx, delivery.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrderNotificationsResponse{
+		OrderID:    orderID,
+		DeliveryID: delivery.ID,
+		History:    MapEntitiesToDTOs(notifications),
+	}, nil
+}
+
+// ListDeliverySchedule returns the deliveries from one date to another, both
+// included. The range defaults to the week starting today.
+func ListDeliverySchedule(from, to string, repository domain.DeliveryRepository) (*DeliveryScheduleResponse, error) {
+	today := time.Now().UTC()
+	if from == "" {
+		from = today.Format(DeliveryDateLayout)
+	}
+	fromDate, err := time.Parse(DeliveryDateLayout, from)
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "from", Message: "must be an ISO date (YYYY-MM-DD)"}
+	}
+	if to == "" {
+		to = fromDate.AddDate(0, 0, defaultScheduleDays-1).Format(DeliveryDateLayout)
+	}
+	toDate, err := time.Parse(DeliveryDateLayout, to)
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "to", Message: "must be an ISO date (YYYY-MM-DD)"}
+	}
+	if toDate.Before(fromDate) {
+		return nil, &domain.ValidationError{Field: "to", Message: "must not be before from"}
+	}
+
+	deliveries, err := repository.ListDeliveries(context.Background(), from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	response := DeliveryScheduleResponse{From: from, To: to, Deliveries: []DeliveryResponse{}}
+	for _, delivery := range deliveries {
+		response.Deliveries = append(response.Deliveries, DeliveryEntityToDTO(delivery))
+	}
+	return &response, nil
+}
+
+// SweepDeliveries evaluates the forecast of the deliveries dated from
+// retryFrom to deliveryDate that were not evaluated yet, which retries the
+// failed ones, notifying their buyers like a batch, and records the outcome
+// in each delivery. Delivered and cancelled deliveries are skipped.
+func SweepDeliveries(ctx context.Context, retryFrom, deliveryDate string, concurrency int, deliveryRepository domain.DeliveryRepository, forecastService third_party.IForecastService, repository domain.NotificationRepository, renderer domain.MessageRenderer, settings NotificationSettings) (*BatchServiceResponse, error) {
+	if retryFrom == "" || retryFrom > deliveryDate {
+		retryFrom = deliveryDate
+	}
+	deliveries, err := deliveryRepository.ListDeliveries(ctx, retryFrom, deliveryDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []domain.Delivery
+	var items []BatchItem
+	for _, delivery := range deliveries {
+		if delivery.SweepStatus == domain.DeliverySweepEvaluated || delivery.Final() {
+			continue
+		}
+		pending = append(pending, delivery)
+		items = append(items, BatchItem{Line: len(items) + 1, Request: DeliveryNotificationRequest(delivery)})
+	}
+
+	result := SendBatchNotifications(ctx, items, concurrency, forecastService, repository, renderer, settings)
+
+	now := time.Now()
+	for i, itemResult := range result.Results {
+		// Cancelled deliveries stay as they were for the next sweep.
+		if itemResult.Status == BatchStatusCancelled {
+			continue
+		}
+		if err := recordSweep(ctx, pending[i], itemResult, now, deliveryRepository); err != nil {
+			log.Printf("SweepDeliveries: %v", err)
+		}
+	}
+
+	return result, nil
+}
+
+// recordSweep stores the outcome of the sweep of swept and the location
+// geocoded from its address. The delivery is read again so changes made while
+// the batch ran are kept; a delivery rescheduled meanwhile stays pending for
+// the sweep of its new date.
+func recordSweep(ctx context.Context, swept domain.Delivery, itemResult BatchItemResult, now time.Time, deliveryRepository domain.DeliveryRepository) error {
+	delivery, err := deliveryRepository.GetDelivery(ctx, swept.ID)
+	if err != nil {
+		return err
+	}
+	if delivery.DeliveryDate != swept.DeliveryDate {
+		log.Printf("SweepDeliveries: delivery %s was rescheduled during the sweep", delivery.ID)
+		return nil
+	}
+
+	delivery.SweptAt = &now
+	delivery.SweepStatus = domain.DeliverySweepEvaluated
+	delivery.SweepError = ""
+	if itemResult.Status == BatchStatusFailed || itemResult.Status == BatchStatusInvalid {
+		delivery.SweepStatus = domain.DeliverySweepFailed
+		delivery.SweepError = itemResult.Error
+	}
+	if itemResult.Result != nil {
+		delivery.NotificationID = itemResult.Result.NotificationID
+		// The coordinates geocoded from the address are kept so the next
+		// sweeps do not resolve it again.
+		if geocoded := itemResult.Result.Location; geocoded != nil && delivery.Location == nil {
+			location, err := ParseLocation(*geocoded, "location")
+			if err != nil {
+				return err
+			}
+			delivery.Location = &location
+		}
+	}
+	return deliveryRepository.SaveDelivery(ctx, *delivery)
+}
+
+// DeliveryNotificationRequest is the notification request of a delivery.
+func DeliveryNotificationRequest(delivery domain.Delivery) RequestDataNotification {
+	request := RequestDataNotification{
+		Email:        delivery.Email,
+		Phone:        delivery.Phone,
+		CallbackURL:  delivery.CallbackURL,
+		Tenant:       delivery.Tenant,
+		Locale:       delivery.Locale,
+		Timezone:     delivery.Timezone,
+		Address:      delivery.Address,
+		DeliveryDate: delivery.DeliveryDate,
+		DeliveryID:   delivery.ID,
+	}
+	if delivery.Location != nil {
+		request.Location = LocationToDTO(*delivery.Location)
+	}
+	return request
+}
+
+// SweepPolicy configures the scheduled sweep: it targets the deliveries
+// DaysAhead days after the sweep day and holds its lock for LockTTL.
+type SweepPolicy struct {
+	DaysAhead   int
+	Concurrency int
+	LockTTL     time.Duration
+}
+
+// RunDeliveryScheduler sweeps the deliveries at every time of schedule, read
+// in location, until ctx is done. Every replica runs the scheduler; the lock
+// of each scheduled time lets only one of them sweep.
+func RunDeliveryScheduler(ctx context.Context, schedule *CronSchedule, location *time.Location, policy SweepPolicy, deliveryRepository domain.DeliveryRepository, forecastService third_party.IForecastService, repository domain.NotificationRepository, renderer domain.MessageRenderer, settings NotificationSettings) {
+	if policy.LockTTL <= 0 {
+		policy.LockTTL = time.Hour
+	}
+
+	go func() {
+		for ctx.Err() == nil {
+			next := schedule.Next(time.Now().In(location))
+			if next.IsZero() {
+				log.Printf("RunDeliveryScheduler: the schedule never runs")
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(next)):
+			}
+
+			if err := RunScheduledSweep(ctx, next, policy, deliveryRepository, forecastService, repository, renderer, settings); err != nil && ctx.Err() == nil {
+				log.Printf("RunDeliveryScheduler: %v", err)
+			}
+		}
+	}()
+}
+
+// RunScheduledSweep sweeps the deliveries of the time scheduledAt of the
+// schedule unless another replica took its lock.
+func RunScheduledSweep(ctx context.Context, scheduledAt time.Time, policy SweepPolicy, deliveryRepository domain.DeliveryRepository, forecastService third_party.IForecastService, repository domain.NotificationRepository, renderer domain.MessageRenderer, settings NotificationSettings) error {
+	// The lock is left to expire so a replica whose clock is late does not
+	// sweep the same scheduled time again.
+	lockName := fmt.Sprintf("deliveries:sweep:%d", scheduledAt.Unix())
+	acquired, err := deliveryRepository.AcquireLock(ctx, lockName, domain.NewID(), policy.LockTTL)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		log.Printf("RunDeliveryScheduler: sweep of %s taken by another replica", scheduledAt.Format(time.RFC3339))
+		return nil
+	}
+
+	// Failed deliveries are retried while their date is after the sweep day.
+	retryFrom := scheduledAt.AddDate(0, 0, min(policy.DaysAhead, 1)).Format(DeliveryDateLayout)
+	deliveryDate := scheduledAt.AddDate(0, 0, policy.DaysAhead).Format(DeliveryDateLayout)
+	result, err := SweepDeliveries(ctx, retryFrom, deliveryDate, policy.Concurrency, deliveryRepository, forecastService, repository, renderer, settings)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("RunDeliveryScheduler: swept %s total %d sent %d skipped %d failed %d", deliveryDate, result.Total, result.Sent, result.Skipped, result.Failed+result.Invalid)
+	return nil
+}
+
+func DeliveryEntityToDTO(delivery domain.Delivery) DeliveryResponse {
+	response := DeliveryResponse{
+		DeliveryID:     delivery.ID,
+		OrderID:        delivery.OrderID,
+		Email:          delivery.Email,
+		Address:        delivery.Address,
+		Carrier:        delivery.Carrier,
+		Status:         delivery.Status,
+		DeliveryDate:   delivery.DeliveryDate,
+		SweepStatus:    delivery.SweepStatus,
+		SweepError:     delivery.SweepError,
+		SweptAt:        delivery.SweptAt,
+		NotificationID: delivery.NotificationID,
+		CreatedAt:      delivery.CreatedAt,
+		UpdatedAt:      delivery.UpdatedAt,
+	}
+	if delivery.Location != nil {
+		location := LocationToDTO(*delivery.Location)
+		response.Location = &location
+	}
+	return response
+}
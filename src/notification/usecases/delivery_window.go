@@ -0,0 +1,115 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+)
+
+const (
+	DeliveryWindowClockLayout = "15:04"
+	forecastHourLayout        = "2006-01-02T15:04"
+)
+
+// ValidateDeliveryWindow checks the window before any forecast is fetched.
+func ValidateDeliveryWindow(window DeliveryWindow) error {
+	start, err := time.Parse(DeliveryWindowClockLayout, window.Start)
+	if err != nil {
+		return &domain.ValidationError{Field: "delivery_window.start", Message: "must be a time (HH:MM)"}
+	}
+
+	end, err := time.Parse(DeliveryWindowClockLayout, window.End)
+	if err != nil {
+		return &domain.ValidationError{Field: "delivery_window.end", Message: "must be a time (HH:MM)"}
+	}
+
+	if !end.After(start) {
+		return &domain.ValidationError{Field: "delivery_window.end", Message: "must be after start"}
+	}
+
+	if window.Timezone != "" {
+		if _, err := time.LoadLocation(window.Timezone); err != nil {
+			return &domain.ValidationError{Field: "delivery_window.timezone", Message: "must be an IANA timezone"}
+		}
+	}
+
+	return nil
+}
+
+// DeliveryWindowBounds resolves the window on deliveryDate. The window is
+// read in its own timezone, falling back to the forecast location timezone.
+func DeliveryWindowBounds(window DeliveryWindow, deliveryDate, forecastTimezone string) (time.Time, time.Time, error) {
+	location, err := loadLocation(window.Timezone, forecastTimezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, &domain.ValidationError{Field: "delivery_window.timezone", Message: "must be an IANA timezone"}
+	}
+
+	layout := DeliveryDateLayout + " " + DeliveryWindowClockLayout
+	start, err := time.ParseInLocation(layout, deliveryDate+" "+window.Start, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, &domain.ValidationError{Field: "delivery_window.start", Message: "must be a time (HH:MM)"}
+	}
+
+	end, err := time.ParseInLocation(layout, deliveryDate+" "+window.End, location)
+	if err != nil {
+		return time.Time{}, time.Time{}, &domain.ValidationError{Field: "delivery_window.end", Message: "must be a time (HH:MM)"}
+	}
+
+	return start, end, nil
+}
+
+// EvaluateDeliveryWindow returns the hourly forecasts overlapping the window
+// whose condition code is one of the notification codes.
+func EvaluateDeliveryWindow(ctx context.Context, repository domain.NotificationRepository, data *third_party.ForecastServiceResponse, window DeliveryWindow, deliveryDate string) ([]TriggeredHour, error) {
+	start, end, err := DeliveryWindowBounds(window, deliveryDate, data.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	forecastLocation, err := loadLocation(data.Timezone, window.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("forecast timezone %q is unknown", data.Timezone)
+	}
+
+	notificationCodes, err := repository.GetNotificationCodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	triggeredHours := []TriggeredHour{}
+	for _, hour := range data.Hours {
+		hourStart, err := time.ParseInLocation(forecastHourLayout, hour.Time, forecastLocation)
+		if err != nil {
+			return nil, fmt.Errorf("hourly forecast time %q is malformed", hour.Time)
+		}
+
+		if !hourStart.Add(time.Hour).After(start) || !hourStart.Before(end) {
+			continue
+		}
+
+		if slices.Contains(notificationCodes, strconv.FormatFloat(hour.Code, 'f', -1, 64)) {
+			triggeredHours = append(triggeredHours, TriggeredHour{
+				Time:                hourStart.In(start.Location()).Format(time.RFC3339),
+				ForecastCode:        hour.Code,
+				ForecastDescription: hour.Description,
+			})
+		}
+	}
+
+	return triggeredHours, nil
+}
+
+// loadLocation loads the first non empty timezone name, UTC when none is set.
+func loadLocation(names ...string) (*time.Location, error) {
+	for _, name := range names {
+		if name != "" {
+			return time.LoadLocation(name)
+		}
+	}
+	return time.UTC, nil
+}
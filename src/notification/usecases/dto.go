@@ -2,32 +2,249 @@ package usecases
 
 import "time"
 
+// Location is the location of a request or a response. Requests may send the
+// coordinates as numbers or numeric strings; ParseLocation validates them.
 type Location struct {
-	Latitude  string `json:"latitude"`
-	Longitude string `json:"longitude"`
+	Latitude  Coordinate `json:"latitude"`
+	Longitude Coordinate `json:"longitude"`
+}
+
+type DeliveryWindow struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
 }
 
 type RequestDataNotification struct {
-	Email    string   `json:"email"`
-	Location Location `json:"location"`
+	Email       string   `json:"email"`
+	Phone       string   `json:"phone,omitempty"`
+	CallbackURL string   `json:"callback_url,omitempty"`
+	Tenant      string   `json:"tenant,omitempty"`
+	Locale      string   `json:"locale,omitempty"`
+	Location    Location `json:"location"`
+	// Address is geocoded when the location has no coordinates.
+	Address        string          `json:"address,omitempty"`
+	DeliveryDate   string          `json:"delivery_date,omitempty"`
+	DeliveryWindow *DeliveryWindow `json:"delivery_window,omitempty"`
+	Timezone       string          `json:"timezone,omitempty"`
+	// DeliveryID links the notification of a registered delivery to it.
+	DeliveryID string `json:"-"`
 }
 
 type RequestGetNotification struct {
 	Email string `json:"email"`
 }
 
-type NotificationServiceResponse struct {
+type TriggeredHour struct {
+	Time                string  `json:"time"`
 	ForecastCode        float64 `json:"forecast_code"`
 	ForecastDescription string  `json:"forecast_description"`
-	BuyerNotification   bool    `json:"buyer_notification"`
+}
+
+type NotificationServiceResponse struct {
+	NotificationID string                  `json:"notification_id,omitempty"`
+	DeliveryStatus string                  `json:"delivery_status,omitempty"`
+	Channels       []ChannelDeliveryDetail `json:"channels,omitempty"`
+	Locale         string                  `json:"locale,omitempty"`
+	ScheduledAt    *time.Time              `json:"scheduled_at,omitempty"`
+	// Location holds the coordinates geocoded from the address of the request.
+	Location            *Location       `json:"location,omitempty"`
+	DeliveryDate        string          `json:"delivery_date"`
+	ForecastCode        float64         `json:"forecast_code"`
+	ForecastDescription string          `json:"forecast_description"`
+	BuyerNotification   bool            `json:"buyer_notification"`
+	TriggeredHours      []TriggeredHour `json:"triggered_hours,omitempty"`
+	FiredRules          []string        `json:"fired_rules,omitempty"`
+}
+
+type ChannelDeliveryDetail struct {
+	Channel   string     `json:"channel"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
 }
 
 type NotificationHistoryDetail struct {
-	NotificationSendAt time.Time `json:"notification_sent_at"`
-	Location           Location  `json:"location"`
-	ForecastCode       float64   `json:"forecast_code"`
+	NotificationID     string                  `json:"notification_id,omitempty"`
+	DeliveryID         string                  `json:"delivery_id,omitempty"`
+	NotificationSendAt time.Time               `json:"notification_sent_at"`
+	Location           Location                `json:"location"`
+	DeliveryDate       string                  `json:"delivery_date,omitempty"`
+	ForecastCode       float64                 `json:"forecast_code"`
+	DeliveryStatus     string                  `json:"delivery_status,omitempty"`
+	ScheduledAt        *time.Time              `json:"scheduled_at,omitempty"`
+	Channels           []ChannelDeliveryDetail `json:"channels,omitempty"`
+}
+
+type BuyerPreferencesRequest struct {
+	Channels   []string    `json:"channels"`
+	Phone      string      `json:"phone,omitempty"`
+	Webhook    string      `json:"webhook,omitempty"`
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	Timezone   string      `json:"timezone,omitempty"`
+}
+
+type BuyerPreferencesResponse struct {
+	Email      string      `json:"email"`
+	Channels   []string    `json:"channels"`
+	Phone      string      `json:"phone,omitempty"`
+	Webhook    string      `json:"webhook,omitempty"`
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	Timezone   string      `json:"timezone,omitempty"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type SubscriptionResponse struct {
+	Email          string     `json:"email"`
+	Subscribed     bool       `json:"subscribed"`
+	Source         string     `json:"source,omitempty"`
+	UnsubscribedAt *time.Time `json:"unsubscribed_at,omitempty"`
 }
 
 type NotificationHistoryServiceResponse struct {
 	History []NotificationHistoryDetail `json:"history"`
 }
+
+type RequestNotificationCode struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+type NotificationCodeDetail struct {
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	UpdatedBy   string    `json:"updated_by"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type NotificationCodesServiceResponse struct {
+	Codes []NotificationCodeDetail `json:"codes"`
+}
+
+type RuleConditionDetail struct {
+	Metric   string   `json:"metric"`
+	Operator string   `json:"operator"`
+	Value    float64  `json:"value,omitempty"`
+	Codes    []string `json:"codes,omitempty"`
+}
+
+type RequestNotificationRule struct {
+	Operator   string                `json:"operator"`
+	Conditions []RuleConditionDetail `json:"conditions"`
+}
+
+type NotificationRuleDetail struct {
+	Name       string                `json:"name"`
+	Operator   string                `json:"operator"`
+	Conditions []RuleConditionDetail `json:"conditions"`
+	UpdatedBy  string                `json:"updated_by,omitempty"`
+	UpdatedAt  *time.Time            `json:"updated_at,omitempty"`
+}
+
+type NotificationRulesServiceResponse struct {
+	Rules []NotificationRuleDetail `json:"rules"`
+}
+
+type BatchItemResult struct {
+	Line   int                          `json:"line"`
+	Email  string                       `json:"email,omitempty"`
+	Status string                       `json:"status"`
+	Error  string                       `json:"error,omitempty"`
+	Result *NotificationServiceResponse `json:"result,omitempty"`
+}
+
+type BatchServiceResponse struct {
+	Total      int               `json:"total"`
+	Sent       int               `json:"sent"`
+	Skipped    int               `json:"skipped"`
+	Suppressed int               `json:"suppressed"`
+	Duplicate  int               `json:"duplicate"`
+	Invalid    int               `json:"invalid"`
+	Failed     int               `json:"failed"`
+	Cancelled  int               `json:"cancelled,omitempty"`
+	Results    []BatchItemResult `json:"results"`
+}
+
+type JobServiceResponse struct {
+	JobID     string                       `json:"job_id"`
+	Status    string                       `json:"status"`
+	Attempts  int                          `json:"attempts"`
+	Error     string                       `json:"error,omitempty"`
+	Result    *NotificationServiceResponse `json:"result,omitempty"`
+	CreatedAt time.Time                    `json:"created_at"`
+	UpdatedAt time.Time                    `json:"updated_at"`
+}
+
+// WebhookPayload is the JSON body posted to partner callbacks.
+type WebhookPayload struct {
+	NotificationID      string    `json:"notification_id"`
+	Event               string    `json:"event"`
+	Email               string    `json:"email"`
+	Location            Location  `json:"location"`
+	DeliveryDate        string    `json:"delivery_date"`
+	ForecastCode        float64   `json:"forecast_code"`
+	ForecastDescription string    `json:"forecast_description"`
+	TriggeredHours      []string  `json:"triggered_hours,omitempty"`
+	FiredRules          []string  `json:"fired_rules,omitempty"`
+	Message             string    `json:"message"`
+	Locale              string    `json:"locale,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+type RequestDelivery struct {
+	OrderID      string   `json:"order_id"`
+	Email        string   `json:"email"`
+	Address      string   `json:"address,omitempty"`
+	Carrier      string   `json:"carrier,omitempty"`
+	Phone        string   `json:"phone,omitempty"`
+	CallbackURL  string   `json:"callback_url,omitempty"`
+	Tenant       string   `json:"tenant,omitempty"`
+	Locale       string   `json:"locale,omitempty"`
+	Timezone     string   `json:"timezone,omitempty"`
+	Location     Location `json:"location"`
+	DeliveryDate string   `json:"delivery_date,omitempty"`
+}
+
+// RequestUpdateDelivery changes the status, the delivery date or the carrier
+// of a delivery; empty fields are kept.
+type RequestUpdateDelivery struct {
+	Status       string `json:"status,omitempty"`
+	DeliveryDate string `json:"delivery_date,omitempty"`
+	Carrier      string `json:"carrier,omitempty"`
+}
+
+type DeliveryResponse struct {
+	DeliveryID     string     `json:"delivery_id"`
+	OrderID        string     `json:"order_id"`
+	Email          string     `json:"email"`
+	Address        string     `json:"address,omitempty"`
+	Carrier        string     `json:"carrier,omitempty"`
+	Status         string     `json:"status"`
+	Location       *Location  `json:"location,omitempty"`
+	DeliveryDate   string     `json:"delivery_date"`
+	SweepStatus    string     `json:"sweep_status"`
+	SweepError     string     `json:"sweep_error,omitempty"`
+	SweptAt        *time.Time `json:"swept_at,omitempty"`
+	NotificationID string     `json:"notification_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type OrderNotificationsResponse struct {
+	OrderID    string                      `json:"order_id"`
+	DeliveryID string                      `json:"delivery_id"`
+	History    []NotificationHistoryDetail `json:"history"`
+}
+
+type DeliveryScheduleResponse struct {
+	From        string             `json:"from"`
+	To          string             `json:"to"`
+	NextSweepAt *time.Time         `json:"next_sweep_at,omitempty"`
+	Deliveries  []DeliveryResponse `json:"deliveries"`
+}
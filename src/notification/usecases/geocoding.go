@@ -0,0 +1,51 @@
+package usecases
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+)
+
+// ResolveLocation returns the coordinates of the request and whether they
+// were geocoded. Coordinates given in the request win; otherwise the address
+// is resolved through geocoder, nil when geocoding is disabled.
+func ResolveLocation(requestDataNotification RequestDataNotification, geocoder third_party.Geocoder) (Location, bool, error) {
+	location := requestDataNotification.Location
+	if !geocodesAddress(requestDataNotification) {
+		return location, false, nil
+	}
+	address := strings.TrimSpace(requestDataNotification.Address)
+
+	if geocoder == nil {
+		return location, false, &domain.ValidationError{Field: "address", Message: "geocoding is disabled, latitude and longitude are required"}
+	}
+
+	place, err := geocoder.Geocode(address)
+	if errors.Is(err, third_party.ErrAddressNotFound) {
+		return location, false, &domain.ValidationError{Field: "address", Message: "no place matches the address"}
+	}
+	if err != nil {
+		return location, false, err
+	}
+
+	log.Printf("ResolveLocation %q at %s,%s", address, place.Latitude, place.Longitude)
+	return Location{Latitude: Coordinate(place.Latitude), Longitude: Coordinate(place.Longitude)}, true, nil
+}
+
+// ValidateRequestLocation checks the coordinates of a request that is not
+// geocoded from its address.
+func ValidateRequestLocation(requestDataNotification RequestDataNotification) error {
+	if geocodesAddress(requestDataNotification) {
+		return nil
+	}
+	_, err := ParseLocation(requestDataNotification.Location, "location")
+	return err
+}
+
+func geocodesAddress(requestDataNotification RequestDataNotification) bool {
+	location := requestDataNotification.Location
+	return strings.TrimSpace(requestDataNotification.Address) != "" && (location.Latitude == "" || location.Longitude == "")
+}
@@ -0,0 +1,229 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+)
+
+const (
+	jobDequeueTimeout = 5 * time.Second
+	defaultJobLease   = 30 * time.Second
+)
+
+// EnqueueNotificationJob validates the request and stores it as a queued job.
+// The delivery date is resolved now so a job processed later still targets
+// the day the caller asked for.
+func EnqueueNotificationJob(requestDataNotification RequestDataNotification, forecastService third_party.IForecastService, jobRepository domain.JobRepository) (*JobServiceResponse, error) {
+	deliveryDate, err := ResolveDeliveryDate(requestDataNotification.DeliveryDate, forecastService.MaxForecastDays(), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	requestDataNotification.DeliveryDate = deliveryDate
+
+	if requestDataNotification.DeliveryWindow != nil {
+		if err := ValidateDeliveryWindow(*requestDataNotification.DeliveryWindow); err != nil {
+			return nil, err
+		}
+	}
+
+	payload, err := json.Marshal(requestDataNotification)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding job payload: %w", err)
+	}
+
+	now := time.Now()
+	job := domain.Job{
+		ID:        domain.NewID(),
+		Status:    domain.JobStatusQueued,
+		Payload:   payload,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	ctx := context.Background()
+	if err := jobRepository.SaveJob(ctx, job); err != nil {
+		return nil, err
+	}
+	if err := jobRepository.EnqueueJob(ctx, job.ID); err != nil {
+		return nil, err
+	}
+
+	return JobEntityToDTO(job), nil
+}
+
+func GetJob(id string, jobRepository domain.JobRepository) (*JobServiceResponse, error) {
+	job, err := jobRepository.GetJob(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	return JobEntityToDTO(*job), nil
+}
+
+// ProcessNextJob takes one job from the queue into the processing list of
+// worker and runs SendNotification for it. Failed jobs are queued again until
+// maxAttempts is reached, except for validation errors which cannot succeed
+// on a retry.
+func ProcessNextJob(ctx context.Context, worker string, maxAttempts int, jobRepository domain.JobRepository, forecastService third_party.IForecastService, repository domain.NotificationRepository, renderer domain.MessageRenderer, settings NotificationSettings) error {
+	id, err := jobRepository.DequeueJob(ctx, worker, jobDequeueTimeout)
+	if err != nil || id == "" {
+		return err
+	}
+
+	job, err := jobRepository.GetJob(ctx, id)
+	if err != nil {
+		if _, ok := err.(*domain.NotFoundError); ok {
+			log.Printf("ProcessNextJob: job %s expired, dropping it", id)
+			return jobRepository.AckJob(ctx, worker, id)
+		}
+		return err
+	}
+	if job.Finished() {
+		return jobRepository.AckJob(ctx, worker, id)
+	}
+
+	job.Status = domain.JobStatusRunning
+	job.Attempts++
+	job.UpdatedAt = time.Now()
+	if err := jobRepository.SaveJob(ctx, *job); err != nil {
+		return err
+	}
+
+	runErr := runJob(job, forecastService, repository, renderer, settings)
+	if runErr != nil && job.Attempts < maxAttempts {
+		if _, ok := runErr.(*domain.ValidationError); !ok {
+			job.Status = domain.JobStatusQueued
+		}
+	}
+	job.UpdatedAt = time.Now()
+	if err := jobRepository.SaveJob(ctx, *job); err != nil {
+		return err
+	}
+
+	if job.Status == domain.JobStatusQueued {
+		if err := jobRepository.EnqueueJob(ctx, job.ID); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("ProcessNextJob job %s status %s attempts %d", job.ID, job.Status, job.Attempts)
+	return jobRepository.AckJob(ctx, worker, id)
+}
+
+func runJob(job *domain.Job, forecastService third_party.IForecastService, repository domain.NotificationRepository, renderer domain.MessageRenderer, settings NotificationSettings) error {
+	var requestDataNotification RequestDataNotification
+	if err := json.Unmarshal(job.Payload, &requestDataNotification); err != nil {
+		job.Status = domain.JobStatusFailed
+		job.Error = fmt.Sprintf("invalid job payload: %v", err)
+		return &domain.ValidationError{Field: "payload", Message: err.Error()}
+	}
+
+	result, err := SendNotification(requestDataNotification, forecastService, repository, renderer, settings)
+	if err != nil {
+		job.Status = domain.JobStatusFailed
+		job.Error = err.Error()
+		return err
+	}
+
+	job.Result, err = json.Marshal(result)
+	if err != nil {
+		job.Status = domain.JobStatusFailed
+		job.Error = fmt.Sprintf("error encoding job result: %v", err)
+		return err
+	}
+
+	job.Status = domain.JobStatusSucceeded
+	job.Error = ""
+	return nil
+}
+
+// RunJobWorkers starts workers goroutines that process the queue until ctx is
+// done. Each worker holds a lease renewed every third of lease; the jobs of
+// workers whose lease expired, on this or another instance, are queued again.
+func RunJobWorkers(ctx context.Context, workers, maxAttempts int, lease time.Duration, jobRepository domain.JobRepository, forecastService third_party.IForecastService, repository domain.NotificationRepository, renderer domain.MessageRenderer, settings NotificationSettings) {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	if lease <= 0 {
+		lease = defaultJobLease
+	}
+
+	ids := make([]string, workers)
+	for i := range ids {
+		ids[i] = domain.NewID()
+	}
+	renewJobLeases(ctx, jobRepository, ids, lease)
+	recoverJobs(ctx, jobRepository)
+
+	go func() {
+		ticker := time.NewTicker(lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				renewJobLeases(ctx, jobRepository, ids, lease)
+				recoverJobs(ctx, jobRepository)
+			}
+		}
+	}()
+
+	for _, id := range ids {
+		go func(worker string) {
+			for ctx.Err() == nil {
+				if err := ProcessNextJob(ctx, worker, maxAttempts, jobRepository, forecastService, repository, renderer, settings); err != nil && ctx.Err() == nil {
+					log.Printf("RunJobWorkers: %v", err)
+					time.Sleep(time.Second)
+				}
+			}
+		}(id)
+	}
+}
+
+func renewJobLeases(ctx context.Context, jobRepository domain.JobRepository, workers []string, lease time.Duration) {
+	for _, worker := range workers {
+		if err := jobRepository.RenewJobLease(ctx, worker, lease); err != nil && ctx.Err() == nil {
+			log.Printf("RunJobWorkers: error renewing lease of worker %s: %v", worker, err)
+		}
+	}
+}
+
+func recoverJobs(ctx context.Context, jobRepository domain.JobRepository) {
+	recovered, err := jobRepository.RecoverJobs(ctx)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("RunJobWorkers: error recovering jobs: %v", err)
+	}
+	if recovered > 0 {
+		log.Printf("RunJobWorkers: %d interrupted job(s) queued again", recovered)
+	}
+}
+
+func JobEntityToDTO(job domain.Job) *JobServiceResponse {
+	response := JobServiceResponse{
+		JobID:     job.ID,
+		Status:    job.Status,
+		Attempts:  job.Attempts,
+		Error:     job.Error,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+
+	if len(job.Result) > 0 {
+		var result NotificationServiceResponse
+		if err := json.Unmarshal(job.Result, &result); err == nil {
+			response.Result = &result
+		}
+	}
+
+	return &response
+}
@@ -0,0 +1,91 @@
+package usecases
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+// Coordinate keeps a coordinate as the client sent it, so a malformed value
+// is reported as a field error instead of invalid JSON. It is written as a
+// JSON number when it is one.
+type Coordinate string
+
+func (c *Coordinate) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*c = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*c = Coordinate(text)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+	*c = Coordinate(number)
+	return nil
+}
+
+func (c Coordinate) MarshalJSON() ([]byte, error) {
+	if _, err := c.Float(); err == nil {
+		return []byte(strings.TrimSpace(string(c))), nil
+	}
+	return json.Marshal(string(c))
+}
+
+// Float parses the coordinate as a JSON number, so hexadecimal, infinite and
+// NaN values are rejected.
+func (c Coordinate) Float() (float64, error) {
+	var number json.Number
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(c))), &number); err != nil {
+		return 0, err
+	}
+	return number.Float64()
+}
+
+// ParseLocation validates the coordinates of location, reported under field,
+// and returns them normalized.
+func ParseLocation(location Location, field string) (domain.DeliveryLocation, error) {
+	var fields []domain.FieldError
+	latitude, message := parseCoordinate(location.Latitude, domain.LatitudeError)
+	if message != "" {
+		fields = append(fields, domain.FieldError{Field: "latitude", Message: message})
+	}
+	longitude, message := parseCoordinate(location.Longitude, domain.LongitudeError)
+	if message != "" {
+		fields = append(fields, domain.FieldError{Field: "longitude", Message: message})
+	}
+	if len(fields) > 0 {
+		return domain.DeliveryLocation{}, domain.LocationValidationError(field, fields)
+	}
+
+	return domain.NewDeliveryLocation(latitude, longitude)
+}
+
+func parseCoordinate(coordinate Coordinate, rangeError func(float64) string) (float64, string) {
+	if strings.TrimSpace(string(coordinate)) == "" {
+		return 0, "is required"
+	}
+	value, err := coordinate.Float()
+	if err != nil {
+		return 0, "must be a number"
+	}
+	return value, rangeError(value)
+}
+
+// LocationToDTO formats the coordinates of location.
+func LocationToDTO(location domain.DeliveryLocation) Location {
+	return Location{
+		Latitude:  Coordinate(domain.FormatCoordinate(location.Latitude)),
+		Longitude: Coordinate(domain.FormatCoordinate(location.Longitude)),
+	}
+}
@@ -2,7 +2,9 @@ package usecases
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log"
 	"slices"
 	"strconv"
 	"time"
@@ -27,13 +29,46 @@ func RequireBuyerNotification(context context.Context, repository domain.Notific
 	return &buyerNotification, nil
 }
 
+const DeliveryDateLayout = "2006-01-02"
+
+// ResolveDeliveryDate returns the requested delivery date, tomorrow when it
+// is empty, making sure the forecast provider can still see that far ahead.
+// Days are counted in UTC, like the scheduled deliveries.
+func ResolveDeliveryDate(deliveryDate string, maxForecastDays int, now time.Time) (string, error) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if deliveryDate == "" {
+		return today.AddDate(0, 0, 1).Format(DeliveryDateLayout), nil
+	}
+
+	date, err := time.Parse(DeliveryDateLayout, deliveryDate)
+	if err != nil {
+		return "", &domain.ValidationError{Field: "delivery_date", Message: "must be an ISO date (YYYY-MM-DD)"}
+	}
+
+	daysAhead := int(date.Sub(today).Hours() / 24)
+	if daysAhead < 0 {
+		return "", &domain.ValidationError{Field: "delivery_date", Message: "must not be in the past"}
+	}
+	if daysAhead >= maxForecastDays {
+		return "", &domain.ValidationError{Field: "delivery_date", Message: fmt.Sprintf("must be within the next %d day(s)", maxForecastDays-1)}
+	}
+
+	return date.Format(DeliveryDateLayout), nil
+}
+
 func CreateNotification(requestDataNotification RequestDataNotification, code float64, requireBuyerNotification bool) (*domain.Notification, error) {
+	location, err := ParseLocation(requestDataNotification.Location, "location")
+	if err != nil {
+		return nil, err
+	}
+
 	notification := domain.Notification{
-		Email: requestDataNotification.Email,
-		DeliveryLocation: domain.DeliveryLocation{
-			Longitude: requestDataNotification.Location.Longitude,
-			Latitude:  requestDataNotification.Location.Latitude,
-		},
+		ID:                domain.NewID(),
+		DeliveryID:        requestDataNotification.DeliveryID,
+		Email:             requestDataNotification.Email,
+		DeliveryLocation:  location,
+		DeliveryDate:      requestDataNotification.DeliveryDate,
 		ForecastCode:      code,
 		BuyerNotification: requireBuyerNotification,
 		Created_at:        time.Now(),
@@ -42,44 +77,302 @@ func CreateNotification(requestDataNotification RequestDataNotification, code fl
 	return &notification, nil
 }
 
-func SendNotification(requestDataNotification RequestDataNotification, forecastService third_party.IForecastService, repository domain.NotificationRepository, sender domain.NotificationSender) (*NotificationServiceResponse, error) {
-	data, err := forecastService.FetchForecastByLocation(requestDataNotification.Location.Longitude, requestDataNotification.Location.Latitude, "2")
+// NotificationSettings are the configurable parts of SendNotification.
+type NotificationSettings struct {
+	Unsubscribe UnsubscribeLinks
+	// DedupWindow is how long a buyer is not warned again of the same delivery;
+	// zero disables deduplication. DedupPrecision is the number of decimals the
+	// coordinates are rounded to in the dedup key.
+	DedupWindow    time.Duration
+	DedupPrecision int
+	// QuietHours are the global quiet hours, replaced by the ones in the buyer
+	// preferences. DefaultTimezone reads them when neither the request, the
+	// preferences nor the forecast give a timezone.
+	QuietHours      domain.QuietHours
+	DefaultTimezone string
+	// Geocoder resolves the address of the requests without coordinates; nil
+	// disables geocoding.
+	Geocoder third_party.Geocoder
+}
+
+// SendNotification evaluates the forecast for the delivery and, when the buyer
+// must be warned, stores the notification as pending in the outbox with one
+// delivery per channel of the buyer. The messages are rendered in the locale
+// of the request and delivered later by DispatchPendingNotifications. Buyers
+// in the opt-out list are not warned; their notification is suppressed. A
+// buyer already warned of the same delivery within the dedup window is not
+// warned again; the response points to the first notification. During the
+// quiet hours of the buyer the notification is scheduled for when they end.
+// A request with an address instead of coordinates is geocoded first.
+func SendNotification(requestDataNotification RequestDataNotification, forecastService third_party.IForecastService, repository domain.NotificationRepository, renderer domain.MessageRenderer, settings NotificationSettings) (response *NotificationServiceResponse, sendErr error) {
+	now := time.Now()
+	deliveryDate, err := ResolveDeliveryDate(requestDataNotification.DeliveryDate, forecastService.MaxForecastDays(), now)
+	if err != nil {
+		return nil, err
+	}
+	requestDataNotification.DeliveryDate = deliveryDate
+
+	if requestDataNotification.DeliveryWindow != nil {
+		if err := ValidateDeliveryWindow(*requestDataNotification.DeliveryWindow); err != nil {
+			return nil, err
+		}
+	}
+	if err := ValidateTimezone(requestDataNotification.Timezone, "timezone"); err != nil {
+		return nil, err
+	}
+
+	resolvedLocation, geocoded, err := ResolveLocation(requestDataNotification, settings.Geocoder)
+	if err != nil {
+		return nil, err
+	}
+	deliveryLocation, err := ParseLocation(resolvedLocation, "location")
+	if err != nil {
+		return nil, err
+	}
+	location := LocationToDTO(deliveryLocation)
+	requestDataNotification.Location = location
+
+	data, err := forecastService.FetchForecastByLocation(deliveryLocation, deliveryDate)
 	if err != nil {
 		return nil, err
 	}
 
 	ctx := context.Background()
 
-	requireBuyerNotification, err := RequireBuyerNotification(ctx, repository, data.Code)
+	var requireBuyerNotification *bool
+	var triggeredHours []TriggeredHour
+	description, descriptionCode := data.Description, data.Code
+	if requestDataNotification.DeliveryWindow != nil && len(data.Hours) > 0 {
+		triggeredHours, err = EvaluateDeliveryWindow(ctx, repository, data, *requestDataNotification.DeliveryWindow, deliveryDate)
+		if err != nil {
+			return nil, err
+		}
+		windowNotification := len(triggeredHours) > 0
+		requireBuyerNotification = &windowNotification
+		if windowNotification {
+			description, descriptionCode = triggeredHours[0].ForecastDescription, triggeredHours[0].ForecastCode
+		}
+	} else {
+		if requestDataNotification.DeliveryWindow != nil {
+			log.Printf("SendNotification: hourly forecast unavailable for %s, using the daily condition", deliveryDate)
+		}
+		requireBuyerNotification, err = RequireBuyerNotification(ctx, repository, data.Code)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	firedRules, err := EvaluateNotificationRules(ctx, repository, data)
 	if err != nil {
 		return nil, err
 	}
+	buyerNotification := *requireBuyerNotification || len(firedRules) > 0
 
-	notification, err := CreateNotification(requestDataNotification, data.Code, *requireBuyerNotification)
+	notification, err := CreateNotification(requestDataNotification, data.Code, buyerNotification)
 	if err != nil {
 		return nil, err
 	}
+	for _, triggeredHour := range triggeredHours {
+		notification.TriggeredHours = append(notification.TriggeredHours, triggeredHour.Time)
+	}
+	notification.FiredRules = firedRules
 
 	notificationServiceResponse := NotificationServiceResponse{
+		DeliveryDate:        deliveryDate,
 		ForecastCode:        data.Code,
 		ForecastDescription: data.Description,
-		BuyerNotification:   *requireBuyerNotification,
+		BuyerNotification:   buyerNotification,
+		TriggeredHours:      triggeredHours,
+		FiredRules:          firedRules,
 	}
+	if geocoded {
+		notificationServiceResponse.Location = &location
+	}
+
+	if buyerNotification {
+		optOut, err := repository.GetOptOut(ctx, requestDataNotification.Email)
+		if err != nil {
+			return nil, err
+		}
+		if optOut != nil {
+			log.Printf("SendNotification: %s opted out, notification suppressed", requestDataNotification.Email)
+			notification.DeliveryStatus = domain.DeliveryStatusSuppressed
+			if err := repository.SaveNotification(ctx, *notification); err != nil {
+				return nil, err
+			}
+			notificationServiceResponse.NotificationID = notification.ID
+			notificationServiceResponse.DeliveryStatus = notification.DeliveryStatus
+			return &notificationServiceResponse, nil
+		}
+
+		if settings.DedupWindow > 0 {
+			key := DedupKey(notification.Email, deliveryDate, deliveryLocation, settings.DedupPrecision)
+			holder, err := repository.ReserveDedupKey(ctx, key, notification.ID, settings.DedupWindow)
+			if err != nil {
+				return nil, err
+			}
+			if holder != notification.ID {
+				log.Printf("SendNotification: %s already notified by %s, duplicate skipped", requestDataNotification.Email, holder)
+				notificationServiceResponse.NotificationID = holder
+				notificationServiceResponse.DeliveryStatus = domain.DeliveryStatusDuplicate
+				return &notificationServiceResponse, nil
+			}
+			// Without a stored notification the key must not block a retry.
+			defer func() {
+				if sendErr != nil {
+					if releaseErr := repository.ReleaseDedupKey(ctx, key); releaseErr != nil {
+						log.Printf("SendNotification: %v", releaseErr)
+					}
+				}
+			}()
+		}
+
+		preferences, err := repository.GetBuyerPreferences(ctx, requestDataNotification.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		unsubscribeURL := settings.Unsubscribe.URL(notification.Email, requestDataNotification.Locale)
+		message, err := renderer.Render(requestDataNotification.Locale, domain.MessageData{
+			Email:               notification.Email,
+			DeliveryDate:        deliveryDate,
+			Tomorrow:            deliveryDate == now.AddDate(0, 0, 1).Format(DeliveryDateLayout),
+			ForecastCode:        descriptionCode,
+			ForecastDescription: description,
+			TriggeredHours:      notification.TriggeredHours,
+			FiredRules:          notification.FiredRules,
+			UnsubscribeURL:      unsubscribeURL,
+		})
+		if err != nil {
+			return nil, err
+		}
+		notification.Locale = message.Locale
+
+		for _, channel := range NotificationChannels(requestDataNotification, preferences) {
+			switch channel.Channel {
+			case domain.ChannelSMS:
+				channel.Message = message.SMS
+			case domain.ChannelWebhook:
+				channel.Message, err = WebhookPayloadJSON(*notification, description, message.Text)
+				if err != nil {
+					return nil, err
+				}
+			default:
+				channel.Subject = message.Subject
+				channel.Message = message.Text
+				channel.HTML = message.HTML
+				channel.UnsubscribeURL = unsubscribeURL
+			}
+			notification.Channels = append(notification.Channels, channel)
+		}
+		notification.DeliveryStatus = domain.DeliveryStatusPending
 
-	text := fmt.Sprintf(`Hola! Tenemos programada la entrega de tu paquete para mañana, en la dirección de  entrega esperamos un día con %s y por esta razón es posible que tengamos retrasos. Haremos todo a nuestro alcance para cumplir con tu entrega.`,
-		data.Description)
+		quietHours, location, err := BuyerQuietHours(requestDataNotification, preferences, data.Timezone, settings)
+		if err != nil {
+			return nil, err
+		}
+		quietHoursEnd, inQuietHours, err := QuietHoursEnd(quietHours, now, location)
+		if err != nil {
+			return nil, err
+		}
+		if inQuietHours {
+			log.Printf("SendNotification: quiet hours of %s, notification scheduled at %s", requestDataNotification.Email, quietHoursEnd.Format(time.RFC3339))
+			notification.ScheduledAt = &quietHoursEnd
+		}
 
-	if *requireBuyerNotification {
-		sender.Send(requestDataNotification.Email, text)
-		err := repository.SaveNotification(ctx, *notification)
+		err = repository.SaveNotification(ctx, *notification)
 		if err != nil {
 			return nil, err
 		}
+		notificationServiceResponse.NotificationID = notification.ID
+		notificationServiceResponse.DeliveryStatus = notification.DeliveryStatus
+		notificationServiceResponse.Channels = ChannelEntitiesToDTOs(notification.Channels)
+		notificationServiceResponse.Locale = notification.Locale
+		notificationServiceResponse.ScheduledAt = notification.ScheduledAt
 	}
 
 	return &notificationServiceResponse, nil
 }
 
+// NotificationChannels returns the pending deliveries of a notification: the
+// channels stored in the buyer preferences, email when there are none, plus
+// the channels whose recipient comes in the request. Recipients in the request
+// take precedence over the stored ones.
+func NotificationChannels(requestDataNotification RequestDataNotification, preferences *domain.BuyerPreferences) []domain.ChannelDelivery {
+	channels := []string{domain.ChannelEmail}
+	phone, webhook := requestDataNotification.Phone, webhookTarget(requestDataNotification)
+	if preferences != nil {
+		channels = slices.Clone(preferences.Channels)
+		if phone == "" {
+			phone = preferences.Phone
+		}
+		if webhook == "" {
+			webhook = preferences.Webhook
+		}
+	}
+	if requestDataNotification.Phone != "" {
+		channels = append(channels, domain.ChannelSMS)
+	}
+	if webhookTarget(requestDataNotification) != "" {
+		channels = append(channels, domain.ChannelWebhook)
+	}
+
+	recipients := map[string]string{
+		domain.ChannelEmail:   requestDataNotification.Email,
+		domain.ChannelSMS:     phone,
+		domain.ChannelWebhook: webhook,
+	}
+
+	var deliveries []domain.ChannelDelivery
+	for _, channel := range channels {
+		if slices.ContainsFunc(deliveries, func(delivery domain.ChannelDelivery) bool { return delivery.Channel == channel }) {
+			continue
+		}
+		if recipients[channel] == "" {
+			log.Printf("NotificationChannels: no recipient for channel %s of %s", channel, requestDataNotification.Email)
+			continue
+		}
+		deliveries = append(deliveries, domain.ChannelDelivery{
+			Channel:   channel,
+			Recipient: recipients[channel],
+			Status:    domain.DeliveryStatusPending,
+		})
+	}
+	return deliveries
+}
+
+// webhookTarget returns the callback URL of the request or, when it has none,
+// its tenant, whose callback is taken from the configuration.
+func webhookTarget(requestDataNotification RequestDataNotification) string {
+	if requestDataNotification.CallbackURL != "" {
+		return requestDataNotification.CallbackURL
+	}
+	return requestDataNotification.Tenant
+}
+
+func WebhookPayloadJSON(notification domain.Notification, description, message string) (string, error) {
+	payload := WebhookPayload{
+		NotificationID:      notification.ID,
+		Event:               "delivery.delay_warning",
+		Email:               notification.Email,
+		Location:            LocationToDTO(notification.DeliveryLocation),
+		DeliveryDate:        notification.DeliveryDate,
+		ForecastCode:        notification.ForecastCode,
+		ForecastDescription: description,
+		TriggeredHours:      notification.TriggeredHours,
+		FiredRules:          notification.FiredRules,
+		Message:             message,
+		Locale:              notification.Locale,
+		CreatedAt:           notification.Created_at,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("error encoding webhook payload: %w", err)
+	}
+	return string(data), nil
+}
+
 func GetBuyerNotification(email string, repository domain.NotificationRepository) (*NotificationHistoryServiceResponse, error) {
 	ctx := context.Background()
 	notifications, err := repository.GetNotifications(ctx, email)
@@ -97,12 +390,29 @@ func GetBuyerNotification(email string, repository domain.NotificationRepository
 func NotificationEntityToDTO(notification domain.Notification) NotificationHistoryDetail {
 	return NotificationHistoryDetail{
 		NotificationSendAt: notification.Created_at,
-		Location: Location{
-			Latitude:  notification.DeliveryLocation.Latitude,
-			Longitude: notification.DeliveryLocation.Longitude,
-		},
-		ForecastCode: notification.ForecastCode,
+		Location:           LocationToDTO(notification.DeliveryLocation),
+		DeliveryDate:       notification.DeliveryDate,
+		ForecastCode:       notification.ForecastCode,
+		NotificationID:     notification.ID,
+		DeliveryID:         notification.DeliveryID,
+		DeliveryStatus:     notification.DeliveryStatus,
+		ScheduledAt:        notification.ScheduledAt,
+		Channels:           ChannelEntitiesToDTOs(notification.Channels),
+	}
+}
+
+func ChannelEntitiesToDTOs(channels []domain.ChannelDelivery) []ChannelDeliveryDetail {
+	var dtos []ChannelDeliveryDetail
+	for _, channel := range channels {
+		dtos = append(dtos, ChannelDeliveryDetail{
+			Channel:   channel.Channel,
+			Status:    channel.Status,
+			Attempts:  channel.Attempts,
+			LastError: channel.LastError,
+			SentAt:    channel.SentAt,
+		})
 	}
+	return dtos
 }
 
 func MapEntitiesToDTOs(entities []domain.Notification) []NotificationHistoryDetail {
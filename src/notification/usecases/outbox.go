@@ -0,0 +1,157 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+const (
+	defaultOutboxLease        = time.Minute
+	defaultOutboxRetryBackoff = 30 * time.Second
+	maxOutboxBackoff          = time.Hour
+)
+
+// OutboxPolicy controls how pending notifications are dispatched.
+type OutboxPolicy struct {
+	BatchSize    int
+	MaxAttempts  int
+	Lease        time.Duration
+	RetryBackoff time.Duration
+}
+
+// DispatchPendingNotifications sends the pending channels of the
+// notifications that are due in the outbox through the sender of each channel
+// and records the outcome of each one. Channels are independent: a failed
+// send is retried with exponential backoff until MaxAttempts, then marked as
+// failed, without holding back the other channels. The pending channels of a
+// buyer who opted out after the notification was created are suppressed. It
+// returns how many notifications were attempted.
+func DispatchPendingNotifications(ctx context.Context, policy OutboxPolicy, repository domain.NotificationRepository, senders map[string]domain.NotificationSender) (int, error) {
+	notifications, err := repository.ClaimPendingNotifications(ctx, policy.BatchSize, policy.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	optedOut := make(map[string]bool)
+	for _, notification := range notifications {
+		suppressed, checked := optedOut[notification.Email]
+		if !checked {
+			optOut, err := repository.GetOptOut(ctx, notification.Email)
+			if err != nil {
+				return 0, err
+			}
+			suppressed = optOut != nil
+			optedOut[notification.Email] = suppressed
+		}
+
+		now := time.Now()
+		attempts := 0
+		for i := range notification.Channels {
+			channel := &notification.Channels[i]
+			if channel.Status != domain.DeliveryStatusPending {
+				continue
+			}
+			if suppressed {
+				channel.Status = domain.DeliveryStatusSuppressed
+				continue
+			}
+
+			var sendErr error
+			sender, ok := senders[channel.Channel]
+			if ok {
+				sendErr = sender.Send(domain.Message{
+					NotificationID: notification.ID,
+					Channel:        channel.Channel,
+					Recipient:      channel.Recipient,
+					Subject:        channel.Subject,
+					Text:           channel.Message,
+					HTML:           channel.HTML,
+					UnsubscribeURL: channel.UnsubscribeURL,
+				})
+			} else {
+				sendErr = fmt.Errorf("no sender configured for channel %s", channel.Channel)
+			}
+
+			channel.Attempts++
+			if sendErr == nil {
+				channel.Status = domain.DeliveryStatusSent
+				channel.LastError = ""
+				channel.SentAt = &now
+				continue
+			}
+
+			channel.LastError = sendErr.Error()
+			if !ok || channel.Attempts >= policy.MaxAttempts {
+				channel.Status = domain.DeliveryStatusFailed
+			} else {
+				attempts = max(attempts, channel.Attempts)
+			}
+			log.Printf("DispatchPendingNotifications: notification %s channel %s attempt %d failed: %v", notification.ID, channel.Channel, channel.Attempts, sendErr)
+		}
+
+		if suppressed {
+			log.Printf("DispatchPendingNotifications: %s opted out, notification %s suppressed", notification.Email, notification.ID)
+		}
+
+		notification.DeliveryStatus = notification.ChannelsStatus()
+		var retryAt time.Time
+		if notification.DeliveryStatus == domain.DeliveryStatusPending {
+			retryAt = now.Add(outboxBackoff(policy.RetryBackoff, attempts))
+		}
+
+		if err := repository.UpdateNotificationDelivery(ctx, notification, retryAt); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(notifications), nil
+}
+
+func outboxBackoff(base time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < maxOutboxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxOutboxBackoff)
+}
+
+// RunOutboxDispatcher drains the outbox every pollInterval until ctx is done.
+// A full batch is followed immediately by the next one.
+func RunOutboxDispatcher(ctx context.Context, policy OutboxPolicy, pollInterval time.Duration, repository domain.NotificationRepository, senders map[string]domain.NotificationSender) {
+	if policy.BatchSize < 1 {
+		policy.BatchSize = 1
+	}
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Lease <= 0 {
+		policy.Lease = defaultOutboxLease
+	}
+	if policy.RetryBackoff <= 0 {
+		policy.RetryBackoff = defaultOutboxRetryBackoff
+	}
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+
+	go func() {
+		for ctx.Err() == nil {
+			dispatched, err := DispatchPendingNotifications(ctx, policy, repository, senders)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("RunOutboxDispatcher: %v", err)
+			}
+			if dispatched == policy.BatchSize {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(pollInterval):
+			}
+		}
+	}()
+}
@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+var notificationChannels = []string{domain.ChannelEmail, domain.ChannelSMS, domain.ChannelWebhook}
+
+func GetBuyerPreferences(email string, repository domain.NotificationRepository) (*BuyerPreferencesResponse, error) {
+	ctx := context.Background()
+	preferences, err := repository.GetBuyerPreferences(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if preferences == nil {
+		return nil, &domain.NotFoundError{Message: fmt.Sprintf("Preferences of buyer %s not found", email)}
+	}
+
+	response := BuyerPreferencesToDTO(*preferences)
+	return &response, nil
+}
+
+// SaveBuyerPreferences replaces the channels a buyer is notified through.
+// Every channel other than email needs its recipient: an E.164 phone for sms
+// and a callback URL or configured tenant for webhook. Quiet hours, when set,
+// replace the global ones for the buyer.
+func SaveBuyerPreferences(email string, request BuyerPreferencesRequest, repository domain.NotificationRepository) (*BuyerPreferencesResponse, error) {
+	preferences, err := newBuyerPreferences(email, request, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if err := repository.SaveBuyerPreferences(ctx, *preferences); err != nil {
+		return nil, err
+	}
+	log.Printf("SaveBuyerPreferences %s channels %v", email, preferences.Channels)
+
+	response := BuyerPreferencesToDTO(*preferences)
+	return &response, nil
+}
+
+func newBuyerPreferences(email string, request BuyerPreferencesRequest, now time.Time) (*domain.BuyerPreferences, error) {
+	if len(request.Channels) == 0 {
+		return nil, &domain.ValidationError{Field: "channels", Message: "at least one channel is required"}
+	}
+
+	var channels []string
+	for _, channel := range request.Channels {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if !slices.Contains(notificationChannels, channel) {
+			return nil, &domain.ValidationError{Field: "channels", Message: fmt.Sprintf("unknown channel %q, must be one of %s", channel, strings.Join(notificationChannels, ", "))}
+		}
+		if !slices.Contains(channels, channel) {
+			channels = append(channels, channel)
+		}
+	}
+
+	if request.Phone != "" && !IsValidPhone(request.Phone) {
+		return nil, &domain.ValidationError{Field: "phone", Message: "must be an E.164 number"}
+	}
+	if slices.Contains(channels, domain.ChannelSMS) && request.Phone == "" {
+		return nil, &domain.ValidationError{Field: "phone", Message: "is required for the sms channel"}
+	}
+
+	webhook := strings.TrimSpace(request.Webhook)
+	if strings.Contains(webhook, "://") && !IsValidCallbackURL(webhook) {
+		return nil, &domain.ValidationError{Field: "webhook", Message: "must be an absolute http(s) URL or a tenant"}
+	}
+	if slices.Contains(channels, domain.ChannelWebhook) && webhook == "" {
+		return nil, &domain.ValidationError{Field: "webhook", Message: "is required for the webhook channel"}
+	}
+
+	var quietHours *domain.QuietHours
+	if request.QuietHours != nil {
+		if err := ValidateQuietHours(*request.QuietHours, "quiet_hours"); err != nil {
+			return nil, err
+		}
+		quietHours = &domain.QuietHours{Start: request.QuietHours.Start, End: request.QuietHours.End}
+	}
+	if err := ValidateTimezone(request.Timezone, "timezone"); err != nil {
+		return nil, err
+	}
+
+	return &domain.BuyerPreferences{
+		Email:      email,
+		Channels:   channels,
+		Phone:      request.Phone,
+		Webhook:    webhook,
+		QuietHours: quietHours,
+		Timezone:   request.Timezone,
+		UpdatedAt:  now,
+	}, nil
+}
+
+func BuyerPreferencesToDTO(preferences domain.BuyerPreferences) BuyerPreferencesResponse {
+	response := BuyerPreferencesResponse{
+		Email:     preferences.Email,
+		Channels:  preferences.Channels,
+		Phone:     preferences.Phone,
+		Webhook:   preferences.Webhook,
+		Timezone:  preferences.Timezone,
+		UpdatedAt: preferences.UpdatedAt,
+	}
+	if preferences.QuietHours != nil {
+		response.QuietHours = &QuietHours{Start: preferences.QuietHours.Start, End: preferences.QuietHours.End}
+	}
+	return response
+}
@@ -0,0 +1,84 @@
+package usecases
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+// ValidateQuietHours checks both ends of the range; field names the range in
+// the validation errors.
+func ValidateQuietHours(quietHours QuietHours, field string) error {
+	if _, err := time.Parse(DeliveryWindowClockLayout, quietHours.Start); err != nil {
+		return &domain.ValidationError{Field: field + ".start", Message: "must be a time (HH:MM)"}
+	}
+	if _, err := time.Parse(DeliveryWindowClockLayout, quietHours.End); err != nil {
+		return &domain.ValidationError{Field: field + ".end", Message: "must be a time (HH:MM)"}
+	}
+	return nil
+}
+
+// ValidateTimezone checks that name, when set, is an IANA timezone.
+func ValidateTimezone(name, field string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return &domain.ValidationError{Field: field, Message: "must be an IANA timezone"}
+	}
+	return nil
+}
+
+// QuietHoursEnd returns when the quiet hours that contain now end, read in
+// location, and false when now is outside the quiet hours.
+func QuietHoursEnd(quietHours domain.QuietHours, now time.Time, location *time.Location) (time.Time, bool, error) {
+	if quietHours.Start == quietHours.End {
+		return time.Time{}, false, nil
+	}
+
+	start, err := time.Parse(DeliveryWindowClockLayout, quietHours.Start)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid quiet hours start %q", quietHours.Start)
+	}
+	end, err := time.Parse(DeliveryWindowClockLayout, quietHours.End)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid quiet hours end %q", quietHours.End)
+	}
+
+	local := now.In(location)
+	// The quiet hours that contain now started today or, when they cross
+	// midnight, yesterday.
+	for _, day := range []int{0, -1} {
+		from := time.Date(local.Year(), local.Month(), local.Day()+day, start.Hour(), start.Minute(), 0, 0, location)
+		to := time.Date(local.Year(), local.Month(), local.Day()+day, end.Hour(), end.Minute(), 0, 0, location)
+		if !end.After(start) {
+			to = time.Date(local.Year(), local.Month(), local.Day()+day+1, end.Hour(), end.Minute(), 0, 0, location)
+		}
+		if !local.Before(from) && local.Before(to) {
+			return to, true, nil
+		}
+	}
+	return time.Time{}, false, nil
+}
+
+// BuyerQuietHours returns the quiet hours of the buyer, the global ones when
+// the buyer has none, and the timezone they are read in: the one of the
+// request, of the preferences, of the delivery location as reported by the
+// forecast, the default timezone or UTC, in that order.
+func BuyerQuietHours(requestDataNotification RequestDataNotification, preferences *domain.BuyerPreferences, forecastTimezone string, settings NotificationSettings) (domain.QuietHours, *time.Location, error) {
+	quietHours := settings.QuietHours
+	preferencesTimezone := ""
+	if preferences != nil {
+		if preferences.QuietHours != nil {
+			quietHours = *preferences.QuietHours
+		}
+		preferencesTimezone = preferences.Timezone
+	}
+
+	location, err := loadLocation(requestDataNotification.Timezone, preferencesTimezone, forecastTimezone, settings.DefaultTimezone)
+	if err != nil {
+		return domain.QuietHours{}, nil, err
+	}
+	return quietHours, location, nil
+}
@@ -0,0 +1,136 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+)
+
+func ForecastMetricsFromDTO(data *third_party.ForecastServiceResponse) domain.ForecastMetrics {
+	return domain.ForecastMetrics{
+		Code:         data.Code,
+		PrecipMm:     data.TotalPrecipMm,
+		WindKph:      data.MaxWindKph,
+		MaxTempC:     data.MaxTempC,
+		MinTempC:     data.MinTempC,
+		ChanceOfRain: data.ChanceOfRain,
+		SnowCm:       data.TotalSnowCm,
+	}
+}
+
+// EvaluateNotificationRules returns the names of the stored rules that fire
+// for the forecast. Rules compare the daily metrics of the forecast, also for
+// requests with a delivery window. Misconfigured rules are logged and
+// skipped.
+func EvaluateNotificationRules(ctx context.Context, repository domain.NotificationRepository, data *third_party.ForecastServiceResponse) ([]string, error) {
+	rules, err := repository.GetNotificationRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := ForecastMetricsFromDTO(data)
+	firedRules := []string{}
+	for _, rule := range rules {
+		matches, err := rule.Matches(metrics)
+		if err != nil {
+			log.Printf("EvaluateNotificationRules: %s", err)
+			continue
+		}
+		if matches {
+			firedRules = append(firedRules, rule.Name)
+		}
+	}
+
+	return firedRules, nil
+}
+
+func ListNotificationRules(repository domain.NotificationRepository) (*NotificationRulesServiceResponse, error) {
+	rules, err := repository.GetNotificationRules(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]NotificationRuleDetail, len(rules))
+	for i, rule := range rules {
+		details[i] = NotificationRuleToDTO(rule)
+	}
+	return &NotificationRulesServiceResponse{Rules: details}, nil
+}
+
+// SaveNotificationRule creates or replaces the rule name once its operator
+// and conditions are valid. Codes are normalized like notification codes.
+func SaveNotificationRule(name string, request RequestNotificationRule, user string, repository domain.NotificationRepository) (*NotificationRuleDetail, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, &domain.ValidationError{Field: "name", Message: "is required"}
+	}
+
+	rule := domain.NotificationRule{
+		Name:       name,
+		Operator:   strings.ToLower(strings.TrimSpace(request.Operator)),
+		Conditions: make([]domain.RuleCondition, len(request.Conditions)),
+		UpdatedBy:  user,
+		UpdatedAt:  time.Now(),
+	}
+	for i, condition := range request.Conditions {
+		codes := make([]string, len(condition.Codes))
+		for j, code := range condition.Codes {
+			normalized, err := NormalizeNotificationCode(code)
+			if err != nil {
+				return nil, err
+			}
+			codes[j] = normalized
+		}
+		rule.Conditions[i] = domain.RuleCondition{
+			Metric:   strings.TrimSpace(condition.Metric),
+			Operator: strings.TrimSpace(condition.Operator),
+			Value:    condition.Value,
+			Codes:    codes,
+		}
+	}
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := repository.SaveNotificationRule(context.Background(), rule); err != nil {
+		return nil, err
+	}
+	log.Printf("SaveNotificationRule %s by %s", rule.Name, user)
+
+	detail := NotificationRuleToDTO(rule)
+	return &detail, nil
+}
+
+func RemoveNotificationRule(name, user string, repository domain.NotificationRepository) error {
+	if err := repository.DeleteNotificationRule(context.Background(), name); err != nil {
+		return err
+	}
+	log.Printf("RemoveNotificationRule %s by %s", name, user)
+
+	return nil
+}
+
+func NotificationRuleToDTO(rule domain.NotificationRule) NotificationRuleDetail {
+	detail := NotificationRuleDetail{
+		Name:       rule.Name,
+		Operator:   rule.Operator,
+		Conditions: make([]RuleConditionDetail, len(rule.Conditions)),
+		UpdatedBy:  rule.UpdatedBy,
+	}
+	if !rule.UpdatedAt.IsZero() {
+		detail.UpdatedAt = &rule.UpdatedAt
+	}
+	for i, condition := range rule.Conditions {
+		detail.Conditions[i] = RuleConditionDetail{
+			Metric:   condition.Metric,
+			Operator: condition.Operator,
+			Value:    condition.Value,
+			Codes:    condition.Codes,
+		}
+	}
+	return detail
+}
@@ -0,0 +1,128 @@
+package usecases
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+)
+
+// UnsubscribeLinks builds the one-click unsubscribe links of the emails. The
+// token of a link is the email signed with Secret, so it does not expire.
+// Without a secret or a base URL no link is built.
+type UnsubscribeLinks struct {
+	Secret  string
+	BaseURL string
+}
+
+// URL returns the unsubscribe link of email, or "" when links are disabled.
+// The page is shown in locale when it is set.
+func (links UnsubscribeLinks) URL(email, locale string) string {
+	if links.Secret == "" || links.BaseURL == "" {
+		return ""
+	}
+	link := strings.TrimRight(links.BaseURL, "/") + "/unsubscribe?token=" + url.QueryEscape(UnsubscribeToken(email, links.Secret))
+	if locale != "" {
+		link += "&locale=" + url.QueryEscape(locale)
+	}
+	return link
+}
+
+// UnsubscribeToken returns "<email>.<signature>", both base64url encoded,
+// where the signature is the HMAC-SHA256 of the normalized email with secret.
+func UnsubscribeToken(email, secret string) string {
+	email = domain.NormalizeEmail(email)
+	return base64.RawURLEncoding.EncodeToString([]byte(email)) + "." +
+		base64.RawURLEncoding.EncodeToString(unsubscribeSignature(email, secret))
+}
+
+// VerifyUnsubscribeToken returns the email of a token signed with secret.
+func VerifyUnsubscribeToken(token, secret string) (string, error) {
+	invalid := errors.New("invalid unsubscribe token")
+	if secret == "" {
+		return "", invalid
+	}
+
+	encodedEmail, encodedSignature, found := strings.Cut(token, ".")
+	if !found {
+		return "", invalid
+	}
+	email, err := base64.RawURLEncoding.DecodeString(encodedEmail)
+	if err != nil {
+		return "", invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return "", invalid
+	}
+	if !hmac.Equal(signature, unsubscribeSignature(string(email), secret)) {
+		return "", invalid
+	}
+	return domain.NormalizeEmail(string(email)), nil
+}
+
+func unsubscribeSignature(email, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("unsubscribe:" + email))
+	return mac.Sum(nil)
+}
+
+// Unsubscribe adds the buyer to the opt-out list. Opting out again keeps the
+// original record.
+func Unsubscribe(email, source string, repository domain.NotificationRepository) (*SubscriptionResponse, error) {
+	email = domain.NormalizeEmail(email)
+	ctx := context.Background()
+	optOut, err := repository.GetOptOut(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	if optOut == nil {
+		optOut = &domain.OptOut{Email: email, Source: source, CreatedAt: time.Now()}
+		if err := repository.SaveOptOut(ctx, *optOut); err != nil {
+			return nil, err
+		}
+		log.Printf("Unsubscribe %s source %s", email, source)
+	}
+
+	response := SubscriptionToDTO(email, optOut)
+	return &response, nil
+}
+
+// UnsubscribeWithToken opts out the buyer of an unsubscribe link.
+func UnsubscribeWithToken(token, secret string, repository domain.NotificationRepository) (*SubscriptionResponse, error) {
+	email, err := VerifyUnsubscribeToken(token, secret)
+	if err != nil {
+		return nil, &domain.ValidationError{Field: "token", Message: "is not a valid unsubscribe token"}
+	}
+	return Unsubscribe(email, domain.OptOutSourceLink, repository)
+}
+
+// Resubscribe removes the buyer from the opt-out list.
+func Resubscribe(email string, repository domain.NotificationRepository) (*SubscriptionResponse, error) {
+	email = domain.NormalizeEmail(email)
+	ctx := context.Background()
+	if err := repository.DeleteOptOut(ctx, email); err != nil {
+		return nil, err
+	}
+	log.Printf("Resubscribe %s", email)
+
+	response := SubscriptionToDTO(email, nil)
+	return &response, nil
+}
+
+func SubscriptionToDTO(email string, optOut *domain.OptOut) SubscriptionResponse {
+	response := SubscriptionResponse{Email: email, Subscribed: optOut == nil}
+	if optOut != nil {
+		response.Source = optOut.Source
+		response.UnsubscribedAt = &optOut.CreatedAt
+	}
+	return response
+}
@@ -0,0 +1,45 @@
+package usecases
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+)
+
+var emailRegex = regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
+
+var phoneRegex = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// IsValidEmail reports whether email is an address, ignoring case; buyers are
+// keyed by domain.NormalizeEmail.
+func IsValidEmail(email string) bool {
+	return emailRegex.MatchString(strings.ToLower(email))
+}
+
+// IsValidPhone reports whether phone is an E.164 number such as +5511912345678.
+func IsValidPhone(phone string) bool {
+	return phoneRegex.MatchString(phone)
+}
+
+// IsValidCallbackURL reports whether callbackURL is an absolute http(s) URL
+// whose host is not localhost nor a loopback, private or link-local address.
+// Hostnames are checked again on the resolved address when the webhook is
+// sent.
+func IsValidCallbackURL(callbackURL string) bool {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return false
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return server.IsPublicIP(ip)
+	}
+	return true
+}
@@ -0,0 +1,393 @@
+package service_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/juandr89/delivery-notifier-buyer/middleware"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAdminRouter(repo domain.NotificationRepository) *mux.Router {
+	adminHandler := infrastructure.NewAdminHandler(repo)
+	router := mux.NewRouter()
+	router.Use(middleware.AdminKeyMiddleware(map[string]string{"alice": "alice-key"}))
+	router.HandleFunc("/admin/codes", adminHandler.ListCodes).Methods(http.MethodGet)
+	router.HandleFunc("/admin/codes", adminHandler.AddCode).Methods(http.MethodPost)
+	router.HandleFunc("/admin/codes", adminHandler.ReplaceCodes).Methods(http.MethodPut)
+	router.HandleFunc("/admin/codes/{code}", adminHandler.RemoveCode).Methods(http.MethodDelete)
+	router.HandleFunc("/admin/rules", adminHandler.ListRules).Methods(http.MethodGet)
+	router.HandleFunc("/admin/rules/{name}", adminHandler.SaveRule).Methods(http.MethodPut)
+	router.HandleFunc("/admin/rules/{name}", adminHandler.RemoveRule).Methods(http.MethodDelete)
+	return router
+}
+
+func TestAdminCodes(t *testing.T) {
+	t.Run("Unauthorized", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		router := newAdminRouter(mocks.NewMockNotificationRepository(ctrl))
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/codes", nil)
+		req.Header.Set("x-admin-key", "wrong")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().ListNotificationCodes(gomock.Any()).Return([]domain.NotificationCode{
+			{Code: "1063", Description: "Lluvia", UpdatedBy: "alice"},
+		}, nil).Times(1)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/codes", nil)
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"1063"`)
+		assert.Contains(t, w.Body.String(), `"updated_by":"alice"`)
+	})
+
+	t.Run("Add", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().SaveNotificationCode(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx interface{}, code domain.NotificationCode) error {
+			assert.Equal(t, "1063", code.Code)
+			assert.Equal(t, "Lluvia ligera", code.Description)
+			assert.Equal(t, "alice", code.UpdatedBy)
+			assert.False(t, code.UpdatedAt.IsZero())
+			return nil
+		}).Times(1)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/codes", bytes.NewBufferString(`{"code":"1063.0","description":"Lluvia ligera"}`))
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("AddInvalidCode", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		router := newAdminRouter(mocks.NewMockNotificationRepository(ctrl))
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/codes", bytes.NewBufferString(`{"code":"rain","description":"Lluvia"}`))
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid code: must be a numeric forecast code")
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().ReplaceNotificationCodes(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx interface{}, codes []domain.NotificationCode) error {
+			assert.Len(t, codes, 2)
+			return nil
+		}).Times(1)
+
+		body := `[{"code":"1063","description":"Lluvia"},{"code":"1195","description":"Lluvia fuerte"}]`
+		req := httptest.NewRequest(http.MethodPut, "/admin/codes", bytes.NewBufferString(body))
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"1195"`)
+	})
+
+	t.Run("ReplaceDuplicated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		router := newAdminRouter(mocks.NewMockNotificationRepository(ctrl))
+
+		body := `[{"code":"1063","description":"Lluvia"},{"code":"1063.0","description":"Lluvia"}]`
+		req := httptest.NewRequest(http.MethodPut, "/admin/codes", bytes.NewBufferString(body))
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "1063 is duplicated")
+	})
+
+	t.Run("ReplaceEmptyRequiresConfirm", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		router := newAdminRouter(mocks.NewMockNotificationRepository(ctrl))
+
+		req := httptest.NewRequest(http.MethodPut, "/admin/codes", bytes.NewBufferString(`[]`))
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "confirm")
+	})
+
+	t.Run("ReplaceEmptyConfirmed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().ReplaceNotificationCodes(gomock.Any(), []domain.NotificationCode{}).Return(nil).Times(1)
+
+		req := httptest.NewRequest(http.MethodPut, "/admin/codes?confirm=true", bytes.NewBufferString(`[]`))
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"codes":[]}`, w.Body.String())
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063", "1195"}, nil).Times(1)
+		mockRepo.EXPECT().DeleteNotificationCode(gomock.Any(), "1063").Return(nil).Times(1)
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/codes/1063", nil)
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("RemoveLastRequiresConfirm", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil).Times(1)
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/codes/1063", nil)
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "confirm")
+	})
+
+	t.Run("RemoveLastConfirmed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().DeleteNotificationCode(gomock.Any(), "1063").Return(nil).Times(1)
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/codes/1063?confirm=true", nil)
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("RemoveNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1195"}, nil).Times(1)
+		mockRepo.EXPECT().DeleteNotificationCode(gomock.Any(), "1063").Return(&domain.NotFoundError{Message: "Notification code 1063 not found"}).Times(1)
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/codes/1063", nil)
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().ListNotificationCodes(gomock.Any()).Return(nil, errors.New("redis error")).Times(1)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/codes", nil)
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestAdminRules(t *testing.T) {
+	t.Run("Unauthorized", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		router := newAdminRouter(mocks.NewMockNotificationRepository(ctrl))
+
+		req := httptest.NewRequest(http.MethodPut, "/admin/rules/storm", bytes.NewBufferString(`{}`))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return([]domain.NotificationRule{
+			{Name: "storm", Conditions: []domain.RuleCondition{{Metric: domain.MetricWindKph, Operator: "gte", Value: 50}}},
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/rules", nil)
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"storm"`)
+		assert.NotContains(t, w.Body.String(), "updated_at")
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().SaveNotificationRule(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx interface{}, rule domain.NotificationRule) error {
+			assert.Equal(t, "storm", rule.Name)
+			assert.Equal(t, domain.RuleOperatorOr, rule.Operator)
+			assert.Equal(t, []string{"1189", "1195"}, rule.Conditions[1].Codes)
+			assert.Equal(t, "alice", rule.UpdatedBy)
+			assert.False(t, rule.UpdatedAt.IsZero())
+			return nil
+		})
+
+		body := `{"operator":"or","conditions":[{"metric":"precip_mm","operator":"gte","value":20},{"metric":"code","operator":"in","codes":["1189.0","1195"]}]}`
+		req := httptest.NewRequest(http.MethodPut, "/admin/rules/storm", bytes.NewBufferString(body))
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"updated_by":"alice"`)
+	})
+
+	t.Run("SaveInvalid", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			body    string
+			message string
+		}{
+			{"NoConditions", `{"conditions":[]}`, "Invalid conditions: must not be empty"},
+			{"UnknownOperator", `{"operator":"xor","conditions":[{"metric":"wind_kph","operator":"gt","value":1}]}`, "Invalid operator: must be and or or"},
+			{"UnknownMetric", `{"conditions":[{"metric":"hail","operator":"gt","value":1}]}`, `Invalid conditions[0]: unknown metric \"hail\"`},
+			{"UnknownComparison", `{"conditions":[{"metric":"wind_kph","operator":"in","value":1}]}`, `Invalid conditions[0]: unknown comparison \"in\" for metric wind_kph`},
+			{"CodeWithoutCodes", `{"conditions":[{"metric":"code","operator":"in"}]}`, "Invalid conditions[0]: codes must not be empty"},
+			{"InvalidCode", `{"conditions":[{"metric":"code","operator":"in","codes":["rain"]}]}`, "Invalid code: must be a numeric forecast code"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+				router := newAdminRouter(mocks.NewMockNotificationRepository(ctrl))
+
+				req := httptest.NewRequest(http.MethodPut, "/admin/rules/storm", bytes.NewBufferString(tt.body))
+				req.Header.Set("x-admin-key", "alice-key")
+				w := httptest.NewRecorder()
+
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), tt.message)
+			})
+		}
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().DeleteNotificationRule(gomock.Any(), "storm").Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/rules/storm", nil)
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("RemoveNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newAdminRouter(mockRepo)
+
+		mockRepo.EXPECT().DeleteNotificationRule(gomock.Any(), "storm").Return(&domain.NotFoundError{Message: "Notification rule storm not found"})
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/rules/storm", nil)
+		req.Header.Set("x-admin-key", "alice-key")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,179 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBatchRequests(t *testing.T) {
+	t.Run("JSONArray", func(t *testing.T) {
+		body := `[
+			{"email": "a@example.com", "location": {"latitude": "1", "longitude": "2"}},
+			{"email": "not-an-email", "location": {"latitude": "1", "longitude": "2"}}
+		]`
+
+		items, err := usecases.ParseBatchRequests(strings.NewReader(body), 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, items, 2)
+		assert.Equal(t, 1, items[0].Line)
+		assert.NoError(t, items[0].Err)
+		assert.Equal(t, "a@example.com", items[0].Request.Email)
+		assert.Equal(t, 2, items[1].Line)
+		assert.EqualError(t, items[1].Err, "Invalid email: must be a valid email")
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		body := `{"email": "a@example.com", "location": {"latitude": "1", "longitude": "2"}}
+
+{"email": "b@example.com", "unknown": true}
+{"email": "c@example.com", "location": {"latitude": "1", "longitude": "2"}}
+`
+
+		items, err := usecases.ParseBatchRequests(strings.NewReader(body), 10)
+
+		assert.NoError(t, err)
+		assert.Len(t, items, 3)
+		assert.Equal(t, 1, items[0].Line)
+		assert.Equal(t, 3, items[1].Line)
+		assert.EqualError(t, items[1].Err, "Invalid body: invalid JSON data")
+		assert.Equal(t, 4, items[2].Line)
+		assert.NoError(t, items[2].Err)
+	})
+
+	t.Run("ExceedsMaxItems", func(t *testing.T) {
+		body := "{\"email\": \"a@example.com\"}\n{\"email\": \"b@example.com\"}\n"
+
+		items, err := usecases.ParseBatchRequests(strings.NewReader(body), 1)
+
+		assert.Nil(t, items)
+		assert.EqualError(t, err, "Invalid body: batch exceeds 1 items")
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		items, err := usecases.ParseBatchRequests(strings.NewReader("  \n"), 10)
+
+		assert.Nil(t, items)
+		assert.IsType(t, &domain.ValidationError{}, err)
+	})
+}
+
+func TestSendBatchNotifications(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockNotificationRepository(ctrl)
+	mockForecastService := mocks.NewMockForecastService(ctrl)
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
+	mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1063, Description: "Lluvia"}, nil).AnyTimes()
+	mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 10, Longitude: 20}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1000, Description: "Soleado"}, nil).AnyTimes()
+	mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 30, Longitude: 40}, tomorrow).Return(nil, assert.AnError).AnyTimes()
+	mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil).AnyTimes()
+	mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil).AnyTimes()
+	mockRepo.EXPECT().GetOptOut(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	items := []usecases.BatchItem{
+		{Line: 1, Request: usecases.RequestDataNotification{Email: "a@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}}},
+		{Line: 2, Request: usecases.RequestDataNotification{Email: "b@example.com", Location: usecases.Location{Latitude: "10", Longitude: "20"}}},
+		{Line: 3, Err: &domain.ValidationError{Field: "body", Message: "invalid JSON data"}},
+		{Line: 4, Request: usecases.RequestDataNotification{Email: "c@example.com", Location: usecases.Location{Latitude: "30", Longitude: "40"}}},
+		{Line: 5, Request: usecases.RequestDataNotification{Email: "d@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}}},
+		{Line: 6, Request: usecases.RequestDataNotification{Email: "e@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}, DeliveryDate: "2000-01-01"}},
+	}
+
+	result := usecases.SendBatchNotifications(context.Background(), items, 2, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+	assert.Equal(t, 6, result.Total)
+	assert.Equal(t, 2, result.Sent)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Equal(t, 2, result.Invalid)
+	assert.Equal(t, 1, result.Failed)
+
+	statuses := make([]string, len(result.Results))
+	for i, itemResult := range result.Results {
+		assert.Equal(t, items[i].Line, itemResult.Line)
+		statuses[i] = itemResult.Status
+	}
+	assert.Equal(t, []string{"sent", "skipped", "invalid", "failed", "sent", "invalid"}, statuses)
+}
+
+func TestSendBatchNotificationsCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockRepo := mocks.NewMockNotificationRepository(ctrl)
+	mockForecastService := mocks.NewMockForecastService(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The client goes away while the first item is processed.
+	mockForecastService.EXPECT().MaxForecastDays().Return(3)
+	mockForecastService.EXPECT().FetchForecastByLocation(gomock.Any(), gomock.Any()).DoAndReturn(func(location domain.DeliveryLocation, deliveryDate string) (*third_party.ForecastServiceResponse, error) {
+		cancel()
+		return nil, assert.AnError
+	})
+
+	items := []usecases.BatchItem{
+		{Line: 1, Request: usecases.RequestDataNotification{Email: "a@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}}},
+		{Line: 2, Request: usecases.RequestDataNotification{Email: "b@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}}},
+		{Line: 3, Request: usecases.RequestDataNotification{Email: "c@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}}},
+	}
+
+	result := usecases.SendBatchNotifications(ctx, items, 1, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+	assert.Equal(t, 1, result.Failed)
+	assert.Equal(t, 2, result.Cancelled)
+	assert.Equal(t, usecases.BatchStatusCancelled, result.Results[2].Status)
+	assert.Equal(t, context.Canceled.Error(), result.Results[2].Error)
+}
+
+func TestNotifyBuyersBatch(t *testing.T) {
+	t.Run("InvalidBody", func(t *testing.T) {
+		handler := infrastructure.NewNotificationHandler(nil, nil, nil, server.Config{})
+
+		req := httptest.NewRequest(http.MethodPost, "/notifications/batch", bytes.NewBufferString(""))
+		w := httptest.NewRecorder()
+
+		handler.NotifyBuyersBatch(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		handler := infrastructure.NewNotificationHandler(nil, nil, mockForecastService, server.Config{})
+
+		req := httptest.NewRequest(http.MethodPost, "/notifications/batch", bytes.NewBufferString(`[{"email": "invalid"}]`))
+		w := httptest.NewRecorder()
+
+		handler.NotifyBuyersBatch(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response usecases.BatchServiceResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Invalid)
+		assert.Equal(t, "invalid", response.Results[0].Status)
+	})
+}
@@ -0,0 +1,136 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupKey(t *testing.T) {
+	t.Run("SameBucket", func(t *testing.T) {
+		first := usecases.DedupKey("Buyer@Example.com ", "2024-10-11", domain.DeliveryLocation{Latitude: -23.5505, Longitude: -46.6333}, 2)
+		second := usecases.DedupKey("buyer@example.com", "2024-10-11", domain.DeliveryLocation{Latitude: -23.5521, Longitude: -46.6349}, 2)
+
+		assert.Equal(t, "buyer@example.com:2024-10-11:-23.55:-46.63", first)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("OtherBucket", func(t *testing.T) {
+		first := usecases.DedupKey("buyer@example.com", "2024-10-11", domain.DeliveryLocation{Latitude: -23.5505, Longitude: -46.6333}, 2)
+		second := usecases.DedupKey("buyer@example.com", "2024-10-11", domain.DeliveryLocation{Latitude: -23.5605, Longitude: -46.6333}, 2)
+
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("OtherDeliveryDate", func(t *testing.T) {
+		location := domain.DeliveryLocation{Latitude: 1, Longitude: 2}
+
+		assert.NotEqual(t,
+			usecases.DedupKey("buyer@example.com", "2024-10-11", location, 2),
+			usecases.DedupKey("buyer@example.com", "2024-10-12", location, 2))
+	})
+
+	t.Run("Equator", func(t *testing.T) {
+		key := usecases.DedupKey("buyer@example.com", "2024-10-11", domain.DeliveryLocation{Latitude: -0.001, Longitude: 2}, 1)
+
+		assert.Equal(t, "buyer@example.com:2024-10-11:0.0:2.0", key)
+	})
+}
+
+func TestSendNotificationDedup(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	request := usecases.RequestDataNotification{Email: "a@example.com", Location: usecases.Location{Latitude: "1.234", Longitude: "2.345"}}
+	settings := usecases.NotificationSettings{DedupWindow: time.Hour, DedupPrecision: 2}
+	key := "a@example.com:" + tomorrow + ":1.23:2.35"
+
+	expectForecast := func(mockForecastService *mocks.MockForecastService, mockRepo *mocks.MockNotificationRepository) {
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1.234, Longitude: 2.345}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1063, Description: "Lluvia"}, nil)
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+	}
+
+	t.Run("FirstNotification", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		expectForecast(mockForecastService, mockRepo)
+
+		var reservedID string
+		mockRepo.EXPECT().ReserveDedupKey(gomock.Any(), key, gomock.Any(), time.Hour).DoAndReturn(func(ctx context.Context, key, notificationID string, window time.Duration) (string, error) {
+			reservedID = notificationID
+			return notificationID, nil
+		})
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).Return(nil)
+
+		response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), settings)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryStatusPending, response.DeliveryStatus)
+		assert.Equal(t, reservedID, response.NotificationID)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		expectForecast(mockForecastService, mockRepo)
+
+		mockRepo.EXPECT().ReserveDedupKey(gomock.Any(), key, gomock.Any(), time.Hour).Return("first-id", nil)
+
+		response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), settings)
+
+		assert.NoError(t, err)
+		assert.True(t, response.BuyerNotification)
+		assert.Equal(t, domain.DeliveryStatusDuplicate, response.DeliveryStatus)
+		assert.Equal(t, "first-id", response.NotificationID)
+		assert.Empty(t, response.Channels)
+	})
+
+	t.Run("ReleaseOnSaveError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		expectForecast(mockForecastService, mockRepo)
+
+		mockRepo.EXPECT().ReserveDedupKey(gomock.Any(), key, gomock.Any(), time.Hour).DoAndReturn(func(ctx context.Context, key, notificationID string, window time.Duration) (string, error) {
+			return notificationID, nil
+		})
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).Return(errors.New("failed to save notification"))
+		mockRepo.EXPECT().ReleaseDedupKey(gomock.Any(), key).Return(nil)
+
+		response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), settings)
+
+		assert.EqualError(t, err, "failed to save notification")
+		assert.Nil(t, response)
+	})
+
+	t.Run("ReserveError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		expectForecast(mockForecastService, mockRepo)
+
+		mockRepo.EXPECT().ReserveDedupKey(gomock.Any(), key, gomock.Any(), time.Hour).Return("", errors.New("error reserving dedup key in Redis: timeout"))
+
+		response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), settings)
+
+		assert.EqualError(t, err, "error reserving dedup key in Redis: timeout")
+		assert.Nil(t, response)
+	})
+}
@@ -0,0 +1,687 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
+	repository "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/repository"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronSchedule(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		expression string
+		after      time.Time
+		next       time.Time
+	}{
+		{"DailyLaterToday", "0 18 * * *", time.Date(2024, 10, 10, 9, 30, 0, 0, saoPaulo), time.Date(2024, 10, 10, 18, 0, 0, 0, saoPaulo)},
+		{"DailyTomorrow", "0 18 * * *", time.Date(2024, 10, 10, 18, 0, 0, 0, saoPaulo), time.Date(2024, 10, 11, 18, 0, 0, 0, saoPaulo)},
+		{"Steps", "*/15 8-18/2 * * *", time.Date(2024, 10, 10, 9, 50, 0, 0, time.UTC), time.Date(2024, 10, 10, 10, 0, 0, 0, time.UTC)},
+		{"Lists", "5,35 7 * * *", time.Date(2024, 10, 10, 7, 6, 0, 0, time.UTC), time.Date(2024, 10, 10, 7, 35, 0, 0, time.UTC)},
+		{"Weekdays", "0 9 * * 1-5", time.Date(2024, 10, 11, 10, 0, 0, 0, time.UTC), time.Date(2024, 10, 14, 9, 0, 0, 0, time.UTC)},
+		{"SundayAsSeven", "0 9 * * 7", time.Date(2024, 10, 10, 10, 0, 0, 0, time.UTC), time.Date(2024, 10, 13, 9, 0, 0, 0, time.UTC)},
+		{"DayOfMonthOrWeekday", "0 0 1 * 1", time.Date(2024, 10, 22, 0, 0, 0, 0, time.UTC), time.Date(2024, 10, 28, 0, 0, 0, 0, time.UTC)},
+		{"EveryDayStep", "0 9 */1 * 1-5", time.Date(2024, 10, 11, 10, 0, 0, 0, time.UTC), time.Date(2024, 10, 14, 9, 0, 0, 0, time.UTC)},
+		{"EveryDayRange", "0 9 1-31 * 1", time.Date(2024, 10, 11, 10, 0, 0, 0, time.UTC), time.Date(2024, 10, 14, 9, 0, 0, 0, time.UTC)},
+		{"EveryWeekdayRange", "0 9 1 * 0-6", time.Date(2024, 10, 10, 10, 0, 0, 0, time.UTC), time.Date(2024, 11, 1, 9, 0, 0, 0, time.UTC)},
+		{"NextYear", "30 6 1 1 *", time.Date(2024, 10, 10, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 6, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := usecases.ParseCronSchedule(tt.expression)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.next, schedule.Next(tt.after))
+		})
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, expression := range []string{"0 18 * *", "60 * * * *", "* 5-2 * * *", "*/0 * * * *", "a * * * *"} {
+			_, err := usecases.ParseCronSchedule(expression)
+
+			assert.Error(t, err, expression)
+		}
+	})
+}
+
+// ignoreEvalScript matches an EVAL by its keys and arguments, leaving out the
+// body of the script.
+func ignoreEvalScript(expected, actual []interface{}) error {
+	if !reflect.DeepEqual(expected[2:], actual[2:]) {
+		return fmt.Errorf("expected eval %v, got %v", expected[2:], actual[2:])
+	}
+	return nil
+}
+
+func TestDeliveryRepository(t *testing.T) {
+	deliveryKeys := []string{"deliveries:records:d-1", "deliveries:schedule", "deliveries:orders"}
+	delivery := domain.Delivery{ID: "d-1", Email: "a@example.com", DeliveryDate: "2024-10-11", SweepStatus: domain.DeliverySweepPending}
+	data, _ := json.Marshal(delivery)
+
+	t.Run("Save", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.CustomMatch(ignoreEvalScript).ExpectEval("", deliveryKeys, string(data), float64(20241011), "d-1", "").SetVal(int64(1))
+
+		assert.NoError(t, repo.SaveDelivery(context.Background(), delivery))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectZRangeByScore("deliveries:schedule", &redis.ZRangeBy{Min: "20241011", Max: "20241017"}).SetVal([]string{"d-1", "d-2"})
+		mock.ExpectMGet("deliveries:records:d-1", "deliveries:records:d-2").SetVal([]interface{}{string(data), nil})
+
+		deliveries, err := repo.ListDeliveries(context.Background(), "2024-10-11", "2024-10-17")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Delivery{delivery}, deliveries)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SaveReservesOrder", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		order := delivery
+		order.OrderID = "o-1"
+		orderData, _ := json.Marshal(order)
+
+		mock.CustomMatch(ignoreEvalScript).ExpectEval("", deliveryKeys, string(orderData), float64(20241011), "d-1", "o-1").SetVal(int64(1))
+
+		assert.NoError(t, repo.SaveDelivery(context.Background(), order))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SaveOrderTaken", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		order := delivery
+		order.OrderID = "o-1"
+		orderData, _ := json.Marshal(order)
+
+		mock.CustomMatch(ignoreEvalScript).ExpectEval("", deliveryKeys, string(orderData), float64(20241011), "d-1", "o-1").SetVal(int64(0))
+
+		err := repo.SaveDelivery(context.Background(), order)
+
+		assert.IsType(t, &domain.ConflictError{}, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SaveFails", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		order := delivery
+		order.OrderID = "o-1"
+		orderData, _ := json.Marshal(order)
+
+		mock.CustomMatch(ignoreEvalScript).ExpectEval("", deliveryKeys, string(orderData), float64(20241011), "d-1", "o-1").SetErr(errors.New("connection reset"))
+
+		err := repo.SaveDelivery(context.Background(), order)
+
+		assert.EqualError(t, err, "error while saving Delivery in Redis: connection reset")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByOrder", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHGet("deliveries:orders", "o-1").SetVal("d-1")
+		mock.ExpectGet("deliveries:records:d-1").SetVal(string(data))
+
+		result, err := repo.GetDeliveryByOrder(context.Background(), "o-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &delivery, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByOrderNotFound", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHGet("deliveries:orders", "o-9").RedisNil()
+
+		_, err := repo.GetDeliveryByOrder(context.Background(), "o-9")
+
+		assert.IsType(t, &domain.NotFoundError{}, err)
+	})
+
+	t.Run("Notifications", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		notification := domain.Notification{ID: "n-1", DeliveryID: "d-1", Email: "a@example.com"}
+		notificationData, _ := json.Marshal(notification)
+		mock.ExpectLRange("deliveries:notifications:d-1", 0, -1).SetVal([]string{"n-1"})
+		mock.ExpectMGet("notification:records:n-1").SetVal([]interface{}{string(notificationData)})
+
+		notifications, err := repo.GetDeliveryNotifications(context.Background(), "d-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Notification{notification}, notifications)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SaveNotificationIndexesDelivery", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		notification := domain.Notification{ID: "n-1", DeliveryID: "d-1", Email: "a@example.com"}
+		notificationData, _ := json.Marshal(notification)
+		mock.ExpectTxPipeline()
+		mock.ExpectSet("notification:records:n-1", notificationData, 0).SetVal("OK")
+		mock.ExpectRPush("notifications:a@example.com", "n-1").SetVal(1)
+		mock.ExpectRPush("deliveries:notifications:d-1", "n-1").SetVal(1)
+		mock.ExpectTxPipelineExec()
+
+		assert.NoError(t, repo.SaveNotification(context.Background(), notification))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AcquireLock", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectSetNX("locks:sweep", "owner-1", time.Hour).SetVal(false)
+
+		acquired, err := repo.AcquireLock(context.Background(), "sweep", "owner-1", time.Hour)
+
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func newDeliveryRouter(repo domain.DeliveryRepository, cfg server.Config) *mux.Router {
+	deliveryHandler := infrastructure.NewDeliveryHandler(repo, cfg)
+	router := mux.NewRouter()
+	router.HandleFunc("/deliveries", deliveryHandler.RegisterDelivery).Methods(http.MethodPost)
+	router.HandleFunc("/deliveries", deliveryHandler.GetDeliverySchedule).Methods(http.MethodGet)
+	router.HandleFunc("/deliveries/{id}", deliveryHandler.GetDelivery).Methods(http.MethodGet)
+	router.HandleFunc("/deliveries/{id}", deliveryHandler.UpdateDelivery).Methods(http.MethodPatch)
+	router.HandleFunc("/orders/{order_id}", deliveryHandler.GetOrderDelivery).Methods(http.MethodGet)
+	router.HandleFunc("/orders/{order_id}/notifications", deliveryHandler.OrderNotifications).Methods(http.MethodGet)
+	return router
+}
+
+func TestDeliveryHandler(t *testing.T) {
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+
+	t.Run("Register", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockRepo.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.Delivery) error {
+			assert.NotEmpty(t, delivery.ID)
+			assert.Equal(t, "o-1", delivery.OrderID)
+			assert.Equal(t, "a@example.com", delivery.Email)
+			assert.Equal(t, "Av. Paulista 1000", delivery.Address)
+			assert.Equal(t, "loggi", delivery.Carrier)
+			assert.Equal(t, domain.DeliveryScheduled, delivery.Status)
+			assert.Equal(t, tomorrow, delivery.DeliveryDate)
+			assert.Equal(t, domain.DeliverySweepPending, delivery.SweepStatus)
+			return nil
+		})
+
+		body := `{"order_id": "o-1", "email": "a@example.com", "address": "Av. Paulista 1000", "carrier": "loggi", "location": {"latitude": "-23.55", "longitude": "-46.63"}}`
+		w := httptest.NewRecorder()
+		newDeliveryRouter(mockRepo, server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/deliveries", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"sweep_status":"pending"`)
+		assert.Contains(t, w.Body.String(), `"status":"scheduled"`)
+	})
+
+	t.Run("OrderAlreadyRegistered", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockRepo.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).Return(&domain.ConflictError{Message: "Delivery of order o-1 already registered"})
+
+		body := `{"order_id": "o-1", "email": "a@example.com", "location": {"latitude": "1", "longitude": "2"}}`
+		w := httptest.NewRecorder()
+		newDeliveryRouter(mockRepo, server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/deliveries", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "Delivery of order o-1 already registered")
+	})
+
+	t.Run("InvalidDelivery", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			body    string
+			message string
+		}{
+			{"InvalidEmail", `{"order_id": "o-1", "email": "a", "location": {"latitude": "1", "longitude": "2"}}`, "Invalid email"},
+			{"MissingOrder", `{"email": "a@example.com", "location": {"latitude": "1", "longitude": "2"}}`, "Invalid order_id: is required"},
+			{"PastDate", `{"order_id": "o-1", "email": "a@example.com", "location": {"latitude": "1", "longitude": "2"}, "delivery_date": "2020-01-01"}`, "Invalid delivery_date: must not be in the past"},
+			{"MissingLocation", `{"order_id": "o-1", "email": "a@example.com"}`, "Invalid location: latitude and longitude or an address are required"},
+			{"UnknownField", `{"email": "a@example.com", "order": "1"}`, "Invalid JSON data"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				w := httptest.NewRecorder()
+				newDeliveryRouter(mocks.NewMockDeliveryRepository(ctrl), server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/deliveries", bytes.NewBufferString(tt.body)))
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), tt.message)
+			})
+		}
+	})
+
+	t.Run("Schedule", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockRepo.EXPECT().ListDeliveries(gomock.Any(), "2024-10-11", "2024-10-17").Return([]domain.Delivery{
+			{ID: "d-1", Email: "a@example.com", DeliveryDate: "2024-10-11", SweepStatus: domain.DeliverySweepEvaluated, NotificationID: "n-1"},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		cfg := server.Config{SchedulerConfig: server.SchedulerConfig{Cron: "0 18 * * *", Timezone: "America/Sao_Paulo"}}
+		newDeliveryRouter(mockRepo, cfg).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/deliveries?from=2024-10-11", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response usecases.DeliveryScheduleResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "2024-10-17", response.To)
+		assert.Equal(t, "n-1", response.Deliveries[0].NotificationID)
+		assert.NotNil(t, response.NextSweepAt)
+	})
+
+	t.Run("InvalidRange", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		w := httptest.NewRecorder()
+		newDeliveryRouter(mocks.NewMockDeliveryRepository(ctrl), server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/deliveries?from=2024-10-11&to=2024-10-01", nil))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid to: must not be before from")
+	})
+}
+
+func TestSweepDeliveries(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	pending := domain.Delivery{ID: "d-1", Email: "a@example.com", Location: &domain.DeliveryLocation{Latitude: 1, Longitude: 2}, DeliveryDate: tomorrow, SweepStatus: domain.DeliverySweepPending}
+	evaluated := domain.Delivery{ID: "d-2", Email: "b@example.com", Location: &domain.DeliveryLocation{Latitude: 1, Longitude: 2}, DeliveryDate: tomorrow, SweepStatus: domain.DeliverySweepEvaluated}
+	cancelled := domain.Delivery{ID: "d-3", Email: "c@example.com", Location: &domain.DeliveryLocation{Latitude: 1, Longitude: 2}, DeliveryDate: tomorrow, Status: domain.DeliveryCancelled, SweepStatus: domain.DeliverySweepPending}
+
+	t.Run("NotifiesPendingDeliveries", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeliveries := mocks.NewMockDeliveryRepository(ctrl)
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		mockDeliveries.EXPECT().ListDeliveries(gomock.Any(), tomorrow, tomorrow).Return([]domain.Delivery{pending, evaluated, cancelled}, nil)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1063, Description: "Lluvia"}, nil)
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(nil, nil)
+		var notificationID string
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+			assert.Equal(t, "d-1", notification.DeliveryID)
+			notificationID = notification.ID
+			return nil
+		})
+		reloaded := pending
+		mockDeliveries.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(&reloaded, nil)
+		var saved domain.Delivery
+		mockDeliveries.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.Delivery) error {
+			saved = delivery
+			return nil
+		})
+
+		result, err := usecases.SweepDeliveries(context.Background(), tomorrow, tomorrow, 2, mockDeliveries, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Total)
+		assert.Equal(t, 1, result.Sent)
+		assert.Equal(t, "d-1", saved.ID)
+		assert.Equal(t, domain.DeliverySweepEvaluated, saved.SweepStatus)
+		assert.Equal(t, notificationID, saved.NotificationID)
+		assert.NotNil(t, saved.SweptAt)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeliveries := mocks.NewMockDeliveryRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		mockDeliveries.EXPECT().ListDeliveries(gomock.Any(), tomorrow, tomorrow).Return([]domain.Delivery{pending}, nil)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(nil, errors.New("forecast unavailable"))
+		reloaded := pending
+		mockDeliveries.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(&reloaded, nil)
+		mockDeliveries.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.Delivery) error {
+			assert.Equal(t, domain.DeliverySweepFailed, delivery.SweepStatus)
+			assert.Equal(t, "forecast unavailable", delivery.SweepError)
+			return nil
+		})
+
+		result, err := usecases.SweepDeliveries(context.Background(), tomorrow, tomorrow, 1, mockDeliveries, mockForecastService, mocks.NewMockNotificationRepository(ctrl), newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Failed)
+	})
+
+	t.Run("KeepsChangesMadeDuringTheSweep", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeliveries := mocks.NewMockDeliveryRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		delivered := pending
+		delivered.Status = domain.DeliveryDelivered
+		mockDeliveries.EXPECT().ListDeliveries(gomock.Any(), tomorrow, tomorrow).Return([]domain.Delivery{pending}, nil)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(nil, errors.New("forecast unavailable"))
+		mockDeliveries.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(&delivered, nil)
+		mockDeliveries.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.Delivery) error {
+			assert.Equal(t, domain.DeliveryDelivered, delivery.Status)
+			assert.Equal(t, domain.DeliverySweepFailed, delivery.SweepStatus)
+			return nil
+		})
+
+		_, err := usecases.SweepDeliveries(context.Background(), tomorrow, tomorrow, 1, mockDeliveries, mockForecastService, mocks.NewMockNotificationRepository(ctrl), newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("RescheduledDuringTheSweep", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeliveries := mocks.NewMockDeliveryRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		rescheduled := pending
+		rescheduled.DeliveryDate = time.Now().AddDate(0, 0, 2).Format(usecases.DeliveryDateLayout)
+		mockDeliveries.EXPECT().ListDeliveries(gomock.Any(), tomorrow, tomorrow).Return([]domain.Delivery{pending}, nil)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(nil, errors.New("forecast unavailable"))
+		mockDeliveries.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(&rescheduled, nil)
+
+		_, err := usecases.SweepDeliveries(context.Background(), tomorrow, tomorrow, 1, mockDeliveries, mockForecastService, mocks.NewMockNotificationRepository(ctrl), newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("SavesGeocodedLocation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeliveries := mocks.NewMockDeliveryRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		mockGeocoder := mocks.NewMockGeocoder(ctrl)
+
+		byAddress := domain.Delivery{ID: "d-4", Email: "d@example.com", Address: "Av. Paulista 1000", DeliveryDate: tomorrow, SweepStatus: domain.DeliverySweepPending}
+		mockDeliveries.EXPECT().ListDeliveries(gomock.Any(), tomorrow, tomorrow).Return([]domain.Delivery{byAddress}, nil)
+		mockGeocoder.EXPECT().Geocode("Av. Paulista 1000").Return(&third_party.GeocodeResult{Latitude: "-23.5614", Longitude: "-46.6559"}, nil)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: -23.5614, Longitude: -46.6559}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1000, Description: "Soleado"}, nil)
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+		reloaded := byAddress
+		mockDeliveries.EXPECT().GetDelivery(gomock.Any(), "d-4").Return(&reloaded, nil)
+		mockDeliveries.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.Delivery) error {
+			assert.Equal(t, &domain.DeliveryLocation{Latitude: -23.5614, Longitude: -46.6559}, delivery.Location)
+			return nil
+		})
+
+		_, err := usecases.SweepDeliveries(context.Background(), tomorrow, tomorrow, 1, mockDeliveries, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{Geocoder: mockGeocoder})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("RetriesFailedDeliveries", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeliveries := mocks.NewMockDeliveryRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		later := time.Now().AddDate(0, 0, 2).Format(usecases.DeliveryDateLayout)
+		failed := pending
+		failed.SweepStatus = domain.DeliverySweepFailed
+		failed.SweepError = "forecast unavailable"
+		mockDeliveries.EXPECT().ListDeliveries(gomock.Any(), tomorrow, later).Return([]domain.Delivery{failed, evaluated}, nil)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1000, Description: "Soleado"}, nil)
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+		mockDeliveries.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(&failed, nil)
+		mockDeliveries.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.Delivery) error {
+			assert.Equal(t, domain.DeliverySweepEvaluated, delivery.SweepStatus)
+			assert.Empty(t, delivery.SweepError)
+			return nil
+		})
+
+		result, err := usecases.SweepDeliveries(context.Background(), tomorrow, later, 1, mockDeliveries, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.Total)
+	})
+}
+
+func TestRunScheduledSweep(t *testing.T) {
+	scheduledAt := time.Date(2024, 10, 10, 18, 0, 0, 0, time.UTC)
+	policy := usecases.SweepPolicy{DaysAhead: 1, Concurrency: 1, LockTTL: time.Hour}
+
+	t.Run("LockTaken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeliveries := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockDeliveries.EXPECT().AcquireLock(gomock.Any(), "deliveries:sweep:1728583200", gomock.Any(), time.Hour).Return(false, nil)
+
+		err := usecases.RunScheduledSweep(context.Background(), scheduledAt, policy, mockDeliveries, mocks.NewMockForecastService(ctrl), mocks.NewMockNotificationRepository(ctrl), newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("SweepsDaysAhead", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeliveries := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockDeliveries.EXPECT().AcquireLock(gomock.Any(), "deliveries:sweep:1728583200", gomock.Any(), time.Hour).Return(true, nil)
+		mockDeliveries.EXPECT().ListDeliveries(gomock.Any(), "2024-10-11", "2024-10-11").Return(nil, nil)
+
+		err := usecases.RunScheduledSweep(context.Background(), scheduledAt, policy, mockDeliveries, mocks.NewMockForecastService(ctrl), mocks.NewMockNotificationRepository(ctrl), newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("RetriesFailedBeforeDaysAhead", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockDeliveries := mocks.NewMockDeliveryRepository(ctrl)
+
+		threeDaysAhead := policy
+		threeDaysAhead.DaysAhead = 3
+		mockDeliveries.EXPECT().AcquireLock(gomock.Any(), "deliveries:sweep:1728583200", gomock.Any(), time.Hour).Return(true, nil)
+		mockDeliveries.EXPECT().ListDeliveries(gomock.Any(), "2024-10-11", "2024-10-13").Return(nil, nil)
+
+		err := usecases.RunScheduledSweep(context.Background(), scheduledAt, threeDaysAhead, mockDeliveries, mocks.NewMockForecastService(ctrl), mocks.NewMockNotificationRepository(ctrl), newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestDeliveryLifecycle(t *testing.T) {
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	scheduled := func() *domain.Delivery {
+		return &domain.Delivery{ID: "d-1", OrderID: "o-1", Email: "a@example.com", DeliveryDate: "2024-10-11", Status: domain.DeliveryScheduled, SweepStatus: domain.DeliverySweepEvaluated, NotificationID: "n-1"}
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockRepo.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(scheduled(), nil)
+
+		w := httptest.NewRecorder()
+		newDeliveryRouter(mockRepo, server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/deliveries/d-1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"order_id":"o-1"`)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockRepo.EXPECT().GetDelivery(gomock.Any(), "d-9").Return(nil, &domain.NotFoundError{Message: "Delivery d-9 not found"})
+
+		w := httptest.NewRecorder()
+		newDeliveryRouter(mockRepo, server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/deliveries/d-9", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockRepo.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(scheduled(), nil)
+		mockRepo.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.Delivery) error {
+			assert.Equal(t, domain.DeliveryInTransit, delivery.Status)
+			assert.Equal(t, "correios", delivery.Carrier)
+			return nil
+		})
+
+		w := httptest.NewRecorder()
+		body := `{"status": "in_transit", "carrier": "correios"}`
+		newDeliveryRouter(mockRepo, server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/deliveries/d-1", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"status":"in_transit"`)
+	})
+
+	t.Run("Reschedule", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockRepo.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(scheduled(), nil)
+		mockRepo.EXPECT().SaveDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.Delivery) error {
+			assert.Equal(t, tomorrow, delivery.DeliveryDate)
+			assert.Equal(t, domain.DeliverySweepPending, delivery.SweepStatus)
+			return nil
+		})
+
+		_, err := usecases.UpdateDelivery("d-1", usecases.RequestUpdateDelivery{DeliveryDate: tomorrow}, mockRepo)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("InvalidTransition", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		delivered := scheduled()
+		delivered.Status = domain.DeliveryDelivered
+		mockRepo.EXPECT().GetDelivery(gomock.Any(), "d-1").Return(delivered, nil)
+
+		w := httptest.NewRecorder()
+		body := `{"status": "in_transit"}`
+		newDeliveryRouter(mockRepo, server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/deliveries/d-1", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `Invalid status: cannot change from delivered to \"in_transit\"`)
+	})
+
+	t.Run("Order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockRepo.EXPECT().GetDeliveryByOrder(gomock.Any(), "o-1").Return(scheduled(), nil)
+
+		w := httptest.NewRecorder()
+		newDeliveryRouter(mockRepo, server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/o-1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"delivery_id":"d-1"`)
+	})
+
+	t.Run("OrderNotifications", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockRepo.EXPECT().GetDeliveryByOrder(gomock.Any(), "o-1").Return(scheduled(), nil)
+		mockRepo.EXPECT().GetDeliveryNotifications(gomock.Any(), "d-1").Return([]domain.Notification{
+			{ID: "n-1", DeliveryID: "d-1", Email: "a@example.com", DeliveryDate: "2024-10-11", DeliveryStatus: domain.DeliveryStatusSent},
+		}, nil)
+
+		w := httptest.NewRecorder()
+		newDeliveryRouter(mockRepo, server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/o-1/notifications", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response usecases.OrderNotificationsResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "d-1", response.DeliveryID)
+		assert.Equal(t, "n-1", response.History[0].NotificationID)
+		assert.Equal(t, "d-1", response.History[0].DeliveryID)
+	})
+
+	t.Run("OrderNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		mockRepo.EXPECT().GetDeliveryByOrder(gomock.Any(), "o-9").Return(nil, &domain.NotFoundError{Message: "Delivery of order o-9 not found"})
+
+		w := httptest.NewRecorder()
+		newDeliveryRouter(mockRepo, server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/o-9/notifications", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "Delivery of order o-9 not found")
+	})
+}
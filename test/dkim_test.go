@@ -0,0 +1,252 @@
+package service_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"bou.ke/monkey"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/sender"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	whitespace   = regexp.MustCompile(`[ \t]+`)
+	signatureTag = regexp.MustCompile(`(^|;)(\s*)b=[^;]*`)
+)
+
+// verifyDKIM checks the DKIM-Signature header at the top of message against
+// publicKey, following the relaxed/relaxed rules of RFC 6376.
+func verifyDKIM(message []byte, publicKey *rsa.PublicKey) error {
+	headerBlock, body, found := strings.Cut(string(message), "\r\n\r\n")
+	if !found {
+		return errors.New("no header separator")
+	}
+
+	var fields []string
+	for _, line := range strings.Split(headerBlock, "\r\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			fields[len(fields)-1] += "\r\n" + line
+			continue
+		}
+		fields = append(fields, line)
+	}
+	if !strings.HasPrefix(fields[0], "DKIM-Signature:") {
+		return errors.New("no DKIM-Signature header")
+	}
+
+	canonicalHeader := func(field string) string {
+		name, value, _ := strings.Cut(field, ":")
+		value = whitespace.ReplaceAllString(strings.ReplaceAll(value, "\r\n", ""), " ")
+		return strings.ToLower(strings.TrimSpace(name)) + ":" + strings.TrimSpace(value)
+	}
+
+	tags := map[string]string{}
+	_, signatureValue, _ := strings.Cut(fields[0], ":")
+	for _, tag := range strings.Split(signatureValue, ";") {
+		name, value, _ := strings.Cut(tag, "=")
+		tags[strings.TrimSpace(name)] = whitespace.ReplaceAllString(strings.ReplaceAll(value, "\r\n", ""), "")
+	}
+
+	lines := strings.Split(body, "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(whitespace.ReplaceAllString(line, " "), " ")
+	}
+	canonicalBody := strings.TrimRight(strings.Join(lines, "\r\n"), "\r\n")
+	if canonicalBody != "" {
+		canonicalBody += "\r\n"
+	}
+	bodyHash := sha256.Sum256([]byte(canonicalBody))
+	if base64.StdEncoding.EncodeToString(bodyHash[:]) != tags["bh"] {
+		return errors.New("body hash mismatch")
+	}
+
+	hash := sha256.New()
+	for _, name := range strings.Split(tags["h"], ":") {
+		for i := len(fields) - 1; i > 0; i-- {
+			if fieldName, _, _ := strings.Cut(fields[i], ":"); strings.EqualFold(fieldName, name) {
+				hash.Write([]byte(canonicalHeader(fields[i]) + "\r\n"))
+				break
+			}
+		}
+	}
+	hash.Write([]byte(canonicalHeader(signatureTag.ReplaceAllString(fields[0], "${1}${2}b="))))
+
+	signature, err := base64.StdEncoding.DecodeString(tags["b"])
+	if err != nil {
+		return err
+	}
+	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash.Sum(nil), signature)
+}
+
+func newDKIMKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestDKIMSigner(t *testing.T) {
+	key := newDKIMKey(t)
+	signer := sender.NewDKIMSigner("example.com", "notifier", key)
+	message, err := sender.BuildMIMEMessage(sender.Mail{
+		From:      mail.Address{Name: "Alertas", Address: "alertas@example.com"},
+		To:        mail.Address{Address: "buyer@example.com"},
+		Subject:   "Entrega retrasada por clima ☔",
+		Text:      "Hola! Lluvia mañana.",
+		HTML:      "<p>Hola! Lluvia mañana.</p>",
+		MessageID: "<n-1@example.com>",
+		Date:      time.Now(),
+	})
+	assert.NoError(t, err)
+
+	t.Run("SignsMessage", func(t *testing.T) {
+		signed, err := signer.Sign(message)
+
+		assert.NoError(t, err)
+		assert.NoError(t, verifyDKIM(signed, &key.PublicKey))
+		assert.True(t, bytes.HasSuffix(signed, message))
+
+		parsed, err := mail.ReadMessage(bytes.NewReader(signed))
+		assert.NoError(t, err)
+		signature := parsed.Header.Get("DKIM-Signature")
+		assert.Contains(t, signature, "a=rsa-sha256; c=relaxed/relaxed; d=example.com; s=notifier;")
+		assert.Contains(t, signature, "h=from:to:subject:date:message-id:mime-version:content-type;")
+	})
+
+	t.Run("TamperedBody", func(t *testing.T) {
+		signed, err := signer.Sign(message)
+		assert.NoError(t, err)
+
+		tampered := bytes.Replace(signed, []byte("Lluvia"), []byte("Sol"), 1)
+
+		assert.EqualError(t, verifyDKIM(tampered, &key.PublicKey), "body hash mismatch")
+	})
+
+	t.Run("TamperedHeader", func(t *testing.T) {
+		signed, err := signer.Sign(message)
+		assert.NoError(t, err)
+
+		tampered := bytes.Replace(signed, []byte("To: <buyer@example.com>"), []byte("To: <other@example.com>"), 1)
+
+		assert.ErrorIs(t, verifyDKIM(tampered, &key.PublicKey), rsa.ErrVerification)
+	})
+
+	t.Run("RelaxedCanonicalization", func(t *testing.T) {
+		plain := []byte("From: <alertas@example.com>\r\nSubject:  Aviso\r\n\r\nHola   mundo\r\n\r\n\r\n")
+		signed, err := signer.Sign(plain)
+		assert.NoError(t, err)
+
+		relayed := bytes.Replace(signed, []byte("Subject:  Aviso"), []byte("subject: Aviso "), 1)
+		relayed = bytes.Replace(relayed, []byte("Hola   mundo\r\n\r\n\r\n"), []byte("Hola mundo \r\n"), 1)
+
+		assert.NoError(t, verifyDKIM(relayed, &key.PublicKey))
+	})
+
+	t.Run("EmptyBody", func(t *testing.T) {
+		signed, err := signer.Sign([]byte("From: <alertas@example.com>\r\n\r\n"))
+
+		assert.NoError(t, err)
+		assert.Contains(t, string(signed), "bh=47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=;")
+		assert.NoError(t, verifyDKIM(signed, &key.PublicKey))
+	})
+
+	t.Run("MissingFrom", func(t *testing.T) {
+		_, err := signer.Sign([]byte("Subject: Aviso\r\n\r\nHola\r\n"))
+
+		assert.EqualError(t, err, "error signing message: the From header is required")
+	})
+}
+
+func TestLoadDKIMSigner(t *testing.T) {
+	key := newDKIMKey(t)
+	dir := t.TempDir()
+	writeKey := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+		return path
+	}
+
+	t.Run("PKCS1", func(t *testing.T) {
+		path := writeKey("pkcs1.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+
+		signer, err := sender.LoadDKIMSigner("example.com", "notifier", path)
+
+		assert.NoError(t, err)
+		signed, err := signer.Sign([]byte("From: <alertas@example.com>\r\n\r\nHola\r\n"))
+		assert.NoError(t, err)
+		assert.NoError(t, verifyDKIM(signed, &key.PublicKey))
+	})
+
+	t.Run("PKCS8", func(t *testing.T) {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		assert.NoError(t, err)
+		path := writeKey("pkcs8.pem", "PRIVATE KEY", der)
+
+		_, err = sender.LoadDKIMSigner("example.com", "notifier", path)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("NotRSA", func(t *testing.T) {
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(ecKey)
+		assert.NoError(t, err)
+		path := writeKey("ec.pem", "PRIVATE KEY", der)
+
+		_, err = sender.LoadDKIMSigner("example.com", "notifier", path)
+
+		assert.EqualError(t, err, "error parsing dkim private key: only RSA keys are supported")
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		_, err := sender.LoadDKIMSigner("example.com", "notifier", filepath.Join(dir, "missing.pem"))
+
+		assert.ErrorContains(t, err, "error reading dkim private key")
+	})
+
+	t.Run("MissingSelector", func(t *testing.T) {
+		_, err := sender.LoadDKIMSigner("example.com", "", filepath.Join(dir, "pkcs1.pem"))
+
+		assert.EqualError(t, err, "dkim domain and selector are required")
+	})
+}
+
+func TestSendEmailWithDKIM(t *testing.T) {
+	key := newDKIMKey(t)
+	smtpClient := sender.NewSmtpClient(server.SMTPConfig{Username: "testuser", From: "alertas@example.com"})
+	smtpClient.DKIMSigner = sender.NewDKIMSigner("example.com", "notifier", key)
+
+	var sentMessage []byte
+	monkey.PatchInstanceMethod(reflect.TypeOf(&sender.SMTPPool{}), "Send", func(pool *sender.SMTPPool, from string, to []string, msg []byte) error {
+		sentMessage = msg
+		return nil
+	})
+	defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&sender.SMTPPool{}), "Send")
+
+	err := smtpClient.Send(domain.Message{Recipient: "buyer@example.com", Subject: "Aviso", Text: "Lluvia mañana.", HTML: "<p>Lluvia mañana.</p>"})
+
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(sentMessage, []byte("DKIM-Signature: ")))
+	assert.NoError(t, verifyDKIM(sentMessage, &key.PublicKey))
+}
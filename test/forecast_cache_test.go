@@ -0,0 +1,175 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/golang/mock/gomock"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryForecastCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		cache := third_party.NewMemoryForecastCache(2)
+
+		cache.Set(ctx, "a", third_party.ForecastServiceResponse{Code: 1}, time.Minute)
+		cache.Set(ctx, "b", third_party.ForecastServiceResponse{Code: 2}, time.Minute)
+		cache.Get(ctx, "a")
+		cache.Set(ctx, "c", third_party.ForecastServiceResponse{Code: 3}, time.Minute)
+
+		_, found, _ := cache.Get(ctx, "b")
+		assert.False(t, found)
+		value, found, _ := cache.Get(ctx, "a")
+		assert.True(t, found)
+		assert.Equal(t, float64(1), value.Code)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		cache := third_party.NewMemoryForecastCache(2)
+
+		cache.Set(ctx, "a", third_party.ForecastServiceResponse{Code: 1}, -time.Second)
+
+		value, found, err := cache.Get(ctx, "a")
+		assert.NoError(t, err)
+		assert.False(t, found)
+		assert.Nil(t, value)
+	})
+}
+
+func TestRedisForecastCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Set", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		cache := third_party.NewRedisForecastCache(redisMock)
+		value := third_party.ForecastServiceResponse{Code: 1063, Description: "Lluvia"}
+		data, _ := json.Marshal(value)
+
+		mock.ExpectSet("forecast:key", data, time.Minute).SetVal("OK")
+
+		err := cache.Set(ctx, "forecast:key", value, time.Minute)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Miss", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		cache := third_party.NewRedisForecastCache(redisMock)
+
+		mock.ExpectGet("forecast:key").RedisNil()
+
+		value, found, err := cache.Get(ctx, "forecast:key")
+
+		assert.NoError(t, err)
+		assert.False(t, found)
+		assert.Nil(t, value)
+	})
+
+	t.Run("Hit", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		cache := third_party.NewRedisForecastCache(redisMock)
+
+		mock.ExpectGet("forecast:key").SetVal(`{"code":1063,"description":"Lluvia"}`)
+
+		value, found, err := cache.Get(ctx, "forecast:key")
+
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, &third_party.ForecastServiceResponse{Code: 1063, Description: "Lluvia"}, value)
+	})
+}
+
+func TestCachedForecastService(t *testing.T) {
+	forecast := &third_party.ForecastServiceResponse{Code: 1063, Description: "Lluvia"}
+
+	t.Run("SharesNearbyLocations", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		service := third_party.NewCachedForecastService(mockForecastService, third_party.WeatherAPIProvider, third_party.NewMemoryForecastCache(10), time.Minute, 2)
+
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.0060}, "2024-10-11").Return(forecast, nil).Times(1)
+
+		first, err := service.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.0060}, "2024-10-11")
+		assert.NoError(t, err)
+		second, err := service.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 40.7131, Longitude: -74.0061}, "2024-10-11")
+		assert.NoError(t, err)
+
+		assert.Equal(t, forecast, first)
+		assert.Equal(t, forecast, second)
+		assert.Equal(t, third_party.CacheStats{Hits: 1, Misses: 1}, service.Stats())
+	})
+
+	t.Run("Bypass", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		service := third_party.NewCachedForecastService(mockForecastService, third_party.WeatherAPIProvider, third_party.NewMemoryForecastCache(10), time.Minute, 2)
+
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.0060}, "2024-10-11").Return(forecast, nil).Times(2)
+
+		service.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.0060}, "2024-10-11")
+		result, err := service.Bypass().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.0060}, "2024-10-11")
+
+		assert.NoError(t, err)
+		assert.Equal(t, forecast, result)
+		assert.Equal(t, third_party.CacheStats{Misses: 1, Bypasses: 1}, service.Stats())
+	})
+
+	t.Run("KeyIncludesProvider", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		redisMock, mock := redismock.NewClientMock()
+		service := third_party.NewCachedForecastService(mockForecastService, third_party.OpenMeteoProvider, third_party.NewRedisForecastCache(redisMock), time.Minute, 2)
+
+		mock.ExpectGet("forecast:openmeteo:40.71:-74.01:2024-10-11").SetVal(`{"code":1063,"description":"Lluvia"}`)
+
+		result, err := service.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.0060}, "2024-10-11")
+
+		assert.NoError(t, err)
+		assert.Equal(t, forecast, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestForecastCacheStats(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		service := third_party.NewCachedForecastService(mocks.NewMockForecastService(ctrl), third_party.WeatherAPIProvider, third_party.NewMemoryForecastCache(10), time.Minute, 2)
+		handler := infrastructure.NewNotificationHandler(nil, nil, service, server.Config{})
+
+		req := httptest.NewRequest(http.MethodGet, "/forecast/cache/stats", nil)
+		w := httptest.NewRecorder()
+
+		handler.ForecastCacheStats(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"hits":0,"misses":0,"bypasses":0}`, w.Body.String())
+	})
+
+	t.Run("CacheDisabled", func(t *testing.T) {
+		handler := infrastructure.NewNotificationHandler(nil, nil, nil, server.Config{})
+
+		req := httptest.NewRequest(http.MethodGet, "/forecast/cache/stats", nil)
+		w := httptest.NewRecorder()
+
+		handler.ForecastCacheStats(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
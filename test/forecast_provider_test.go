@@ -0,0 +1,197 @@
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewForecastService(t *testing.T) {
+	t.Run("DefaultProvider", func(t *testing.T) {
+		service, err := third_party.NewForecastService(&server.Config{})
+
+		assert.NoError(t, err)
+		assert.IsType(t, &third_party.ForecastService{}, service)
+	})
+
+	t.Run("OpenMeteoProvider", func(t *testing.T) {
+		cfg := &server.Config{
+			ForecastServiceConfig: server.ForecastServiceConfig{Provider: "OpenMeteo"},
+		}
+
+		service, err := third_party.NewForecastService(cfg)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &third_party.OpenMeteoForecastService{BaseURL: third_party.OpenMeteoDefaultBaseURL}, service)
+		assert.Equal(t, third_party.OpenMeteoMaxForecastDays, service.MaxForecastDays())
+	})
+
+	t.Run("ConfiguredHorizon", func(t *testing.T) {
+		cfg := &server.Config{
+			ForecastServiceConfig: server.ForecastServiceConfig{MaxDays: 6},
+		}
+
+		service, err := third_party.NewForecastService(cfg)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 6, service.MaxForecastDays())
+	})
+
+	t.Run("UnknownProvider", func(t *testing.T) {
+		cfg := &server.Config{
+			ForecastServiceConfig: server.ForecastServiceConfig{Provider: "unknown"},
+		}
+
+		service, err := third_party.NewForecastService(cfg)
+
+		assert.Nil(t, service)
+		assert.EqualError(t, err, `forecast provider "unknown" is not registered`)
+	})
+
+	t.Run("RegisteredProvider", func(t *testing.T) {
+		expected := &third_party.ForecastService{BaseURL: "http://custom"}
+		third_party.RegisterForecastProvider("custom", func(cfg server.ForecastServiceConfig) (third_party.IForecastService, error) {
+			return expected, nil
+		})
+		cfg := &server.Config{
+			ForecastServiceConfig: server.ForecastServiceConfig{Provider: "custom"},
+		}
+
+		service, err := third_party.NewForecastService(cfg)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, service)
+	})
+}
+
+func TestOpenMeteoMapperToDTO(t *testing.T) {
+	t.Run("Successful mapping", func(t *testing.T) {
+		input := third_party.OpenMeteoForecastDocument{
+			Timezone: "America/Bogota",
+			Daily: &third_party.OpenMeteoDaily{
+				Time:             []string{"2024-10-10", "2024-10-11"},
+				WeatherCode:      []float64{0, 63},
+				TemperatureMax:   []float64{20, 18.5},
+				PrecipitationSum: []float64{0, 12.4},
+			},
+			Hourly: &third_party.OpenMeteoHourly{
+				Time:          []string{"2024-10-10T14:00", "2024-10-11T14:00"},
+				WeatherCode:   []float64{0, 65},
+				Precipitation: []float64{0, 4.2},
+			},
+		}
+		expected := &third_party.ForecastServiceResponse{
+			Code:          63,
+			Description:   "Moderate rain",
+			Date:          "2024-10-11",
+			Timezone:      "America/Bogota",
+			MaxTempC:      18.5,
+			TotalPrecipMm: 12.4,
+			Hours: []third_party.HourlyForecast{
+				{Time: "2024-10-11T14:00", Code: 65, Description: "Heavy rain", PrecipMm: 4.2},
+			},
+		}
+
+		response, err := third_party.OpenMeteoMapperToDTO(input, "2024-10-11")
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, response)
+	})
+
+	t.Run("MissingDailyKey", func(t *testing.T) {
+		response, err := third_party.OpenMeteoMapperToDTO(third_party.OpenMeteoForecastDocument{}, "2024-10-11")
+
+		assert.Nil(t, response)
+		assert.EqualError(t, err, "daily section is missing")
+	})
+
+	t.Run("MissingDate", func(t *testing.T) {
+		input := third_party.OpenMeteoForecastDocument{
+			Daily: &third_party.OpenMeteoDaily{
+				Time:        []string{"2024-10-10"},
+				WeatherCode: []float64{0},
+			},
+		}
+
+		response, err := third_party.OpenMeteoMapperToDTO(input, "2024-10-11")
+
+		assert.Nil(t, response)
+		assert.EqualError(t, err, "daily.time has 1 day(s), forecast for 2024-10-11 is missing")
+	})
+
+	t.Run("MisalignedSeries", func(t *testing.T) {
+		input := third_party.OpenMeteoForecastDocument{
+			Daily: &third_party.OpenMeteoDaily{
+				Time:           []string{"2024-10-10", "2024-10-11"},
+				WeatherCode:    []float64{0, 1},
+				TemperatureMax: []float64{20},
+			},
+		}
+
+		response, err := third_party.OpenMeteoMapperToDTO(input, "2024-10-11")
+
+		assert.Nil(t, response)
+		assert.EqualError(t, err, "daily.temperature_2m_max has 1 value(s), expected 2")
+	})
+
+	t.Run("UnknownCode", func(t *testing.T) {
+		input := third_party.OpenMeteoForecastDocument{
+			Daily: &third_party.OpenMeteoDaily{
+				Time:        []string{"2024-10-10", "2024-10-11"},
+				WeatherCode: []float64{0, 42},
+			},
+		}
+
+		response, err := third_party.OpenMeteoMapperToDTO(input, "2024-10-11")
+
+		assert.Nil(t, response)
+		assert.EqualError(t, err, "daily.weather_code[1] 42 is not a known WMO code")
+	})
+}
+
+func TestOpenMeteoFetchForecastByLocation(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/forecast", r.URL.Path)
+		assert.Equal(t, "4.61", r.URL.Query().Get("latitude"))
+		assert.Equal(t, "-74.08", r.URL.Query().Get("longitude"))
+		assert.Equal(t, "2024-10-11", r.URL.Query().Get("start_date"))
+		assert.Equal(t, "2024-10-11", r.URL.Query().Get("end_date"))
+		assert.Contains(t, r.URL.Query().Get("hourly"), "precipitation")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"daily": {"time": ["2024-10-10", "2024-10-11"], "weather_code": [1, 95]}}`))
+	}))
+	defer mockServer.Close()
+
+	service, err := third_party.NewOpenMeteoForecastService(server.ForecastServiceConfig{BaseURL: mockServer.URL})
+	assert.NoError(t, err)
+
+	result, err := service.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 4.61, Longitude: -74.08}, "2024-10-11")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &third_party.ForecastServiceResponse{Code: 95, Description: "Thunderstorm", Date: "2024-10-11"}, result)
+}
+
+func TestWeatherAPIFetchForecastByLocation(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/forecast.json", r.URL.Path)
+		assert.Equal(t, "4.61,-74.08", r.URL.Query().Get("q"))
+		assert.Equal(t, "2024-10-11", r.URL.Query().Get("dt"))
+		assert.False(t, r.URL.Query().Has("lang"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"forecast": {"forecastday": [{"date": "2024-10-11", "day": {"condition": {"code": 1063, "text": "Patchy rain possible"}}}]}}`))
+	}))
+	defer mockServer.Close()
+
+	service, err := third_party.NewWeatherAPIForecastService(server.ForecastServiceConfig{BaseURL: mockServer.URL})
+	assert.NoError(t, err)
+
+	result, err := service.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 4.61, Longitude: -74.08}, "2024-10-11")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &third_party.ForecastServiceResponse{Code: 1063, Description: "Patchy rain possible", Date: "2024-10-11"}, result)
+}
@@ -11,28 +11,39 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/juandr89/delivery-notifier-buyer/server"
 
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
 	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestMapperToDTO(t *testing.T) {
 	t.Run("Successful mapping", func(t *testing.T) {
-		input := map[string]interface{}{
-			"forecast": map[string]interface{}{
-				"forecastday": []interface{}{
-					map[string]interface{}{
-						"day": map[string]interface{}{
-							"condition": map[string]interface{}{
-								"code": 300.0,
-								"text": "Partly cloudy",
-							},
+		input := third_party.WeatherAPIForecastDocument{
+			Location: &third_party.WeatherAPILocation{TzID: "America/Bogota"},
+			Forecast: &third_party.WeatherAPIForecast{
+				ForecastDay: []third_party.WeatherAPIForecastDay{
+					{
+						Date: "2024-10-10",
+						Day: &third_party.WeatherAPIDay{
+							Condition: &third_party.WeatherAPICondition{Code: 300, Text: "Partly cloudy"},
 						},
 					},
-					map[string]interface{}{
-						"day": map[string]interface{}{
-							"condition": map[string]interface{}{
-								"code": 200.0,
-								"text": "Sunny",
+					{
+						Date: "2024-10-11",
+						Day: &third_party.WeatherAPIDay{
+							MaxTempC:          21.5,
+							MinTempC:          9.1,
+							MaxWindKph:        14.4,
+							TotalPrecipMm:     3.2,
+							DailyChanceOfRain: 80,
+							Condition:         &third_party.WeatherAPICondition{Code: 200, Text: "Sunny"},
+						},
+						Hour: []third_party.WeatherAPIHour{
+							{
+								Time:      "2024-10-11 14:00",
+								TempC:     20.3,
+								PrecipMm:  1.1,
+								Condition: &third_party.WeatherAPICondition{Code: 1063, Text: "Patchy rain possible"},
 							},
 						},
 					},
@@ -40,73 +51,95 @@ func TestMapperToDTO(t *testing.T) {
 			},
 		}
 		expectedResponse := &third_party.ForecastServiceResponse{
-			Code:        200.0,
-			Description: "Sunny",
+			Code:          200.0,
+			Description:   "Sunny",
+			Date:          "2024-10-11",
+			Timezone:      "America/Bogota",
+			MaxTempC:      21.5,
+			MinTempC:      9.1,
+			MaxWindKph:    14.4,
+			TotalPrecipMm: 3.2,
+			ChanceOfRain:  80,
+			Hours: []third_party.HourlyForecast{
+				{Time: "2024-10-11T14:00", Code: 1063, Description: "Patchy rain possible", TempC: 20.3, PrecipMm: 1.1},
+			},
 		}
 
-		response, err := third_party.MapperToDTO(input)
+		response, err := third_party.MapperToDTO(input, "2024-10-11")
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedResponse, response)
 	})
 	t.Run("MissingForecastKey", func(t *testing.T) {
-		input := map[string]interface{}{
-			"foo": "bar",
+		input := third_party.WeatherAPIForecastDocument{}
+		expectedError := "forecast section is missing"
+
+		response, err := third_party.MapperToDTO(input, "2024-10-11")
+
+		assert.Nil(t, response)
+		assert.EqualError(t, err, expectedError)
+	})
+
+	t.Run("SingleForecastDay", func(t *testing.T) {
+		input := third_party.WeatherAPIForecastDocument{
+			Forecast: &third_party.WeatherAPIForecast{
+				ForecastDay: []third_party.WeatherAPIForecastDay{
+					{Date: "2024-10-10", Day: &third_party.WeatherAPIDay{Condition: &third_party.WeatherAPICondition{Code: 1000, Text: "Sunny"}}},
+				},
+			},
 		}
-		expectedError := "forecast is not a nested JSON object"
+		expectedError := "forecast.forecastday has 1 day(s), forecast for 2024-10-11 is missing"
 
-		response, err := third_party.MapperToDTO(input)
+		response, err := third_party.MapperToDTO(input, "2024-10-11")
 
 		assert.Nil(t, response)
 		assert.EqualError(t, err, expectedError)
 	})
 
-	t.Run("MissingForecastdayKey", func(t *testing.T) {
-		input := map[string]interface{}{
-			"forecast": map[string]interface{}{
-				"foo": "bar",
+	t.Run("MissingDaySection", func(t *testing.T) {
+		input := third_party.WeatherAPIForecastDocument{
+			Forecast: &third_party.WeatherAPIForecast{
+				ForecastDay: []third_party.WeatherAPIForecastDay{{Date: "2024-10-10"}, {Date: "2024-10-11"}},
 			},
 		}
-		expectedError := "forecastday is not a nested JSON object"
+		expectedError := "forecast.forecastday[1].day section is missing"
 
-		response, err := third_party.MapperToDTO(input)
+		response, err := third_party.MapperToDTO(input, "2024-10-11")
 
 		assert.Nil(t, response)
 		assert.EqualError(t, err, expectedError)
 	})
 
-	t.Run("InvalidForecastdayDataStructure", func(t *testing.T) {
-		input := map[string]interface{}{
-			"forecast": map[string]interface{}{
-				"forecastday": "not-an-array",
+	t.Run("MissingConditionSection", func(t *testing.T) {
+		input := third_party.WeatherAPIForecastDocument{
+			Forecast: &third_party.WeatherAPIForecast{
+				ForecastDay: []third_party.WeatherAPIForecastDay{{Date: "2024-10-10"}, {Date: "2024-10-11", Day: &third_party.WeatherAPIDay{}}},
 			},
 		}
-		expectedError := "forecastday is not a nested JSON object"
+		expectedError := "forecast.forecastday[1].day.condition section is missing"
 
-		response, err := third_party.MapperToDTO(input)
+		response, err := third_party.MapperToDTO(input, "2024-10-11")
 
 		assert.Nil(t, response)
 		assert.EqualError(t, err, expectedError)
 	})
-	t.Run("InvalidDayAsSecondElement", func(t *testing.T) {
-		input := map[string]interface{}{
-			"forecast": map[string]interface{}{
-				"forecastday": []interface{}{
-					map[string]interface{}{
-						"day": map[string]interface{}{
-							"condition": map[string]interface{}{
-								"code": 200.0,
-								"text": "Sunny",
-							},
-						},
+
+	t.Run("MalformedHour", func(t *testing.T) {
+		input := third_party.WeatherAPIForecastDocument{
+			Forecast: &third_party.WeatherAPIForecast{
+				ForecastDay: []third_party.WeatherAPIForecastDay{
+					{Date: "2024-10-10"},
+					{
+						Date: "2024-10-11",
+						Day:  &third_party.WeatherAPIDay{Condition: &third_party.WeatherAPICondition{Code: 1000, Text: "Sunny"}},
+						Hour: []third_party.WeatherAPIHour{{Time: "2024-10-11 00:00"}},
 					},
-					"not-a-map",
 				},
 			},
 		}
-		expectedError := "day is not a nested JSON object"
+		expectedError := "forecast.forecastday[1].hour[0].condition section is missing"
 
-		response, err := third_party.MapperToDTO(input)
+		response, err := third_party.MapperToDTO(input, "2024-10-11")
 
 		assert.Nil(t, response)
 		assert.EqualError(t, err, expectedError)
@@ -125,7 +158,7 @@ func TestFetchForecastByLocation(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		mockResponse := &http.Response{
 			StatusCode: 200,
-			Body:       io.NopCloser(bytes.NewBufferString(`{"forecast": "test"}`)),
+			Body:       io.NopCloser(bytes.NewBufferString(`{"forecast": {"forecastday": []}}`)),
 		}
 
 		mockResponseService := &third_party.ForecastServiceResponse{}
@@ -134,12 +167,12 @@ func TestFetchForecastByLocation(t *testing.T) {
 		})
 		defer monkey.Unpatch(server.DoRequestWithRetry)
 
-		monkey.Patch(third_party.MapperToDTO, func(body map[string]interface{}) (*third_party.ForecastServiceResponse, error) {
+		monkey.Patch(third_party.MapperToDTO, func(document third_party.WeatherAPIForecastDocument, date string) (*third_party.ForecastServiceResponse, error) {
 			return mockResponseService, nil
 		})
 		defer monkey.Unpatch(server.DoRequestWithRetry)
 
-		result, err := forecastService.FetchForecastByLocation("123.456", "78.910", "3")
+		result, err := forecastService.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 78.910, Longitude: 123.456}, "2024-10-11")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
@@ -151,7 +184,7 @@ func TestFetchForecastByLocation(t *testing.T) {
 		})
 		defer monkey.Unpatch(server.DoRequestWithRetry)
 
-		result, err := forecastService.FetchForecastByLocation("123.456", "78.910", "3")
+		result, err := forecastService.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 78.910, Longitude: 123.456}, "2024-10-11")
 
 		assert.EqualError(t, err, "failed to make request")
 		assert.Nil(t, result)
@@ -167,7 +200,7 @@ func TestFetchForecastByLocation(t *testing.T) {
 		})
 		defer monkey.Unpatch(server.DoRequestWithRetry)
 
-		result, err := forecastService.FetchForecastByLocation("123.456", "78.910", "3")
+		result, err := forecastService.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 78.910, Longitude: 123.456}, "2024-10-11")
 
 		assert.EqualError(t, err, "failed to communicate with the third-party service")
 		assert.Nil(t, result)
@@ -183,10 +216,26 @@ func TestFetchForecastByLocation(t *testing.T) {
 		})
 		defer monkey.Unpatch(server.DoRequestWithRetry)
 
-		result, err := forecastService.FetchForecastByLocation("123.456", "78.910", "3")
+		result, err := forecastService.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 78.910, Longitude: 123.456}, "2024-10-11")
 
 		assert.EqualError(t, err, "invalid character 'i' looking for beginning of object key string")
 		assert.Nil(t, result)
 	})
 
+	t.Run("UnexpectedSectionType", func(t *testing.T) {
+		mockResponse := &http.Response{
+			StatusCode: 200,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"forecast": {"forecastday": "not-an-array"}}`)),
+		}
+		monkey.Patch(server.DoRequestWithRetry, func(opts server.RequestOptions) (*http.Response, error) {
+			return mockResponse, nil
+		})
+		defer monkey.Unpatch(server.DoRequestWithRetry)
+
+		result, err := forecastService.FetchForecastByLocation(domain.DeliveryLocation{Latitude: 78.910, Longitude: 123.456}, "2024-10-11")
+
+		assert.ErrorContains(t, err, "forecast.forecastday")
+		assert.Nil(t, result)
+	})
+
 }
@@ -0,0 +1,219 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/golang/mock/gomock"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNominatimGeocoder(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/search", r.URL.Path)
+			assert.Equal(t, "Av. Paulista 1000 & 1001, São Paulo", r.URL.Query().Get("q"))
+			assert.Equal(t, "jsonv2", r.URL.Query().Get("format"))
+			assert.Equal(t, "1", r.URL.Query().Get("limit"))
+			assert.Equal(t, "br", r.URL.Query().Get("countrycodes"))
+			assert.Equal(t, "notifier-test", r.Header.Get("User-Agent"))
+			w.Write([]byte(`[{"lat": "-23.5649", "lon": "-46.6519", "display_name": "Avenida Paulista, São Paulo"}]`))
+		}))
+		defer stub.Close()
+
+		geocoder := third_party.NewNominatimGeocoder(server.GeocoderConfig{BaseURL: stub.URL + "/", UserAgent: "notifier-test", CountryCodes: "br"})
+		result, err := geocoder.Geocode("Av. Paulista 1000 & 1001, São Paulo")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &third_party.GeocodeResult{Latitude: "-23.5649", Longitude: "-46.6519", DisplayName: "Avenida Paulista, São Paulo"}, result)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[]`))
+		}))
+		defer stub.Close()
+
+		_, err := third_party.NewNominatimGeocoder(server.GeocoderConfig{BaseURL: stub.URL}).Geocode("nowhere")
+
+		assert.ErrorIs(t, err, third_party.ErrAddressNotFound)
+	})
+
+	t.Run("HttpError", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer stub.Close()
+
+		_, err := third_party.NewNominatimGeocoder(server.GeocoderConfig{BaseURL: stub.URL}).Geocode("Av. Paulista 1000")
+
+		assert.EqualError(t, err, "failed to communicate with the geocoding service")
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		geocoder := third_party.NewNominatimGeocoder(server.GeocoderConfig{})
+
+		assert.Equal(t, third_party.NominatimDefaultBaseURL, geocoder.BaseURL)
+		assert.Equal(t, third_party.NominatimDefaultUserAgent, geocoder.UserAgent)
+	})
+}
+
+func TestCachedGeocoder(t *testing.T) {
+	place := &third_party.GeocodeResult{Latitude: "-23.5649", Longitude: "-46.6519"}
+
+	t.Run("SharesNormalizedAddress", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockGeocoder := mocks.NewMockGeocoder(ctrl)
+		mockGeocoder.EXPECT().Geocode("Av. Paulista 1000").Return(place, nil).Times(1)
+
+		geocoder := third_party.NewCachedGeocoder(mockGeocoder, third_party.NewMemoryGeocodeCache(10), time.Minute)
+		first, err := geocoder.Geocode("Av. Paulista 1000")
+		assert.NoError(t, err)
+		second, err := geocoder.Geocode("  av. paulista   1000 ")
+		assert.NoError(t, err)
+
+		assert.Equal(t, place, first)
+		assert.Equal(t, place, second)
+	})
+
+	t.Run("NotFoundIsNotCached", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockGeocoder := mocks.NewMockGeocoder(ctrl)
+		mockGeocoder.EXPECT().Geocode("nowhere").Return(nil, third_party.ErrAddressNotFound).Times(2)
+
+		geocoder := third_party.NewCachedGeocoder(mockGeocoder, third_party.NewMemoryGeocodeCache(10), time.Minute)
+		_, err := geocoder.Geocode("nowhere")
+		assert.ErrorIs(t, err, third_party.ErrAddressNotFound)
+		_, err = geocoder.Geocode("nowhere")
+		assert.ErrorIs(t, err, third_party.ErrAddressNotFound)
+	})
+
+	t.Run("MemoryEvictsLeastRecentlyUsed", func(t *testing.T) {
+		ctx := context.Background()
+		cache := third_party.NewMemoryGeocodeCache(1)
+
+		cache.Set(ctx, "a", *place, time.Minute)
+		cache.Set(ctx, "b", *place, time.Minute)
+
+		_, found, _ := cache.Get(ctx, "a")
+		assert.False(t, found)
+		_, found, _ = cache.Get(ctx, "b")
+		assert.True(t, found)
+	})
+
+	t.Run("Redis", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockGeocoder := mocks.NewMockGeocoder(ctrl)
+		mockGeocoder.EXPECT().Geocode("Av. Paulista 1000").Return(place, nil)
+
+		redisMock, mock := redismock.NewClientMock()
+		key := third_party.GeocodeCacheKey("Av. Paulista 1000")
+		data, _ := json.Marshal(place)
+		mock.ExpectGet(key).RedisNil()
+		mock.ExpectSet(key, data, time.Hour).SetVal("OK")
+		mock.ExpectGet(key).SetVal(string(data))
+
+		geocoder := third_party.NewCachedGeocoder(mockGeocoder, third_party.NewRedisGeocodeCache(redisMock), time.Hour)
+		_, err := geocoder.Geocode("Av. Paulista 1000")
+		assert.NoError(t, err)
+		result, err := geocoder.Geocode("Av. Paulista 1000")
+
+		assert.NoError(t, err)
+		assert.Equal(t, place, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSendNotificationWithAddress(t *testing.T) {
+	request := usecases.RequestDataNotification{Email: "a@example.com", Address: "Av. Paulista 1000"}
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+
+	t.Run("Geocoded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		mockGeocoder := mocks.NewMockGeocoder(ctrl)
+
+		mockGeocoder.EXPECT().Geocode("Av. Paulista 1000").Return(&third_party.GeocodeResult{Latitude: "-23.56", Longitude: "-46.65"}, nil)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: -23.56, Longitude: -46.65}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1000, Description: "Sol"}, nil)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil).AnyTimes()
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+			assert.Equal(t, domain.DeliveryLocation{Latitude: -23.56, Longitude: -46.65}, notification.DeliveryLocation)
+			return nil
+		}).AnyTimes()
+
+		response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{Geocoder: mockGeocoder})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &usecases.Location{Latitude: "-23.56", Longitude: "-46.65"}, response.Location)
+	})
+
+	t.Run("CoordinatesWin", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockGeocoder := mocks.NewMockGeocoder(ctrl)
+
+		withCoordinates := request
+		withCoordinates.Location = usecases.Location{Latitude: "1", Longitude: "2"}
+		location, geocoded, err := usecases.ResolveLocation(withCoordinates, mockGeocoder)
+
+		assert.NoError(t, err)
+		assert.False(t, geocoded)
+		assert.Equal(t, withCoordinates.Location, location)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockGeocoder := mocks.NewMockGeocoder(ctrl)
+
+		_, _, err := usecases.ResolveLocation(request, nil)
+		assert.EqualError(t, err, "Invalid address: geocoding is disabled, latitude and longitude are required")
+
+		mockGeocoder.EXPECT().Geocode("Av. Paulista 1000").Return(nil, third_party.ErrAddressNotFound)
+		_, _, err = usecases.ResolveLocation(request, mockGeocoder)
+		assert.EqualError(t, err, "Invalid address: no place matches the address")
+
+		unavailable := errors.New("failed to communicate with the geocoding service")
+		mockGeocoder.EXPECT().Geocode("Av. Paulista 1000").Return(nil, unavailable)
+		_, _, err = usecases.ResolveLocation(request, mockGeocoder)
+		assert.Equal(t, unavailable, err)
+	})
+
+	t.Run("HandlerAddressNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		mockGeocoder := mocks.NewMockGeocoder(ctrl)
+
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
+		mockGeocoder.EXPECT().Geocode("nowhere").Return(nil, third_party.ErrAddressNotFound)
+
+		handler := &infrastructure.NotificationHandler{ForecastService: mockForecastService, Geocoder: mockGeocoder}
+		body := `{"email": "a@example.com", "address": "nowhere"}`
+		w := httptest.NewRecorder()
+		handler.NotifyBuyer(w, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid address: no place matches the address")
+	})
+}
@@ -72,7 +72,7 @@ func TestListBuyerNotifications(t *testing.T) {
 	t.Run("NotFoundBuyerNotification", func(t *testing.T) {
 
 		mockRepo := mocks.NewMockNotificationRepository(ctrl)
-		handler := infrastructure.NewNotificationHandler(mockRepo, nil, server.Config{})
+		handler := infrastructure.NewNotificationHandler(mockRepo, nil, nil, server.Config{})
 
 		email := "buyer@example.com"
 		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/notifications/%s", email), nil)
@@ -91,7 +91,7 @@ func TestListBuyerNotifications(t *testing.T) {
 
 	t.Run("Error", func(t *testing.T) {
 		mockRepo := mocks.NewMockNotificationRepository(ctrl)
-		handler := infrastructure.NewNotificationHandler(mockRepo, nil, server.Config{})
+		handler := infrastructure.NewNotificationHandler(mockRepo, nil, nil, server.Config{})
 
 		email := "buyer@example.com"
 		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/notifications/%s", email), nil)
@@ -135,7 +135,6 @@ func TestNotifyBuyer(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockNotificationRepository(ctrl)
-		mockSender := mocks.NewMockNotificationSender(ctrl)
 
 		requestData := usecases.RequestDataNotification{
 			Email: "test@example.com",
@@ -152,13 +151,12 @@ func TestNotifyBuyer(t *testing.T) {
 			BuyerNotification:   true,
 		}
 
-		monkey.Patch(usecases.SendNotification, func(req usecases.RequestDataNotification, forecastService third_party.IForecastService, repo domain.NotificationRepository, sender domain.NotificationSender) (*usecases.NotificationServiceResponse, error) {
+		monkey.Patch(usecases.SendNotification, func(req usecases.RequestDataNotification, forecastService third_party.IForecastService, repo domain.NotificationRepository, renderer domain.MessageRenderer, settings usecases.NotificationSettings) (*usecases.NotificationServiceResponse, error) {
 			return &mockNotificationResponse, nil
 		})
 		defer monkey.Unpatch(usecases.SendNotification)
 		handler := &infrastructure.NotificationHandler{
 			NotificationRepository: mockRepo,
-			NotificationSender:     mockSender,
 			Config:                 server.Config{},
 		}
 
@@ -175,7 +173,7 @@ func TestNotifyBuyer(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockNotificationRepository(ctrl)
-		mockSender := mocks.NewMockNotificationSender(ctrl)
+		renderer := newMessageRenderer(t)
 
 		cfg := server.Config{
 			SMTPConfig: server.SMTPConfig{},
@@ -185,11 +183,14 @@ func TestNotifyBuyer(t *testing.T) {
 			},
 		}
 
-		handler := infrastructure.NewNotificationHandler(mockRepo, mockSender, cfg)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		handler := infrastructure.NewNotificationHandler(mockRepo, renderer, mockForecastService, cfg)
 
 		assert.NotNil(t, handler)
 		assert.Equal(t, mockRepo, handler.NotificationRepository)
-		assert.Equal(t, mockSender, handler.NotificationSender)
+		assert.Equal(t, renderer, handler.MessageRenderer)
+		assert.Equal(t, mockForecastService, handler.ForecastService)
 		assert.Equal(t, cfg, handler.Config)
 	})
 
@@ -207,7 +208,6 @@ func TestNotifyBuyer(t *testing.T) {
 		requestBody, _ := json.Marshal(requestData)
 
 		mockRepo := mocks.NewMockNotificationRepository(ctrl)
-		mockSender := mocks.NewMockNotificationSender(ctrl)
 
 		cfg := server.Config{
 			SMTPConfig: server.SMTPConfig{},
@@ -216,11 +216,11 @@ func TestNotifyBuyer(t *testing.T) {
 				Port: 6379,
 			},
 		}
-		monkey.Patch(usecases.SendNotification, func(requestDataNotification usecases.RequestDataNotification, forecastService third_party.IForecastService, repository domain.NotificationRepository, sender domain.NotificationSender) (*usecases.NotificationServiceResponse, error) {
+		monkey.Patch(usecases.SendNotification, func(requestDataNotification usecases.RequestDataNotification, forecastService third_party.IForecastService, repository domain.NotificationRepository, renderer domain.MessageRenderer, settings usecases.NotificationSettings) (*usecases.NotificationServiceResponse, error) {
 			return nil, errors.New("failed to send notification")
 		})
 		defer monkey.Unpatch(usecases.SendNotification)
-		handler := infrastructure.NewNotificationHandler(mockRepo, mockSender, cfg)
+		handler := infrastructure.NewNotificationHandler(mockRepo, nil, nil, cfg)
 
 		req := httptest.NewRequest("POST", "/notifications", bytes.NewReader(requestBody))
 		w := httptest.NewRecorder()
@@ -230,4 +230,22 @@ func TestNotifyBuyer(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "failed to send notification")
 
 	})
+
+	t.Run("ValidationError", func(t *testing.T) {
+		requestBody := []byte(`{"email":"test@example.com","location":{"latitude":"40.7128","longitude":"-74.0060"},"delivery_date":"2000-01-01"}`)
+
+		monkey.Patch(usecases.SendNotification, func(requestDataNotification usecases.RequestDataNotification, forecastService third_party.IForecastService, repository domain.NotificationRepository, renderer domain.MessageRenderer, settings usecases.NotificationSettings) (*usecases.NotificationServiceResponse, error) {
+			return nil, &domain.ValidationError{Field: "delivery_date", Message: "must not be in the past"}
+		})
+		defer monkey.Unpatch(usecases.SendNotification)
+		handler := infrastructure.NewNotificationHandler(nil, nil, nil, server.Config{})
+
+		req := httptest.NewRequest("POST", "/notifications", bytes.NewReader(requestBody))
+		w := httptest.NewRecorder()
+
+		handler.NotifyBuyer(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid delivery_date: must not be in the past")
+	})
 }
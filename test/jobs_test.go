@@ -0,0 +1,308 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
+	repository "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/repository"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnqueueNotificationJob(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		mockJobRepo := mocks.NewMockJobRepository(ctrl)
+
+		tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+
+		var saved domain.Job
+		mockJobRepo.EXPECT().SaveJob(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, job domain.Job) error {
+			saved = job
+			return nil
+		})
+		mockJobRepo.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Return(nil)
+
+		request := usecases.RequestDataNotification{Email: "a@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}}
+		result, err := usecases.EnqueueNotificationJob(request, mockForecastService, mockJobRepo)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.JobStatusQueued, result.Status)
+		assert.Len(t, result.JobID, 32)
+		assert.Equal(t, saved.ID, result.JobID)
+
+		var payload usecases.RequestDataNotification
+		assert.NoError(t, json.Unmarshal(saved.Payload, &payload))
+		assert.Equal(t, tomorrow, payload.DeliveryDate)
+	})
+
+	t.Run("InvalidDeliveryDate", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		mockJobRepo := mocks.NewMockJobRepository(ctrl)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+
+		request := usecases.RequestDataNotification{Email: "a@example.com", DeliveryDate: "2000-01-01"}
+		result, err := usecases.EnqueueNotificationJob(request, mockForecastService, mockJobRepo)
+
+		assert.Nil(t, result)
+		assert.IsType(t, &domain.ValidationError{}, err)
+	})
+}
+
+func TestProcessNextJob(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	payload, _ := json.Marshal(usecases.RequestDataNotification{Email: "a@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}, DeliveryDate: tomorrow})
+
+	t.Run("EmptyQueue", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockJobRepo := mocks.NewMockJobRepository(ctrl)
+		mockJobRepo.EXPECT().DequeueJob(gomock.Any(), "worker-1", gomock.Any()).Return("", nil)
+
+		err := usecases.ProcessNextJob(context.Background(), "worker-1", 3, mockJobRepo, nil, nil, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Succeeded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockJobRepo := mocks.NewMockJobRepository(ctrl)
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1000, Description: "Soleado"}, nil)
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+
+		var statuses []string
+		var last domain.Job
+		mockJobRepo.EXPECT().DequeueJob(gomock.Any(), "worker-1", gomock.Any()).Return("job-1", nil)
+		mockJobRepo.EXPECT().GetJob(gomock.Any(), "job-1").Return(&domain.Job{ID: "job-1", Status: domain.JobStatusQueued, Payload: payload}, nil)
+		mockJobRepo.EXPECT().SaveJob(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, job domain.Job) error {
+			statuses = append(statuses, job.Status)
+			last = job
+			return nil
+		}).Times(2)
+		mockJobRepo.EXPECT().AckJob(gomock.Any(), "worker-1", "job-1").Return(nil)
+
+		err := usecases.ProcessNextJob(context.Background(), "worker-1", 3, mockJobRepo, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{domain.JobStatusRunning, domain.JobStatusSucceeded}, statuses)
+		assert.Equal(t, 1, last.Attempts)
+
+		result := usecases.JobEntityToDTO(last)
+		assert.Equal(t, float64(1000), result.Result.ForecastCode)
+		assert.False(t, result.Result.BuyerNotification)
+	})
+
+	t.Run("RetriedUntilMaxAttempts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockJobRepo := mocks.NewMockJobRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).Times(2)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(nil, assert.AnError).Times(2)
+
+		job := &domain.Job{ID: "job-1", Status: domain.JobStatusQueued, Payload: payload}
+		mockJobRepo.EXPECT().DequeueJob(gomock.Any(), "worker-1", gomock.Any()).Return("job-1", nil).Times(2)
+		mockJobRepo.EXPECT().GetJob(gomock.Any(), "job-1").DoAndReturn(func(ctx context.Context, id string) (*domain.Job, error) {
+			copied := *job
+			return &copied, nil
+		}).Times(2)
+		mockJobRepo.EXPECT().SaveJob(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, saved domain.Job) error {
+			*job = saved
+			return nil
+		}).Times(4)
+		mockJobRepo.EXPECT().EnqueueJob(gomock.Any(), "job-1").Return(nil).Times(1)
+		mockJobRepo.EXPECT().AckJob(gomock.Any(), "worker-1", "job-1").Return(nil).Times(2)
+
+		assert.NoError(t, usecases.ProcessNextJob(context.Background(), "worker-1", 2, mockJobRepo, mockForecastService, nil, newMessageRenderer(t), usecases.NotificationSettings{}))
+		assert.Equal(t, domain.JobStatusQueued, job.Status)
+
+		assert.NoError(t, usecases.ProcessNextJob(context.Background(), "worker-1", 2, mockJobRepo, mockForecastService, nil, newMessageRenderer(t), usecases.NotificationSettings{}))
+		assert.Equal(t, domain.JobStatusFailed, job.Status)
+		assert.Equal(t, 2, job.Attempts)
+		assert.Equal(t, assert.AnError.Error(), job.Error)
+	})
+
+	t.Run("FinishedJobIsAcknowledged", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockJobRepo := mocks.NewMockJobRepository(ctrl)
+		mockJobRepo.EXPECT().DequeueJob(gomock.Any(), "worker-1", gomock.Any()).Return("job-1", nil)
+		mockJobRepo.EXPECT().GetJob(gomock.Any(), "job-1").Return(&domain.Job{ID: "job-1", Status: domain.JobStatusSucceeded}, nil)
+		mockJobRepo.EXPECT().AckJob(gomock.Any(), "worker-1", "job-1").Return(nil)
+
+		err := usecases.ProcessNextJob(context.Background(), "worker-1", 3, mockJobRepo, nil, nil, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestJobHandler(t *testing.T) {
+	t.Run("NotifyBuyerAsyncAccepted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		mockJobRepo := mocks.NewMockJobRepository(ctrl)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockJobRepo.EXPECT().SaveJob(gomock.Any(), gomock.Any()).Return(nil)
+		mockJobRepo.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Return(nil)
+
+		handler := infrastructure.NewJobHandler(mockJobRepo, mockForecastService, server.Config{})
+
+		body := `{"email": "a@example.com", "location": {"latitude": "1", "longitude": "2"}}`
+		req := httptest.NewRequest(http.MethodPost, "/notifications?async=true", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		handler.NotifyBuyerAsync(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		var response usecases.JobServiceResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "queued", response.Status)
+		assert.Equal(t, "/api/v1/jobs/"+response.JobID, w.Header().Get("Location"))
+	})
+
+	t.Run("NotifyBuyerAsyncInvalidEmail", func(t *testing.T) {
+		handler := infrastructure.NewJobHandler(nil, nil, server.Config{})
+
+		req := httptest.NewRequest(http.MethodPost, "/notifications?async=true", bytes.NewBufferString(`{"email": "invalid"}`))
+		w := httptest.NewRecorder()
+
+		handler.NotifyBuyerAsync(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("GetJobNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockJobRepo := mocks.NewMockJobRepository(ctrl)
+		mockJobRepo.EXPECT().GetJob(gomock.Any(), "missing").Return(nil, &domain.NotFoundError{Message: "Job missing not found"})
+
+		handler := infrastructure.NewJobHandler(mockJobRepo, nil, server.Config{})
+
+		req := httptest.NewRequest(http.MethodGet, "/jobs/missing", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": "missing"})
+		w := httptest.NewRecorder()
+
+		handler.GetJob(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("GetJobSuccess", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockJobRepo := mocks.NewMockJobRepository(ctrl)
+		mockJobRepo.EXPECT().GetJob(gomock.Any(), "job-1").Return(&domain.Job{ID: "job-1", Status: domain.JobStatusSucceeded, Attempts: 1, Result: json.RawMessage(`{"forecast_code": 1063, "buyer_notification": true}`)}, nil)
+
+		handler := infrastructure.NewJobHandler(mockJobRepo, nil, server.Config{})
+
+		req := httptest.NewRequest(http.MethodGet, "/jobs/job-1", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": "job-1"})
+		w := httptest.NewRecorder()
+
+		handler.GetJob(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response usecases.JobServiceResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "succeeded", response.Status)
+		assert.True(t, response.Result.BuyerNotification)
+	})
+}
+
+func TestRedisJobs(t *testing.T) {
+	t.Run("GetJobNotFound", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectGet("jobs:missing").RedisNil()
+
+		job, err := repo.GetJob(context.Background(), "missing")
+
+		assert.Nil(t, job)
+		assert.IsType(t, &domain.NotFoundError{}, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DequeueEmpty", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectBLMove("jobs:queue", "jobs:processing:worker-1", "RIGHT", "LEFT", time.Second).RedisNil()
+
+		id, err := repo.DequeueJob(context.Background(), "worker-1", time.Second)
+
+		assert.NoError(t, err)
+		assert.Empty(t, id)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RenewJobLease", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectTxPipeline()
+		mock.ExpectSet("jobs:lease:worker-1", 1, 30*time.Second).SetVal("OK")
+		mock.ExpectSAdd("jobs:workers", "worker-1").SetVal(1)
+		mock.ExpectTxPipelineExec()
+
+		err := repo.RenewJobLease(context.Background(), "worker-1", 30*time.Second)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RecoverJobsOfExpiredWorkers", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectSMembers("jobs:workers").SetVal([]string{"alive", "expired"})
+		mock.ExpectExists("jobs:lease:alive").SetVal(1)
+		mock.ExpectExists("jobs:lease:expired").SetVal(0)
+		mock.ExpectLMove("jobs:processing:expired", "jobs:queue", "RIGHT", "RIGHT").SetVal("job-1")
+		mock.ExpectLMove("jobs:processing:expired", "jobs:queue", "RIGHT", "RIGHT").SetVal("job-2")
+		mock.ExpectLMove("jobs:processing:expired", "jobs:queue", "RIGHT", "RIGHT").RedisNil()
+		mock.ExpectSRem("jobs:workers", "expired").SetVal(1)
+
+		recovered, err := repo.RecoverJobs(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, recovered)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,170 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"bou.ke/monkey"
+	"github.com/golang/mock/gomock"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDeliveryLocation(t *testing.T) {
+	t.Run("Normalized", func(t *testing.T) {
+		location, err := domain.NewDeliveryLocation(-23.55052349, -0.0000001)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryLocation{Latitude: -23.550523, Longitude: 0}, location)
+		assert.False(t, math.Signbit(location.Longitude))
+	})
+
+	t.Run("Bounds", func(t *testing.T) {
+		_, err := domain.NewDeliveryLocation(90, -180)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("OutOfRange", func(t *testing.T) {
+		_, err := domain.NewDeliveryLocation(90.5, math.Inf(1))
+
+		validationErr, ok := err.(*domain.ValidationError)
+		assert.True(t, ok)
+		assert.EqualError(t, err, "Invalid location: latitude must be between -90 and 90, longitude must be a number")
+		assert.Equal(t, []domain.FieldError{
+			{Field: "location.latitude", Message: "must be between -90 and 90"},
+			{Field: "location.longitude", Message: "must be a number"},
+		}, validationErr.Fields)
+	})
+
+	t.Run("DecodesLegacyStrings", func(t *testing.T) {
+		var notification domain.Notification
+		err := json.Unmarshal([]byte(`{"location": {"latitude": "-23.55", "longitude": ""}}`), &notification)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryLocation{Latitude: -23.55}, notification.DeliveryLocation)
+	})
+
+	t.Run("EncodesNumbers", func(t *testing.T) {
+		data, err := json.Marshal(domain.DeliveryLocation{Latitude: -23.55, Longitude: -46.63})
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"latitude": -23.55, "longitude": -46.63}`, string(data))
+	})
+}
+
+func TestParseLocation(t *testing.T) {
+	t.Run("NumbersAndStrings", func(t *testing.T) {
+		var request usecases.RequestDataNotification
+		err := json.Unmarshal([]byte(`{"location": {"latitude": -23.5505, "longitude": " -46.6333 "}}`), &request)
+		assert.NoError(t, err)
+
+		location, err := usecases.ParseLocation(request.Location, "location")
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryLocation{Latitude: -23.5505, Longitude: -46.6333}, location)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			location usecases.Location
+			expected string
+		}{
+			{"Missing", usecases.Location{Longitude: "2"}, "Invalid location: latitude is required"},
+			{"Garbage", usecases.Location{Latitude: "1", Longitude: "2&q=London"}, "Invalid location: longitude must be a number"},
+			{"NaN", usecases.Location{Latitude: "NaN", Longitude: "2"}, "Invalid location: latitude must be a number"},
+			{"Hexadecimal", usecases.Location{Latitude: "0x10", Longitude: "2"}, "Invalid location: latitude must be a number"},
+			{"OutOfRange", usecases.Location{Latitude: "-91", Longitude: "180.1"}, "Invalid location: latitude must be between -90 and 90, longitude must be between -180 and 180"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := usecases.ParseLocation(tt.location, "location")
+
+				assert.EqualError(t, err, tt.expected)
+			})
+		}
+	})
+
+	t.Run("ResponseNumbers", func(t *testing.T) {
+		data, err := json.Marshal(usecases.LocationToDTO(domain.DeliveryLocation{Latitude: -23.5505, Longitude: 2}))
+
+		assert.NoError(t, err)
+		assert.Equal(t, `{"latitude":-23.5505,"longitude":2}`, string(data))
+	})
+}
+
+func TestForecastURLEncoding(t *testing.T) {
+	var requestURL string
+	monkey.Patch(server.DoRequestWithRetry, func(opts server.RequestOptions) (*http.Response, error) {
+		requestURL = opts.URL
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(bytes.NewBufferString(""))}, nil
+	})
+	defer monkey.Unpatch(server.DoRequestWithRetry)
+
+	forecastService := &third_party.ForecastService{BaseURL: "http://example.com", APIKey: "key&dt=2000-01-01"}
+	forecastService.FetchForecastByLocation(domain.DeliveryLocation{Latitude: -23.5505, Longitude: -46.6333}, "2024-10-11")
+
+	parsed, err := url.Parse(requestURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "key&dt=2000-01-01", parsed.Query().Get("key"))
+	assert.Equal(t, "-23.5505,-46.6333", parsed.Query().Get("q"))
+	assert.Equal(t, []string{"2024-10-11"}, parsed.Query()["dt"])
+}
+
+func TestNotifyBuyerInvalidLocation(t *testing.T) {
+	t.Run("FieldErrors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		handler := &infrastructure.NotificationHandler{ForecastService: mockForecastService}
+		body := `{"email": "a@example.com", "location": {"latitude": 95, "longitude": "abc"}}`
+		w := httptest.NewRecorder()
+		handler.NotifyBuyer(w, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var response domain.ErrorResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Invalid location: latitude must be between -90 and 90, longitude must be a number", response.Message)
+		assert.Equal(t, []domain.FieldError{
+			{Field: "location.latitude", Message: "must be between -90 and 90"},
+			{Field: "location.longitude", Message: "must be a number"},
+		}, response.Errors)
+	})
+
+	t.Run("MissingLocation", func(t *testing.T) {
+		handler := &infrastructure.NotificationHandler{}
+		body := `{"email": "a@example.com"}`
+		w := httptest.NewRecorder()
+		handler.NotifyBuyer(w, httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"location.latitude","message":"is required"`)
+	})
+
+	t.Run("RegisterDelivery", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockDeliveryRepository(ctrl)
+
+		body := `{"order_id": "o-1", "email": "a@example.com", "location": {"latitude": "1", "longitude": "200"}}`
+		w := httptest.NewRecorder()
+		newDeliveryRouter(mockRepo, server.Config{}).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/deliveries", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"location.longitude","message":"must be between -180 and 180"`)
+	})
+}
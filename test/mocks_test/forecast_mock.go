@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	domain "github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
 	infrastructure "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
 )
 
@@ -30,17 +31,27 @@ func (m *MockForecastService) EXPECT() *MockForecastServiceMockRecorder {
 	return m.recorder
 }
 
-func (m *MockForecastService) FetchForecastByLocation(longitude, latitude, days string) (*infrastructure.ForecastServiceResponse, error) {
+func (m *MockForecastService) FetchForecastByLocation(location domain.DeliveryLocation, date string) (*infrastructure.ForecastServiceResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FetchForecastByLocation", longitude, latitude, days)
+	ret := m.ctrl.Call(m, "FetchForecastByLocation", location, date)
 	ret0, _ := ret[0].(*infrastructure.ForecastServiceResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (mr *MockForecastServiceMockRecorder) FetchForecastByLocation(longitude, latitude, days interface{}) *gomock.Call {
+func (mr *MockForecastServiceMockRecorder) FetchForecastByLocation(location, date interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchForecastByLocation", reflect.TypeOf((*MockForecastService)(nil).FetchForecastByLocation), longitude, latitude, days)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchForecastByLocation", reflect.TypeOf((*MockForecastService)(nil).FetchForecastByLocation), location, date)
 }
 
+func (m *MockForecastService) MaxForecastDays() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxForecastDays")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
 
+func (mr *MockForecastServiceMockRecorder) MaxForecastDays() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxForecastDays", reflect.TypeOf((*MockForecastService)(nil).MaxForecastDays))
+}
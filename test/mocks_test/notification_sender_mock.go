@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	domain "github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
 )
 
 type MockNotificationSender struct {
@@ -29,14 +30,14 @@ func (m *MockNotificationSender) EXPECT() *MockNotificationSenderMockRecorder {
 	return m.recorder
 }
 
-func (m *MockNotificationSender) Send(email, text string) error {
+func (m *MockNotificationSender) Send(message domain.Message) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Send", email, text)
+	ret := m.ctrl.Call(m, "Send", message)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-func (mr *MockNotificationSenderMockRecorder) Send(email, text interface{}) *gomock.Call {
+func (mr *MockNotificationSenderMockRecorder) Send(message interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockNotificationSender)(nil).Send), email, text)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockNotificationSender)(nil).Send), message)
 }
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	domain "github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
@@ -68,3 +69,201 @@ func (mr *MockNotificationRepositoryMockRecorder) SaveNotification(ctx, notifica
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveNotification", reflect.TypeOf((*MockNotificationRepository)(nil).SaveNotification), ctx, notification)
 }
+
+func (m *MockNotificationRepository) GetNotificationRules(ctx context.Context) ([]domain.NotificationRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationRules", ctx)
+	ret0, _ := ret[0].([]domain.NotificationRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) GetNotificationRules(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationRules", reflect.TypeOf((*MockNotificationRepository)(nil).GetNotificationRules), ctx)
+}
+
+func (m *MockNotificationRepository) SaveNotificationRule(ctx context.Context, rule domain.NotificationRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveNotificationRule", ctx, rule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) SaveNotificationRule(ctx, rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveNotificationRule", reflect.TypeOf((*MockNotificationRepository)(nil).SaveNotificationRule), ctx, rule)
+}
+
+func (m *MockNotificationRepository) DeleteNotificationRule(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNotificationRule", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) DeleteNotificationRule(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotificationRule", reflect.TypeOf((*MockNotificationRepository)(nil).DeleteNotificationRule), ctx, name)
+}
+
+func (m *MockNotificationRepository) ListNotificationCodes(ctx context.Context) ([]domain.NotificationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotificationCodes", ctx)
+	ret0, _ := ret[0].([]domain.NotificationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) ListNotificationCodes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotificationCodes", reflect.TypeOf((*MockNotificationRepository)(nil).ListNotificationCodes), ctx)
+}
+
+func (m *MockNotificationRepository) SaveNotificationCode(ctx context.Context, code domain.NotificationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveNotificationCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) SaveNotificationCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveNotificationCode", reflect.TypeOf((*MockNotificationRepository)(nil).SaveNotificationCode), ctx, code)
+}
+
+func (m *MockNotificationRepository) DeleteNotificationCode(ctx context.Context, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteNotificationCode", ctx, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) DeleteNotificationCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteNotificationCode", reflect.TypeOf((*MockNotificationRepository)(nil).DeleteNotificationCode), ctx, code)
+}
+
+func (m *MockNotificationRepository) ReplaceNotificationCodes(ctx context.Context, codes []domain.NotificationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceNotificationCodes", ctx, codes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) ReplaceNotificationCodes(ctx, codes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceNotificationCodes", reflect.TypeOf((*MockNotificationRepository)(nil).ReplaceNotificationCodes), ctx, codes)
+}
+
+func (m *MockNotificationRepository) ClaimPendingNotifications(ctx context.Context, limit int, lease time.Duration) ([]domain.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPendingNotifications", ctx, limit, lease)
+	ret0, _ := ret[0].([]domain.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) ClaimPendingNotifications(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPendingNotifications", reflect.TypeOf((*MockNotificationRepository)(nil).ClaimPendingNotifications), ctx, limit, lease)
+}
+
+func (m *MockNotificationRepository) UpdateNotificationDelivery(ctx context.Context, notification domain.Notification, retryAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNotificationDelivery", ctx, notification, retryAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) UpdateNotificationDelivery(ctx, notification, retryAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNotificationDelivery", reflect.TypeOf((*MockNotificationRepository)(nil).UpdateNotificationDelivery), ctx, notification, retryAt)
+}
+
+func (m *MockNotificationRepository) GetBuyerPreferences(ctx context.Context, email string) (*domain.BuyerPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBuyerPreferences", ctx, email)
+	ret0, _ := ret[0].(*domain.BuyerPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) GetBuyerPreferences(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBuyerPreferences", reflect.TypeOf((*MockNotificationRepository)(nil).GetBuyerPreferences), ctx, email)
+}
+
+func (m *MockNotificationRepository) SaveBuyerPreferences(ctx context.Context, preferences domain.BuyerPreferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBuyerPreferences", ctx, preferences)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) SaveBuyerPreferences(ctx, preferences interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBuyerPreferences", reflect.TypeOf((*MockNotificationRepository)(nil).SaveBuyerPreferences), ctx, preferences)
+}
+
+func (m *MockNotificationRepository) GetOptOut(ctx context.Context, email string) (*domain.OptOut, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOptOut", ctx, email)
+	ret0, _ := ret[0].(*domain.OptOut)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) GetOptOut(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOptOut", reflect.TypeOf((*MockNotificationRepository)(nil).GetOptOut), ctx, email)
+}
+
+func (m *MockNotificationRepository) SaveOptOut(ctx context.Context, optOut domain.OptOut) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveOptOut", ctx, optOut)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) SaveOptOut(ctx, optOut interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOptOut", reflect.TypeOf((*MockNotificationRepository)(nil).SaveOptOut), ctx, optOut)
+}
+
+func (m *MockNotificationRepository) DeleteOptOut(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOptOut", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) DeleteOptOut(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOptOut", reflect.TypeOf((*MockNotificationRepository)(nil).DeleteOptOut), ctx, email)
+}
+
+func (m *MockNotificationRepository) ReserveDedupKey(ctx context.Context, key, notificationID string, window time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveDedupKey", ctx, key, notificationID, window)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) ReserveDedupKey(ctx, key, notificationID, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveDedupKey", reflect.TypeOf((*MockNotificationRepository)(nil).ReserveDedupKey), ctx, key, notificationID, window)
+}
+
+func (m *MockNotificationRepository) ReleaseDedupKey(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseDedupKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *MockNotificationRepositoryMockRecorder) ReleaseDedupKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseDedupKey", reflect.TypeOf((*MockNotificationRepository)(nil).ReleaseDedupKey), ctx, key)
+}
@@ -0,0 +1,297 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/golang/mock/gomock"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	repository "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/repository"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchPendingNotifications(t *testing.T) {
+	policy := usecases.OutboxPolicy{BatchSize: 10, MaxAttempts: 3, Lease: time.Minute, RetryBackoff: 30 * time.Second}
+
+	emailDelivery := func(attempts int) domain.ChannelDelivery {
+		return domain.ChannelDelivery{Channel: domain.ChannelEmail, Recipient: "a@example.com", Message: "Hola", Status: domain.DeliveryStatusPending, Attempts: attempts}
+	}
+	smsDelivery := domain.ChannelDelivery{Channel: domain.ChannelSMS, Recipient: "+5511912345678", Message: "Hola SMS", Status: domain.DeliveryStatusPending}
+
+	t.Run("Sent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockSender := mocks.NewMockNotificationSender(ctrl)
+
+		pending := domain.Notification{ID: "n-1", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusPending, Channels: []domain.ChannelDelivery{emailDelivery(0)}}
+		mockRepo.EXPECT().ClaimPendingNotifications(gomock.Any(), 10, time.Minute).Return([]domain.Notification{pending}, nil)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockSender.EXPECT().Send(domain.Message{NotificationID: "n-1", Channel: domain.ChannelEmail, Recipient: "a@example.com", Text: "Hola"}).Return(nil)
+		mockRepo.EXPECT().UpdateNotificationDelivery(gomock.Any(), gomock.Any(), time.Time{}).DoAndReturn(func(ctx context.Context, notification domain.Notification, retryAt time.Time) error {
+			assert.Equal(t, domain.DeliveryStatusSent, notification.DeliveryStatus)
+			assert.Equal(t, 1, notification.Channels[0].Attempts)
+			assert.Equal(t, domain.DeliveryStatusSent, notification.Channels[0].Status)
+			assert.NotNil(t, notification.Channels[0].SentAt)
+			return nil
+		})
+
+		dispatched, err := usecases.DispatchPendingNotifications(context.Background(), policy, mockRepo, map[string]domain.NotificationSender{domain.ChannelEmail: mockSender})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, dispatched)
+	})
+
+	t.Run("RetryWithBackoff", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockSender := mocks.NewMockNotificationSender(ctrl)
+
+		pending := domain.Notification{ID: "n-1", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusPending, Channels: []domain.ChannelDelivery{emailDelivery(1)}}
+		mockRepo.EXPECT().ClaimPendingNotifications(gomock.Any(), 10, time.Minute).Return([]domain.Notification{pending}, nil)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockSender.EXPECT().Send(gomock.Any()).Return(errors.New("smtp unavailable"))
+
+		before := time.Now()
+		mockRepo.EXPECT().UpdateNotificationDelivery(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification, retryAt time.Time) error {
+			assert.Equal(t, domain.DeliveryStatusPending, notification.DeliveryStatus)
+			assert.Equal(t, 2, notification.Channels[0].Attempts)
+			assert.Equal(t, "smtp unavailable", notification.Channels[0].LastError)
+			assert.WithinDuration(t, before.Add(time.Minute), retryAt, time.Second)
+			return nil
+		})
+
+		_, err := usecases.DispatchPendingNotifications(context.Background(), policy, mockRepo, map[string]domain.NotificationSender{domain.ChannelEmail: mockSender})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("FailedAfterMaxAttempts", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockSender := mocks.NewMockNotificationSender(ctrl)
+
+		pending := domain.Notification{ID: "n-1", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusPending, Channels: []domain.ChannelDelivery{emailDelivery(2)}}
+		mockRepo.EXPECT().ClaimPendingNotifications(gomock.Any(), 10, time.Minute).Return([]domain.Notification{pending}, nil)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockSender.EXPECT().Send(gomock.Any()).Return(errors.New("mailbox unavailable"))
+		mockRepo.EXPECT().UpdateNotificationDelivery(gomock.Any(), gomock.Any(), time.Time{}).DoAndReturn(func(ctx context.Context, notification domain.Notification, retryAt time.Time) error {
+			assert.Equal(t, domain.DeliveryStatusFailed, notification.DeliveryStatus)
+			assert.Equal(t, 3, notification.Channels[0].Attempts)
+			assert.Equal(t, "mailbox unavailable", notification.Channels[0].LastError)
+			return nil
+		})
+
+		_, err := usecases.DispatchPendingNotifications(context.Background(), policy, mockRepo, map[string]domain.NotificationSender{domain.ChannelEmail: mockSender})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("FailedChannelDoesNotBlockOthers", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockEmailSender := mocks.NewMockNotificationSender(ctrl)
+		mockSMSSender := mocks.NewMockNotificationSender(ctrl)
+
+		pending := domain.Notification{ID: "n-2", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusPending, Channels: []domain.ChannelDelivery{emailDelivery(2), smsDelivery}}
+		mockRepo.EXPECT().ClaimPendingNotifications(gomock.Any(), 10, time.Minute).Return([]domain.Notification{pending}, nil)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockEmailSender.EXPECT().Send(gomock.Any()).Return(errors.New("mailbox unavailable"))
+		mockSMSSender.EXPECT().Send(domain.Message{NotificationID: "n-2", Channel: domain.ChannelSMS, Recipient: "+5511912345678", Text: "Hola SMS"}).Return(nil)
+		mockRepo.EXPECT().UpdateNotificationDelivery(gomock.Any(), gomock.Any(), time.Time{}).DoAndReturn(func(ctx context.Context, notification domain.Notification, retryAt time.Time) error {
+			assert.Equal(t, domain.DeliveryStatusPartial, notification.DeliveryStatus)
+			assert.Equal(t, domain.DeliveryStatusFailed, notification.Channels[0].Status)
+			assert.Equal(t, domain.DeliveryStatusSent, notification.Channels[1].Status)
+			return nil
+		})
+
+		senders := map[string]domain.NotificationSender{domain.ChannelEmail: mockEmailSender, domain.ChannelSMS: mockSMSSender}
+		dispatched, err := usecases.DispatchPendingNotifications(context.Background(), policy, mockRepo, senders)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, dispatched)
+	})
+
+	t.Run("OnlyPendingChannelsAreRetried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockEmailSender := mocks.NewMockNotificationSender(ctrl)
+		mockSMSSender := mocks.NewMockNotificationSender(ctrl)
+
+		sent := emailDelivery(1)
+		sent.Status = domain.DeliveryStatusSent
+		pending := domain.Notification{ID: "n-2", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusPending, Channels: []domain.ChannelDelivery{sent, smsDelivery}}
+		mockRepo.EXPECT().ClaimPendingNotifications(gomock.Any(), 10, time.Minute).Return([]domain.Notification{pending}, nil)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockSMSSender.EXPECT().Send(gomock.Any()).Return(errors.New("gateway unavailable"))
+		mockRepo.EXPECT().UpdateNotificationDelivery(gomock.Any(), gomock.Any(), gomock.Not(time.Time{})).DoAndReturn(func(ctx context.Context, notification domain.Notification, retryAt time.Time) error {
+			assert.Equal(t, domain.DeliveryStatusPending, notification.DeliveryStatus)
+			assert.Equal(t, 1, notification.Channels[0].Attempts)
+			assert.Equal(t, 1, notification.Channels[1].Attempts)
+			return nil
+		})
+
+		senders := map[string]domain.NotificationSender{domain.ChannelEmail: mockEmailSender, domain.ChannelSMS: mockSMSSender}
+		_, err := usecases.DispatchPendingNotifications(context.Background(), policy, mockRepo, senders)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("ChannelWithoutSender", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockSender := mocks.NewMockNotificationSender(ctrl)
+
+		pending := domain.Notification{ID: "n-2", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusPending, Channels: []domain.ChannelDelivery{smsDelivery}}
+		mockRepo.EXPECT().ClaimPendingNotifications(gomock.Any(), 10, time.Minute).Return([]domain.Notification{pending}, nil)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockRepo.EXPECT().UpdateNotificationDelivery(gomock.Any(), gomock.Any(), time.Time{}).DoAndReturn(func(ctx context.Context, notification domain.Notification, retryAt time.Time) error {
+			assert.Equal(t, domain.DeliveryStatusFailed, notification.DeliveryStatus)
+			assert.Equal(t, "no sender configured for channel sms", notification.Channels[0].LastError)
+			return nil
+		})
+
+		_, err := usecases.DispatchPendingNotifications(context.Background(), policy, mockRepo, map[string]domain.NotificationSender{domain.ChannelEmail: mockSender})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("OptedOutAfterCreation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockSender := mocks.NewMockNotificationSender(ctrl)
+
+		first := domain.Notification{ID: "n-1", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusPending, Channels: []domain.ChannelDelivery{emailDelivery(0), smsDelivery}}
+		second := domain.Notification{ID: "n-2", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusPending, Channels: []domain.ChannelDelivery{emailDelivery(1)}}
+		mockRepo.EXPECT().ClaimPendingNotifications(gomock.Any(), 10, time.Minute).Return([]domain.Notification{first, second}, nil)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(&domain.OptOut{Email: "a@example.com", Source: domain.OptOutSourceLink}, nil).Times(1)
+		mockRepo.EXPECT().UpdateNotificationDelivery(gomock.Any(), gomock.Any(), time.Time{}).DoAndReturn(func(ctx context.Context, notification domain.Notification, retryAt time.Time) error {
+			assert.Equal(t, domain.DeliveryStatusSuppressed, notification.DeliveryStatus)
+			for _, channel := range notification.Channels {
+				assert.Equal(t, domain.DeliveryStatusSuppressed, channel.Status)
+			}
+			return nil
+		}).Times(2)
+
+		dispatched, err := usecases.DispatchPendingNotifications(context.Background(), policy, mockRepo, map[string]domain.NotificationSender{domain.ChannelEmail: mockSender, domain.ChannelSMS: mockSender})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, dispatched)
+	})
+
+	t.Run("ClaimError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockRepo.EXPECT().ClaimPendingNotifications(gomock.Any(), 10, time.Minute).Return(nil, errors.New("redis error"))
+
+		dispatched, err := usecases.DispatchPendingNotifications(context.Background(), policy, mockRepo, nil)
+
+		assert.EqualError(t, err, "redis error")
+		assert.Equal(t, 0, dispatched)
+	})
+}
+
+func TestBuyerNotificationDeliveryStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sentAt := time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC)
+	mockRepo := mocks.NewMockNotificationRepository(ctrl)
+	mockRepo.EXPECT().GetNotifications(gomock.Any(), "a@example.com").Return([]domain.Notification{
+		{ID: "n-1", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusSent, Channels: []domain.ChannelDelivery{
+			{Channel: domain.ChannelEmail, Status: domain.DeliveryStatusSent, Attempts: 1, SentAt: &sentAt},
+		}},
+		{ID: "n-2", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusFailed, Channels: []domain.ChannelDelivery{
+			{Channel: domain.ChannelEmail, Status: domain.DeliveryStatusFailed, Attempts: 5, LastError: "mailbox unavailable"},
+		}},
+	}, nil)
+
+	result, err := usecases.GetBuyerNotification("a@example.com", mockRepo)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "sent", result.History[0].DeliveryStatus)
+	assert.Equal(t, &sentAt, result.History[0].Channels[0].SentAt)
+	assert.Equal(t, "failed", result.History[1].DeliveryStatus)
+	assert.Equal(t, "mailbox unavailable", result.History[1].Channels[0].LastError)
+}
+
+func TestUpdateNotificationDelivery(t *testing.T) {
+	t.Run("Completed", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		notification := domain.Notification{ID: "n-1", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusSent}
+		data, _ := json.Marshal(notification)
+
+		mock.ExpectTxPipeline()
+		mock.ExpectSet("notification:records:n-1", data, 0).SetVal("OK")
+		mock.ExpectZRem("notification:outbox", "n-1").SetVal(1)
+		mock.ExpectTxPipelineExec()
+
+		err := repo.UpdateNotificationDelivery(context.Background(), notification, time.Time{})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		retryAt := time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC)
+		notification := domain.Notification{ID: "n-1", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusPending}
+		data, _ := json.Marshal(notification)
+
+		mock.ExpectTxPipeline()
+		mock.ExpectSet("notification:records:n-1", data, 0).SetVal("OK")
+		mock.ExpectZAdd("notification:outbox", redis.Z{Score: float64(retryAt.UnixMilli()), Member: "n-1"}).SetVal(0)
+		mock.ExpectTxPipelineExec()
+
+		err := repo.UpdateNotificationDelivery(context.Background(), notification, retryAt)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSaveScheduledNotification(t *testing.T) {
+	redisMock, mock := redismock.NewClientMock()
+	repo := &repository.RedisRepository{Client: redisMock}
+
+	scheduledAt := time.Date(2024, 10, 11, 8, 0, 0, 0, time.UTC)
+	notification := domain.Notification{ID: "n-1", Email: "a@example.com", DeliveryStatus: domain.DeliveryStatusPending, ScheduledAt: &scheduledAt}
+	data, _ := json.Marshal(notification)
+
+	mock.ExpectTxPipeline()
+	mock.ExpectSet("notification:records:n-1", data, 0).SetVal("OK")
+	mock.ExpectRPush("notifications:a@example.com", "n-1").SetVal(1)
+	mock.ExpectZAdd("notification:outbox", redis.Z{Score: float64(scheduledAt.UnixMilli()), Member: "n-1"}).SetVal(1)
+	mock.ExpectTxPipelineExec()
+
+	err := repo.SaveNotification(context.Background(), notification)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,189 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBuyerRouter(repo domain.NotificationRepository) *mux.Router {
+	buyerHandler := infrastructure.NewBuyerHandler(repo, nil, server.Config{})
+	router := mux.NewRouter()
+	router.HandleFunc("/buyers/{email}/preferences", buyerHandler.GetPreferences).Methods(http.MethodGet)
+	router.HandleFunc("/buyers/{email}/preferences", buyerHandler.SavePreferences).Methods(http.MethodPut)
+	return router
+}
+
+func TestBuyerPreferencesHandler(t *testing.T) {
+	t.Run("Save", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newBuyerRouter(mockRepo)
+
+		mockRepo.EXPECT().SaveBuyerPreferences(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, preferences domain.BuyerPreferences) error {
+			assert.Equal(t, "a@example.com", preferences.Email)
+			assert.Equal(t, []string{"email", "sms"}, preferences.Channels)
+			assert.Equal(t, "+5511912345678", preferences.Phone)
+			return nil
+		})
+
+		body := `{"channels": ["email", "SMS", "email"], "phone": "+5511912345678"}`
+		req := httptest.NewRequest(http.MethodPut, "/buyers/a@example.com/preferences", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"channels":["email","sms"]`)
+	})
+
+	t.Run("InvalidPreferences", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			body    string
+			message string
+		}{
+			{"UnknownChannel", `{"channels": ["fax"]}`, "Invalid channels: unknown channel"},
+			{"NoChannels", `{"channels": []}`, "Invalid channels: at least one channel is required"},
+			{"SMSWithoutPhone", `{"channels": ["sms"]}`, "Invalid phone: is required for the sms channel"},
+			{"InvalidPhone", `{"channels": ["email"], "phone": "12345"}`, "Invalid phone: must be an E.164 number"},
+			{"WebhookWithoutTarget", `{"channels": ["webhook"]}`, "Invalid webhook: is required for the webhook channel"},
+			{"InvalidWebhookURL", `{"channels": ["webhook"], "webhook": "ftp://partner.example.com"}`, "Invalid webhook: must be an absolute http(s) URL or a tenant"},
+			{"InvalidQuietHours", `{"channels": ["email"], "quiet_hours": {"start": "22h", "end": "08:00"}}`, "Invalid quiet_hours.start: must be a time (HH:MM)"},
+			{"InvalidTimezone", `{"channels": ["email"], "timezone": "Mars/Olympus"}`, "Invalid timezone: must be an IANA timezone"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+				router := newBuyerRouter(mocks.NewMockNotificationRepository(ctrl))
+
+				req := httptest.NewRequest(http.MethodPut, "/buyers/a@example.com/preferences", bytes.NewBufferString(tt.body))
+				w := httptest.NewRecorder()
+
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), tt.message)
+			})
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newBuyerRouter(mockRepo)
+
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(&domain.BuyerPreferences{
+			Email: "a@example.com", Channels: []string{"webhook"}, Webhook: "acme",
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/buyers/a@example.com/preferences", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"webhook":"acme"`)
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newBuyerRouter(mockRepo)
+
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/buyers/a@example.com/preferences", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestNotificationChannels(t *testing.T) {
+	request := usecases.RequestDataNotification{Email: "a@example.com"}
+
+	t.Run("EmailByDefault", func(t *testing.T) {
+		channels := usecases.NotificationChannels(request, nil)
+
+		assert.Equal(t, []domain.ChannelDelivery{
+			{Channel: domain.ChannelEmail, Recipient: "a@example.com", Status: domain.DeliveryStatusPending},
+		}, channels)
+	})
+
+	t.Run("StoredPreferences", func(t *testing.T) {
+		preferences := &domain.BuyerPreferences{Channels: []string{"sms", "webhook"}, Phone: "+5511912345678", Webhook: "acme"}
+
+		channels := usecases.NotificationChannels(request, preferences)
+
+		assert.Equal(t, []domain.ChannelDelivery{
+			{Channel: domain.ChannelSMS, Recipient: "+5511912345678", Status: domain.DeliveryStatusPending},
+			{Channel: domain.ChannelWebhook, Recipient: "acme", Status: domain.DeliveryStatusPending},
+		}, channels)
+	})
+
+	t.Run("RequestRecipientsTakePrecedence", func(t *testing.T) {
+		preferences := &domain.BuyerPreferences{Channels: []string{"email", "sms"}, Phone: "+5511912345678"}
+		request := usecases.RequestDataNotification{Email: "a@example.com", Phone: "+14155552671", CallbackURL: "https://partner.example.com/hooks"}
+
+		channels := usecases.NotificationChannels(request, preferences)
+
+		assert.Equal(t, []domain.ChannelDelivery{
+			{Channel: domain.ChannelEmail, Recipient: "a@example.com", Status: domain.DeliveryStatusPending},
+			{Channel: domain.ChannelSMS, Recipient: "+14155552671", Status: domain.DeliveryStatusPending},
+			{Channel: domain.ChannelWebhook, Recipient: "https://partner.example.com/hooks", Status: domain.DeliveryStatusPending},
+		}, channels)
+	})
+}
+
+func TestSendNotificationWithBuyerPreferences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockNotificationRepository(ctrl)
+	mockForecastService := mocks.NewMockForecastService(ctrl)
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	mockForecastService.EXPECT().MaxForecastDays().Return(3)
+	mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1063, Description: "Patchy rain possible"}, nil)
+	mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+	mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+	mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+	mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(&domain.BuyerPreferences{
+		Email: "a@example.com", Channels: []string{"sms"}, Phone: "+5511912345678",
+	}, nil)
+
+	var saved domain.Notification
+	mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+		saved = notification
+		return nil
+	})
+
+	request := usecases.RequestDataNotification{Email: "a@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}}
+	_, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+	assert.NoError(t, err)
+	assert.Len(t, saved.Channels, 1)
+	assert.Equal(t, domain.ChannelSMS, saved.Channels[0].Channel)
+	assert.Equal(t, "Tu entrega programada para mañana puede retrasarse por clima: Posibles lluvias aisladas.", saved.Channels[0].Message)
+}
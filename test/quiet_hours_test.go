@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuietHoursEnd(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	assert.NoError(t, err)
+	overnight := domain.QuietHours{Start: "22:00", End: "08:00"}
+
+	tests := []struct {
+		name       string
+		quietHours domain.QuietHours
+		now        time.Time
+		inside     bool
+		end        time.Time
+	}{
+		{"BeforeMidnight", overnight, time.Date(2024, 10, 10, 23, 30, 0, 0, saoPaulo), true, time.Date(2024, 10, 11, 8, 0, 0, 0, saoPaulo)},
+		{"AfterMidnight", overnight, time.Date(2024, 10, 11, 3, 0, 0, 0, saoPaulo), true, time.Date(2024, 10, 11, 8, 0, 0, 0, saoPaulo)},
+		{"AtStart", overnight, time.Date(2024, 10, 10, 22, 0, 0, 0, saoPaulo), true, time.Date(2024, 10, 11, 8, 0, 0, 0, saoPaulo)},
+		{"AtEnd", overnight, time.Date(2024, 10, 11, 8, 0, 0, 0, saoPaulo), false, time.Time{}},
+		{"Daytime", overnight, time.Date(2024, 10, 11, 12, 0, 0, 0, saoPaulo), false, time.Time{}},
+		{"SameDayRange", domain.QuietHours{Start: "12:00", End: "14:00"}, time.Date(2024, 10, 11, 13, 0, 0, 0, saoPaulo), true, time.Date(2024, 10, 11, 14, 0, 0, 0, saoPaulo)},
+		{"EmptyRange", domain.QuietHours{Start: "08:00", End: "08:00"}, time.Date(2024, 10, 11, 8, 0, 0, 0, saoPaulo), false, time.Time{}},
+		{"Disabled", domain.QuietHours{}, time.Date(2024, 10, 11, 3, 0, 0, 0, saoPaulo), false, time.Time{}},
+		// 02:00 UTC is 23:00 of the previous day in Sao Paulo.
+		{"ReadInLocation", overnight, time.Date(2024, 10, 11, 2, 0, 0, 0, time.UTC), true, time.Date(2024, 10, 11, 8, 0, 0, 0, saoPaulo)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, inside, err := usecases.QuietHoursEnd(tt.quietHours, tt.now, saoPaulo)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.inside, inside)
+			assert.True(t, tt.end.Equal(end), "end %s, want %s", end, tt.end)
+		})
+	}
+
+	t.Run("InvalidStart", func(t *testing.T) {
+		_, _, err := usecases.QuietHoursEnd(domain.QuietHours{Start: "10pm", End: "08:00"}, time.Now(), time.UTC)
+
+		assert.EqualError(t, err, `invalid quiet hours start "10pm"`)
+	})
+}
+
+func TestBuyerQuietHours(t *testing.T) {
+	settings := usecases.NotificationSettings{QuietHours: domain.QuietHours{Start: "22:00", End: "08:00"}, DefaultTimezone: "America/Bogota"}
+	preferences := &domain.BuyerPreferences{QuietHours: &domain.QuietHours{Start: "20:00", End: "09:00"}, Timezone: "America/Mexico_City"}
+
+	t.Run("RequestTimezone", func(t *testing.T) {
+		quietHours, location, err := usecases.BuyerQuietHours(usecases.RequestDataNotification{Timezone: "Europe/Madrid"}, preferences, "America/Sao_Paulo", settings)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.QuietHours{Start: "20:00", End: "09:00"}, quietHours)
+		assert.Equal(t, "Europe/Madrid", location.String())
+	})
+
+	t.Run("PreferencesTimezone", func(t *testing.T) {
+		_, location, err := usecases.BuyerQuietHours(usecases.RequestDataNotification{}, preferences, "America/Sao_Paulo", settings)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "America/Mexico_City", location.String())
+	})
+
+	t.Run("LocationTimezone", func(t *testing.T) {
+		quietHours, location, err := usecases.BuyerQuietHours(usecases.RequestDataNotification{}, nil, "America/Sao_Paulo", settings)
+
+		assert.NoError(t, err)
+		assert.Equal(t, settings.QuietHours, quietHours)
+		assert.Equal(t, "America/Sao_Paulo", location.String())
+	})
+
+	t.Run("DefaultTimezone", func(t *testing.T) {
+		_, location, err := usecases.BuyerQuietHours(usecases.RequestDataNotification{}, nil, "", settings)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "America/Bogota", location.String())
+	})
+
+	t.Run("UTC", func(t *testing.T) {
+		_, location, err := usecases.BuyerQuietHours(usecases.RequestDataNotification{}, nil, "", usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, time.UTC, location)
+	})
+}
+
+func TestSendNotificationQuietHours(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	request := usecases.RequestDataNotification{Email: "a@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}, Timezone: "America/Sao_Paulo"}
+
+	expectForecast := func(mockForecastService *mocks.MockForecastService, mockRepo *mocks.MockNotificationRepository) {
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1063, Description: "Lluvia", Timezone: "UTC"}, nil)
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+	}
+
+	t.Run("Scheduled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		expectForecast(mockForecastService, mockRepo)
+
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(nil, nil)
+		var saved domain.Notification
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+			saved = notification
+			return nil
+		})
+
+		// Quiet all day long except the minute before now, so now is always quiet.
+		location, _ := time.LoadLocation("America/Sao_Paulo")
+		now := time.Now().In(location)
+		quietHours := domain.QuietHours{Start: now.Format("15:04"), End: now.Add(-time.Minute).Format("15:04")}
+		response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{QuietHours: quietHours})
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryStatusPending, response.DeliveryStatus)
+		assert.NotNil(t, saved.ScheduledAt)
+		assert.Equal(t, saved.ScheduledAt, response.ScheduledAt)
+		assert.True(t, saved.ScheduledAt.After(now))
+		assert.Equal(t, quietHours.End, saved.ScheduledAt.In(location).Format("15:04"))
+	})
+
+	t.Run("PreferencesDisableQuietHours", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		expectForecast(mockForecastService, mockRepo)
+
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(&domain.BuyerPreferences{
+			Email:      "a@example.com",
+			Channels:   []string{domain.ChannelEmail},
+			QuietHours: &domain.QuietHours{Start: "00:00", End: "00:00"},
+		}, nil)
+		var saved domain.Notification
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+			saved = notification
+			return nil
+		})
+
+		settings := usecases.NotificationSettings{QuietHours: domain.QuietHours{Start: "00:00", End: "23:59"}}
+		response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), settings)
+
+		assert.NoError(t, err)
+		assert.Nil(t, saved.ScheduledAt)
+		assert.Nil(t, response.ScheduledAt)
+	})
+
+	t.Run("InvalidTimezone", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+
+		invalid := request
+		invalid.Timezone = "Mars/Olympus"
+		_, err := usecases.SendNotification(invalid, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.EqualError(t, err, "Invalid timezone: must be an IANA timezone")
+	})
+}
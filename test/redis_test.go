@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/go-redis/redismock/v9"
 	"github.com/golang/mock/gomock"
@@ -45,12 +46,15 @@ func TestSaveNotification(t *testing.T) {
 		repo := &repository.RedisRepository{Client: redisMock}
 
 		ctx := context.Background()
-		notification := domain.Notification{Email: "test@example.com"}
+		notification := domain.Notification{ID: "n-1", Email: "test@example.com"}
 
 		data, _ := json.Marshal(notification)
 		key := fmt.Sprintf("notifications:%s", notification.Email)
 
-		mock.ExpectRPush(key, data).SetVal(1)
+		mock.ExpectTxPipeline()
+		mock.ExpectSet("notification:records:n-1", data, 0).SetVal("OK")
+		mock.ExpectRPush(key, "n-1").SetVal(1)
+		mock.ExpectTxPipelineExec()
 
 		err := repo.SaveNotification(ctx, notification)
 
@@ -63,12 +67,14 @@ func TestSaveNotification(t *testing.T) {
 		repo := &repository.RedisRepository{Client: redisMock}
 
 		ctx := context.Background()
-		notification := domain.Notification{Email: "test@example.com"}
+		notification := domain.Notification{ID: "n-1", Email: "test@example.com"}
 
 		data, _ := json.Marshal(notification)
 		key := fmt.Sprintf("notifications:%s", notification.Email)
 
-		mock.ExpectRPush(key, data).SetErr(errors.New("redis error"))
+		mock.ExpectTxPipeline()
+		mock.ExpectSet("notification:records:n-1", data, 0).SetVal("OK")
+		mock.ExpectRPush(key, "n-1").SetErr(errors.New("redis error"))
 
 		err := repo.SaveNotification(ctx, notification)
 
@@ -103,6 +109,31 @@ func TestGetNotifications(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("OutboxRecords", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		ctx := context.Background()
+		email := "test@example.com"
+		legacy := domain.Notification{Email: email, ForecastCode: 1063}
+		record := domain.Notification{ID: "n-1", Email: email, DeliveryStatus: domain.DeliveryStatusSent, Channels: []domain.ChannelDelivery{
+			{Channel: domain.ChannelEmail, Recipient: email, Message: "Hola", Status: domain.DeliveryStatusSent, Attempts: 1},
+		}}
+
+		legacyData, _ := json.Marshal(legacy)
+		recordData, _ := json.Marshal(record)
+		key := fmt.Sprintf("notifications:%s", email)
+
+		mock.ExpectLRange(key, 0, -1).SetVal([]string{string(legacyData), "n-1", "n-2"})
+		mock.ExpectMGet("notification:records:n-1", "notification:records:n-2").SetVal([]interface{}{string(recordData), nil})
+
+		notifications, err := repo.GetNotifications(ctx, email)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Notification{legacy, record}, notifications)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("NotFound", func(t *testing.T) {
 		redisMock, mock := redismock.NewClientMock()
 		repo := &repository.RedisRepository{Client: redisMock}
@@ -141,7 +172,7 @@ func TestGetNotificationCodes(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("NotFound", func(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
 		redisMock, mock := redismock.NewClientMock()
 		repo := &repository.RedisRepository{Client: redisMock}
 
@@ -151,10 +182,316 @@ func TestGetNotificationCodes(t *testing.T) {
 
 		codes, err := repo.GetNotificationCodes(ctx)
 
-		assert.Error(t, err)
-		assert.Nil(t, codes)
+		assert.NoError(t, err)
+		assert.Empty(t, codes)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetNotificationRules(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHGetAll("notification:rules").SetVal(map[string]string{
+			"strong-wind": `{"conditions":[{"metric":"wind_kph","operator":"gte","value":50}]}`,
+			"heavy-rain":  `{"operator":"or","conditions":[{"metric":"precip_mm","operator":"gt","value":20}]}`,
+		})
+
+		rules, err := repo.GetNotificationRules(context.Background())
+
+		assert.NoError(t, err)
+		assert.Len(t, rules, 2)
+		assert.Equal(t, "heavy-rain", rules[0].Name)
+		assert.Equal(t, domain.RuleOperatorOr, rules[0].Operator)
+		assert.Equal(t, "strong-wind", rules[1].Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("InvalidRule", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHGetAll("notification:rules").SetVal(map[string]string{"broken": `{`})
+
+		rules, err := repo.GetNotificationRules(context.Background())
+
+		assert.Nil(t, rules)
+		assert.ErrorContains(t, err, "error decoding notification rule broken")
+	})
+}
+
+func TestNotificationRulesAdministration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Save", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+		rule := domain.NotificationRule{Name: "storm", Conditions: []domain.RuleCondition{{Metric: domain.MetricWindKph, Operator: "gte", Value: 50}}, UpdatedBy: "alice"}
+		data, _ := json.Marshal(rule)
+
+		mock.ExpectHSet("notification:rules", "storm", data).SetVal(1)
+
+		assert.NoError(t, repo.SaveNotificationRule(ctx, rule))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHDel("notification:rules", "storm").SetVal(1)
+
+		assert.NoError(t, repo.DeleteNotificationRule(ctx, "storm"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteNotFound", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHDel("notification:rules", "storm").SetVal(0)
+
+		err := repo.DeleteNotificationRule(ctx, "storm")
+
+		assert.IsType(t, &domain.NotFoundError{}, err)
+	})
+}
+
+func TestNotificationCodesAdministration(t *testing.T) {
+	ctx := context.Background()
+	code := domain.NotificationCode{Code: "1063", Description: "Lluvia", UpdatedBy: "alice"}
+	data, _ := json.Marshal(code)
+
+	t.Run("List", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectLRange("notification:codes", 0, -1).SetVal([]string{"1063", "1195"})
+		mock.ExpectHGetAll("notification:codes:meta").SetVal(map[string]string{"1063": string(data)})
+
+		codes, err := repo.ListNotificationCodes(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.NotificationCode{code, {Code: "1195"}}, codes)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectTxPipeline()
+		mock.ExpectLRem("notification:codes", 0, "1063").SetVal(0)
+		mock.ExpectRPush("notification:codes", "1063").SetVal(1)
+		mock.ExpectHSet("notification:codes:meta", "1063", data).SetVal(1)
+		mock.ExpectTxPipelineExec()
+
+		err := repo.SaveNotificationCode(ctx, code)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteNotFound", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectTxPipeline()
+		mock.ExpectLRem("notification:codes", 0, "1063").SetVal(0)
+		mock.ExpectHDel("notification:codes:meta", "1063").SetVal(0)
+		mock.ExpectTxPipelineExec()
+
+		err := repo.DeleteNotificationCode(ctx, "1063")
+
 		assert.IsType(t, &domain.NotFoundError{}, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectTxPipeline()
+		mock.ExpectDel("notification:codes", "notification:codes:meta").SetVal(2)
+		mock.ExpectRPush("notification:codes", "1063").SetVal(1)
+		mock.ExpectHSet("notification:codes:meta", map[string]interface{}{"1063": data}).SetVal(1)
+		mock.ExpectTxPipelineExec()
+
+		err := repo.ReplaceNotificationCodes(ctx, []domain.NotificationCode{code})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBuyerPreferencesRepository(t *testing.T) {
+	t.Run("Get", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		preferences := domain.BuyerPreferences{Email: "a@example.com", Channels: []string{"email", "sms"}, Phone: "+5511912345678"}
+		data, _ := json.Marshal(preferences)
+		mock.ExpectGet("buyers:a@example.com:preferences").SetVal(string(data))
+
+		result, err := repo.GetBuyerPreferences(context.Background(), "a@example.com")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &preferences, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectGet("buyers:a@example.com:preferences").RedisNil()
+
+		result, err := repo.GetBuyerPreferences(context.Background(), "a@example.com")
+
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		preferences := domain.BuyerPreferences{Email: "a@example.com", Channels: []string{"webhook"}, Webhook: "acme"}
+		data, _ := json.Marshal(preferences)
+		mock.ExpectSet("buyers:a@example.com:preferences", data, 0).SetVal("OK")
+
+		assert.NoError(t, repo.SaveBuyerPreferences(context.Background(), preferences))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestOptOutRepository(t *testing.T) {
+	optOut := domain.OptOut{Email: "a@example.com", Source: domain.OptOutSourceLink, CreatedAt: time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC)}
+	data, _ := json.Marshal(optOut)
+
+	t.Run("Get", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHGet("buyers:opt_outs", "a@example.com").SetVal(string(data))
+
+		result, err := repo.GetOptOut(context.Background(), "a@example.com")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &optOut, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHGet("buyers:opt_outs", "a@example.com").RedisNil()
+
+		result, err := repo.GetOptOut(context.Background(), "a@example.com")
+
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Save", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHSet("buyers:opt_outs", "a@example.com", data).SetVal(1)
+
+		assert.NoError(t, repo.SaveOptOut(context.Background(), optOut))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHDel("buyers:opt_outs", "a@example.com").SetVal(1)
+
+		assert.NoError(t, repo.DeleteOptOut(context.Background(), "a@example.com"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("MixedCase", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectHGet("buyers:opt_outs", "a@example.com").SetVal(string(data))
+
+		result, err := repo.GetOptOut(context.Background(), " A@Example.com")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "a@example.com", result.Email)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDedupRepository(t *testing.T) {
+	t.Run("Reserved", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectSetNX("notification:dedup:key", "n-1", time.Hour).SetVal(true)
+
+		holder, err := repo.ReserveDedupKey(context.Background(), "key", "n-1", time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "n-1", holder)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyReserved", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectSetNX("notification:dedup:key", "n-2", time.Hour).SetVal(false)
+		mock.ExpectGet("notification:dedup:key").SetVal("n-1")
+
+		holder, err := repo.ReserveDedupKey(context.Background(), "key", "n-2", time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "n-1", holder)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ExpiredBeforeGet", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectSetNX("notification:dedup:key", "n-2", time.Hour).SetVal(false)
+		mock.ExpectGet("notification:dedup:key").RedisNil()
+		mock.ExpectSetNX("notification:dedup:key", "n-2", time.Hour).SetVal(true)
+
+		holder, err := repo.ReserveDedupKey(context.Background(), "key", "n-2", time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "n-2", holder)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 
+	t.Run("Error", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectSetNX("notification:dedup:key", "n-1", time.Hour).SetErr(errors.New("timeout"))
+
+		_, err := repo.ReserveDedupKey(context.Background(), "key", "n-1", time.Hour)
+
+		assert.EqualError(t, err, "error reserving dedup key in Redis: timeout")
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		redisMock, mock := redismock.NewClientMock()
+		repo := &repository.RedisRepository{Client: redisMock}
+
+		mock.ExpectDel("notification:dedup:key").SetVal(1)
+
+		assert.NoError(t, repo.ReleaseDedupKey(context.Background(), "key"))
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
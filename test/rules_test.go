@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationRuleMatches(t *testing.T) {
+	metrics := domain.ForecastMetrics{
+		Code:         1195,
+		PrecipMm:     25.4,
+		WindKph:      18,
+		MaxTempC:     31,
+		MinTempC:     19,
+		ChanceOfRain: 90,
+	}
+
+	t.Run("AndAllConditionsHold", func(t *testing.T) {
+		rule := domain.NotificationRule{
+			Name: "storm",
+			Conditions: []domain.RuleCondition{
+				{Metric: domain.MetricPrecipMm, Operator: "gte", Value: 20},
+				{Metric: domain.MetricCode, Operator: "in", Codes: []string{"1189", "1195"}},
+			},
+		}
+
+		matches, err := rule.Matches(metrics)
+
+		assert.NoError(t, err)
+		assert.True(t, matches)
+	})
+
+	t.Run("AndOneConditionFails", func(t *testing.T) {
+		rule := domain.NotificationRule{
+			Name:     "storm",
+			Operator: domain.RuleOperatorAnd,
+			Conditions: []domain.RuleCondition{
+				{Metric: domain.MetricPrecipMm, Operator: "gte", Value: 20},
+				{Metric: domain.MetricWindKph, Operator: "gt", Value: 40},
+			},
+		}
+
+		matches, err := rule.Matches(metrics)
+
+		assert.NoError(t, err)
+		assert.False(t, matches)
+	})
+
+	t.Run("OrAnyConditionHolds", func(t *testing.T) {
+		rule := domain.NotificationRule{
+			Name:     "extreme-temperature",
+			Operator: domain.RuleOperatorOr,
+			Conditions: []domain.RuleCondition{
+				{Metric: domain.MetricMaxTempC, Operator: "gt", Value: 35},
+				{Metric: domain.MetricMinTempC, Operator: "lt", Value: 0},
+				{Metric: domain.MetricChanceOfRain, Operator: "gte", Value: 90},
+			},
+		}
+
+		matches, err := rule.Matches(metrics)
+
+		assert.NoError(t, err)
+		assert.True(t, matches)
+	})
+
+	t.Run("UnknownMetric", func(t *testing.T) {
+		rule := domain.NotificationRule{
+			Name:       "fog",
+			Conditions: []domain.RuleCondition{{Metric: "visibility_km", Operator: "lt", Value: 1}},
+		}
+
+		matches, err := rule.Matches(metrics)
+
+		assert.False(t, matches)
+		assert.EqualError(t, err, `rule fog: unknown metric "visibility_km"`)
+	})
+
+	t.Run("UnknownOperator", func(t *testing.T) {
+		rule := domain.NotificationRule{
+			Name:       "snow",
+			Operator:   "xor",
+			Conditions: []domain.RuleCondition{{Metric: domain.MetricSnowCm, Operator: "gt", Value: 1}},
+		}
+
+		_, err := rule.Matches(metrics)
+
+		assert.EqualError(t, err, `rule snow: unknown operator "xor"`)
+	})
+}
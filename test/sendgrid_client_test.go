@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/sender"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendGridClient(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var body map[string]interface{}
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "/v3/mail/send", r.URL.Path)
+			assert.Equal(t, "Bearer SG.test", r.Header.Get("Authorization"))
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer stub.Close()
+
+		client := sender.NewSendGridClient(server.SendGridConfig{
+			APIKey:    "SG.test",
+			BaseURL:   stub.URL + "/",
+			FromEmail: "alertas@example.com",
+			FromName:  "Alertas",
+		})
+
+		err := client.Send(domain.Message{Recipient: "buyer@example.com", Subject: "Entrega retrasada por clima", Text: "Hola!", HTML: "<p>Hola!</p>"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Entrega retrasada por clima", body["subject"])
+		assert.Equal(t, map[string]interface{}{"email": "alertas@example.com", "name": "Alertas"}, body["from"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"to": []interface{}{map[string]interface{}{"email": "buyer@example.com"}}}}, body["personalizations"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"type": "text/plain", "value": "Hola!"},
+			map[string]interface{}{"type": "text/html", "value": "<p>Hola!</p>"},
+		}, body["content"])
+	})
+
+	t.Run("ErrorMessages", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors": [{"message": "The from address does not match a verified Sender Identity.", "field": "from"}]}`))
+		}))
+		defer stub.Close()
+
+		client := sender.NewSendGridClient(server.SendGridConfig{APIKey: "SG.test", BaseURL: stub.URL})
+
+		err := client.Send(domain.Message{Recipient: "buyer@example.com", Text: "Hola!"})
+
+		assert.EqualError(t, err, "sendgrid returned http status 400: The from address does not match a verified Sender Identity.")
+	})
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer stub.Close()
+
+		client := sender.NewSendGridClient(server.SendGridConfig{APIKey: "invalid", BaseURL: stub.URL})
+
+		err := client.Send(domain.Message{Recipient: "buyer@example.com", Text: "Hola!"})
+
+		assert.EqualError(t, err, "sendgrid returned http status 401")
+	})
+}
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/sender"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMSClient(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+			user, password, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "AC123", user)
+			assert.Equal(t, "secret", password)
+			assert.NoError(t, r.ParseForm())
+			assert.Equal(t, "+5511912345678", r.PostForm.Get("To"))
+			assert.Equal(t, "+15005550006", r.PostForm.Get("From"))
+			assert.Equal(t, "Hola!", r.PostForm.Get("Body"))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"sid": "SM1", "status": "queued"}`))
+		}))
+		defer stub.Close()
+
+		client := sender.NewSMSClient(server.SMSConfig{BaseURL: stub.URL, AccountSID: "AC123", AuthToken: "secret", From: "+15005550006"})
+
+		err := client.Send(domain.Message{Recipient: "+5511912345678", Text: "Hola!"})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("GatewayError", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": 21211, "message": "The 'To' number is not a valid phone number.", "status": 400}`))
+		}))
+		defer stub.Close()
+
+		client := sender.NewSMSClient(server.SMSConfig{BaseURL: stub.URL, AccountSID: "AC123", AuthToken: "secret"})
+
+		err := client.Send(domain.Message{Recipient: "+5511912345678", Text: "Hola!"})
+
+		assert.EqualError(t, err, "sms gateway returned http status 400: The 'To' number is not a valid phone number. (code 21211)")
+	})
+}
+
+func TestIsValidPhone(t *testing.T) {
+	assert.True(t, usecases.IsValidPhone("+5511912345678"))
+	assert.True(t, usecases.IsValidPhone("+14155552671"))
+	assert.False(t, usecases.IsValidPhone("5511912345678"))
+	assert.False(t, usecases.IsValidPhone("+0511912345678"))
+	assert.False(t, usecases.IsValidPhone("+55 11 91234-5678"))
+	assert.False(t, usecases.IsValidPhone("+1234567890123456"))
+}
+
+func TestSendNotificationWithPhone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockNotificationRepository(ctrl)
+	mockForecastService := mocks.NewMockForecastService(ctrl)
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	mockForecastService.EXPECT().MaxForecastDays().Return(3)
+	mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1063, Description: "Patchy rain possible"}, nil)
+	mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+	mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+
+	mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+	mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(nil, nil)
+
+	var saved domain.Notification
+	mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+		saved = notification
+		return nil
+	})
+
+	request := usecases.RequestDataNotification{Email: "a@example.com", Phone: "+5511912345678", Location: usecases.Location{Latitude: "1", Longitude: "2"}}
+	response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+	assert.NoError(t, err)
+	assert.Len(t, saved.Channels, 2)
+	assert.Equal(t, domain.ChannelEmail, saved.Channels[0].Channel)
+	assert.Equal(t, "a@example.com", saved.Channels[0].Recipient)
+	assert.Equal(t, domain.ChannelSMS, saved.Channels[1].Channel)
+	assert.Equal(t, "+5511912345678", saved.Channels[1].Recipient)
+	assert.Equal(t, "Tu entrega programada para mañana puede retrasarse por clima: Posibles lluvias aisladas.", saved.Channels[1].Message)
+	assert.Equal(t, saved.ID, response.NotificationID)
+	assert.Equal(t, []usecases.ChannelDeliveryDetail{
+		{Channel: domain.ChannelEmail, Status: domain.DeliveryStatusPending},
+		{Channel: domain.ChannelSMS, Status: domain.DeliveryStatusPending},
+	}, response.Channels)
+}
@@ -1,16 +1,21 @@
 package service_test
 
 import (
+	"bytes"
 	"errors"
-	"net/smtp"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"reflect"
 	"testing"
+	"time"
 
 	"bou.ke/monkey"
 	"github.com/golang/mock/gomock"
 	"github.com/juandr89/delivery-notifier-buyer/server"
 	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
 	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/sender"
-	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -25,12 +30,12 @@ func TestSendEmail(t *testing.T) {
 		email := "recipient@example.com"
 		text := "This is a test email."
 
-		monkey.Patch(smtp.SendMail, func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		monkey.PatchInstanceMethod(reflect.TypeOf(&sender.SMTPPool{}), "Send", func(pool *sender.SMTPPool, from string, to []string, msg []byte) error {
 			return nil
 		})
-		defer monkey.Unpatch(usecases.SendNotification)
+		defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&sender.SMTPPool{}), "Send")
 
-		err := smtpClient.Send(email, text)
+		err := smtpClient.Send(domain.Message{Recipient: email, Text: text})
 
 		assert.Nil(t, err)
 	})
@@ -44,15 +49,109 @@ func TestSendEmail(t *testing.T) {
 		email := "recipient@example.com"
 		text := "This is a test email."
 
-		monkey.Patch(smtp.SendMail, func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		monkey.PatchInstanceMethod(reflect.TypeOf(&sender.SMTPPool{}), "Send", func(pool *sender.SMTPPool, from string, to []string, msg []byte) error {
 			return errors.New("failed to send notification")
 		})
-		defer monkey.Unpatch(smtp.SendMail)
-		err := smtpClient.Send(email, text)
+		defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&sender.SMTPPool{}), "Send")
+		err := smtpClient.Send(domain.Message{Recipient: email, Text: text})
 
 		assert.EqualError(t, err, "failed to send notification")
 	})
 
+	t.Run("MultipartMessage", func(t *testing.T) {
+		smtpClient := sender.NewSmtpClient(server.SMTPConfig{
+			Host:            "smtp.example.com",
+			Port:            587,
+			Username:        "testuser",
+			Password:        "testpass",
+			From:            "alertas@example.com",
+			FromName:        "Alertas Entregas",
+			ReplyTo:         "soporte@example.com",
+			ListUnsubscribe: "mailto:unsubscribe@example.com",
+		})
+
+		var sentFrom string
+		var sentMessage []byte
+		monkey.PatchInstanceMethod(reflect.TypeOf(&sender.SMTPPool{}), "Send", func(pool *sender.SMTPPool, from string, to []string, msg []byte) error {
+			sentFrom = from
+			sentMessage = msg
+			return nil
+		})
+		defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&sender.SMTPPool{}), "Send")
+
+		err := smtpClient.Send(domain.Message{
+			Recipient: "recipient@example.com",
+			Subject:   "Entrega retrasada por clima ☔",
+			Text:      "Hola! Lluvia mañana.",
+			HTML:      "<p>Hola! Lluvia mañana.</p>",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "alertas@example.com", sentFrom)
+
+		parsed, err := mail.ReadMessage(bytes.NewReader(sentMessage))
+		assert.NoError(t, err)
+
+		subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+		assert.NoError(t, err)
+		assert.Equal(t, "Entrega retrasada por clima ☔", subject)
+		from, err := parsed.Header.AddressList("From")
+		assert.NoError(t, err)
+		assert.Equal(t, []*mail.Address{{Name: "Alertas Entregas", Address: "alertas@example.com"}}, from)
+		assert.Equal(t, "soporte@example.com", parsed.Header.Get("Reply-To"))
+		assert.Equal(t, "<mailto:unsubscribe@example.com>", parsed.Header.Get("List-Unsubscribe"))
+		assert.Regexp(t, `^<[0-9a-f]{32}@example\.com>$`, parsed.Header.Get("Message-ID"))
+		_, err = parsed.Header.Date()
+		assert.NoError(t, err)
+
+		mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+		assert.NoError(t, err)
+		assert.Equal(t, "multipart/alternative", mediaType)
+
+		parts := multipart.NewReader(parsed.Body, params["boundary"])
+		var bodies []string
+		for {
+			part, err := parts.NextPart()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			content, _ := io.ReadAll(part)
+			bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(content))
+		}
+		assert.Equal(t, []string{
+			"text/plain; charset=UTF-8: Hola! Lluvia mañana.",
+			"text/html; charset=UTF-8: <p>Hola! Lluvia mañana.</p>",
+		}, bodies)
+	})
+
+	t.Run("UnsubscribeHeaders", func(t *testing.T) {
+		smtpClient := sender.NewSmtpClient(server.SMTPConfig{
+			Username:        "testuser",
+			From:            "alertas@example.com",
+			ListUnsubscribe: "mailto:unsubscribe@example.com",
+		})
+
+		var sentMessage []byte
+		monkey.PatchInstanceMethod(reflect.TypeOf(&sender.SMTPPool{}), "Send", func(pool *sender.SMTPPool, from string, to []string, msg []byte) error {
+			sentMessage = msg
+			return nil
+		})
+		defer monkey.UnpatchInstanceMethod(reflect.TypeOf(&sender.SMTPPool{}), "Send")
+
+		err := smtpClient.Send(domain.Message{
+			Recipient:      "recipient@example.com",
+			Text:           "Hola!",
+			UnsubscribeURL: "https://notifier.example.com/unsubscribe?token=abc.def",
+		})
+
+		assert.NoError(t, err)
+		parsed, err := mail.ReadMessage(bytes.NewReader(sentMessage))
+		assert.NoError(t, err)
+		assert.Equal(t, "<https://notifier.example.com/unsubscribe?token=abc.def>, <mailto:unsubscribe@example.com>", parsed.Header.Get("List-Unsubscribe"))
+		assert.Equal(t, "List-Unsubscribe=One-Click", parsed.Header.Get("List-Unsubscribe-Post"))
+	})
+
 	t.Run("NotFoundError", func(t *testing.T) {
 
 		expected_message := "Not Found: Resource not found"
@@ -63,3 +162,34 @@ func TestSendEmail(t *testing.T) {
 		}
 	})
 }
+
+func TestBuildMIMEMessage(t *testing.T) {
+	t.Run("PlainText", func(t *testing.T) {
+		date := time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC)
+		message, err := sender.BuildMIMEMessage(sender.Mail{
+			From:      mail.Address{Address: "alertas@example.com"},
+			To:        mail.Address{Address: "buyer@example.com"},
+			Subject:   "Aviso",
+			Text:      "Línea uno\nLínea dos",
+			MessageID: "<n-1@example.com>",
+			Date:      date,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "From: <alertas@example.com>\r\n"+
+			"To: <buyer@example.com>\r\n"+
+			"Subject: Aviso\r\n"+
+			"Date: Thu, 10 Oct 2024 12:00:00 +0000\r\n"+
+			"Message-ID: <n-1@example.com>\r\n"+
+			"MIME-Version: 1.0\r\n"+
+			"Content-Type: text/plain; charset=UTF-8\r\n"+
+			"Content-Transfer-Encoding: quoted-printable\r\n"+
+			"\r\n"+
+			"L=C3=ADnea uno\r\nL=C3=ADnea dos", string(message))
+	})
+
+	t.Run("MessageID", func(t *testing.T) {
+		assert.Regexp(t, `^<[0-9a-f]{32}@example\.com>$`, sender.NewMessageID("alertas@example.com"))
+		assert.Regexp(t, `^<[0-9a-f]{32}@localhost>$`, sender.NewMessageID("alertas"))
+	})
+}
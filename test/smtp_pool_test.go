@@ -0,0 +1,349 @@
+package service_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/sender"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTPServer is a minimal SMTP server that accepts a single AUTH
+// mechanism and records the connections and messages it receives.
+type fakeSMTPServer struct {
+	listener    net.Listener
+	tlsConfig   *tls.Config
+	implicitTLS bool
+	startTLS    bool
+	mechanism   string
+	username    string
+	password    string
+	stall       bool
+
+	mu              sync.Mutex
+	conns           []net.Conn
+	connections     int
+	authentications int
+	messages        []string
+	tlsMessages     int
+}
+
+func newFakeSMTPServer(t *testing.T, configure func(*fakeSMTPServer)) *fakeSMTPServer {
+	s := &fakeSMTPServer{mechanism: "PLAIN", username: "testuser", password: "testpass", tlsConfig: selfSignedTLSConfig(t)}
+	if configure != nil {
+		configure(s)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.implicitTLS {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+	s.listener = listener
+	t.Cleanup(func() {
+		listener.Close()
+		s.dropConnections()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.connections++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) config() server.SMTPConfig {
+	return server.SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     s.listener.Addr().(*net.TCPAddr).Port,
+		Username: s.username,
+		Password: s.password,
+		TLSMode:  sender.SMTPTLSNone,
+	}
+}
+
+func (s *fakeSMTPServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeSMTPServer) stats() (connections, authentications int, messages []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections, s.authentications, append([]string(nil), s.messages...)
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	secure := s.implicitTLS
+
+	text.PrintfLine("220 fake ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command, argument, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(command) {
+		case "EHLO", "HELO":
+			extensions := []string{"fake"}
+			if s.startTLS && !secure {
+				extensions = append(extensions, "STARTTLS")
+			}
+			extensions = append(extensions, "AUTH "+s.mechanism)
+			for i, extension := range extensions {
+				separator := "-"
+				if i == len(extensions)-1 {
+					separator = " "
+				}
+				text.PrintfLine("250%s%s", separator, extension)
+			}
+		case "STARTTLS":
+			text.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, text, secure = tlsConn, textproto.NewConn(tlsConn), true
+		case "AUTH":
+			if s.authenticate(text, argument) {
+				s.mu.Lock()
+				s.authentications++
+				s.mu.Unlock()
+				text.PrintfLine("235 authenticated")
+			} else {
+				text.PrintfLine("535 authentication failed")
+			}
+		case "MAIL":
+			if s.stall {
+				time.Sleep(5 * time.Second)
+				return
+			}
+			text.PrintfLine("250 ok")
+		case "RCPT", "RSET", "NOOP":
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 go ahead")
+			message, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(message))
+			if secure {
+				s.tlsMessages++
+			}
+			s.mu.Unlock()
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 unknown command")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) authenticate(text *textproto.Conn, argument string) bool {
+	mechanism, initial, _ := strings.Cut(argument, " ")
+	if mechanism != s.mechanism {
+		return false
+	}
+
+	challenge := func(prompt string) string {
+		text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+		line, _ := text.ReadLine()
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		return string(decoded)
+	}
+
+	switch mechanism {
+	case "PLAIN":
+		decoded, _ := base64.StdEncoding.DecodeString(initial)
+		return string(decoded) == "\x00"+s.username+"\x00"+s.password
+	case "LOGIN":
+		username := challenge("Username:")
+		password := challenge("Password:")
+		return username == s.username && password == s.password
+	case "CRAM-MD5":
+		nonce := "<1896.697170952@fake>"
+		username, digest, _ := strings.Cut(challenge(nonce), " ")
+		mac := hmac.New(md5.New, []byte(s.password))
+		mac.Write([]byte(nonce))
+		return username == s.username && digest == hex.EncodeToString(mac.Sum(nil))
+	}
+	return false
+}
+
+func selfSignedTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &certificate, &certificate, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func TestSMTPPool(t *testing.T) {
+	message := []byte("Subject: Aviso\r\n\r\nHola\r\n")
+
+	t.Run("ReusesConnection", func(t *testing.T) {
+		smtpServer := newFakeSMTPServer(t, nil)
+		pool := sender.NewSMTPPool(smtpServer.config())
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, pool.Send("alertas@example.com", []string{"buyer@example.com"}, message))
+		}
+		pool.Close()
+
+		connections, authentications, messages := smtpServer.stats()
+		assert.Equal(t, 1, connections)
+		assert.Equal(t, 1, authentications)
+		assert.Len(t, messages, 3)
+		assert.Contains(t, messages[0], "Hola")
+	})
+
+	t.Run("ReconnectsAfterConnectionLost", func(t *testing.T) {
+		smtpServer := newFakeSMTPServer(t, nil)
+		pool := sender.NewSMTPPool(smtpServer.config())
+
+		assert.NoError(t, pool.Send("alertas@example.com", []string{"buyer@example.com"}, message))
+		smtpServer.dropConnections()
+		assert.NoError(t, pool.Send("alertas@example.com", []string{"buyer@example.com"}, message))
+
+		connections, _, messages := smtpServer.stats()
+		assert.Equal(t, 2, connections)
+		assert.Len(t, messages, 2)
+	})
+
+	t.Run("AuthMechanisms", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			auth      string
+			mechanism string
+		}{
+			{"Plain", sender.SMTPAuthPlain, "PLAIN"},
+			{"Login", sender.SMTPAuthLogin, "LOGIN"},
+			{"CRAMMD5", sender.SMTPAuthCRAMMD5, "CRAM-MD5"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				smtpServer := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.mechanism = tt.mechanism })
+				config := smtpServer.config()
+				config.Auth = tt.auth
+
+				err := sender.NewSMTPPool(config).Send("alertas@example.com", []string{"buyer@example.com"}, message)
+
+				assert.NoError(t, err)
+				_, authentications, messages := smtpServer.stats()
+				assert.Equal(t, 1, authentications)
+				assert.Len(t, messages, 1)
+
+				config.Password = "wrong"
+				err = sender.NewSMTPPool(config).Send("alertas@example.com", []string{"buyer@example.com"}, message)
+
+				assert.ErrorContains(t, err, "error authenticating with smtp server")
+			})
+		}
+	})
+
+	t.Run("UnsupportedAuth", func(t *testing.T) {
+		smtpServer := newFakeSMTPServer(t, nil)
+		config := smtpServer.config()
+		config.Auth = "xoauth2"
+
+		err := sender.NewSMTPPool(config).Send("alertas@example.com", []string{"buyer@example.com"}, message)
+
+		assert.EqualError(t, err, `smtp auth "xoauth2" is not supported`)
+	})
+
+	t.Run("ImplicitTLS", func(t *testing.T) {
+		smtpServer := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.implicitTLS = true })
+		config := smtpServer.config()
+		config.TLSMode = sender.SMTPTLSImplicit
+		pool := sender.NewSMTPPool(config)
+		pool.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+		assert.NoError(t, pool.Send("alertas@example.com", []string{"buyer@example.com"}, message))
+		assert.Equal(t, 1, smtpServer.tlsMessages)
+	})
+
+	t.Run("StartTLS", func(t *testing.T) {
+		smtpServer := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.startTLS = true })
+		config := smtpServer.config()
+		config.TLSMode = sender.SMTPTLSStartTLSRequired
+		pool := sender.NewSMTPPool(config)
+		pool.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+
+		assert.NoError(t, pool.Send("alertas@example.com", []string{"buyer@example.com"}, message))
+		assert.Equal(t, 1, smtpServer.tlsMessages)
+	})
+
+	t.Run("StartTLSRequiredNotOffered", func(t *testing.T) {
+		smtpServer := newFakeSMTPServer(t, nil)
+		config := smtpServer.config()
+		config.TLSMode = sender.SMTPTLSStartTLSRequired
+
+		err := sender.NewSMTPPool(config).Send("alertas@example.com", []string{"buyer@example.com"}, message)
+
+		assert.EqualError(t, err, "smtp server does not support STARTTLS")
+		_, _, messages := smtpServer.stats()
+		assert.Empty(t, messages)
+	})
+
+	t.Run("SendTimeout", func(t *testing.T) {
+		smtpServer := newFakeSMTPServer(t, func(s *fakeSMTPServer) { s.stall = true })
+		config := smtpServer.config()
+		config.SendTimeoutSeconds = 1
+
+		start := time.Now()
+		err := sender.NewSMTPPool(config).Send("alertas@example.com", []string{"buyer@example.com"}, message)
+
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), 3*time.Second)
+	})
+}
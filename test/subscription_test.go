@@ -0,0 +1,339 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+)
+
+const unsubscribeSecret = "unsubscribe-secret"
+
+func newSubscriptionRouter(t *testing.T, repo domain.NotificationRepository) *mux.Router {
+	buyerHandler := infrastructure.NewBuyerHandler(repo, newMessageRenderer(t), server.Config{
+		UnsubscribeConfig: server.UnsubscribeConfig{Secret: unsubscribeSecret, BaseURL: "https://notifier.example.com"},
+	})
+	router := mux.NewRouter()
+	router.HandleFunc("/buyers/{email}/subscription", buyerHandler.Subscribe).Methods(http.MethodPut)
+	router.HandleFunc("/buyers/{email}/subscription", buyerHandler.Unsubscribe).Methods(http.MethodDelete)
+	router.HandleFunc("/unsubscribe", buyerHandler.UnsubscribePage).Methods(http.MethodGet)
+	router.HandleFunc("/unsubscribe", buyerHandler.UnsubscribeOneClick).Methods(http.MethodPost)
+	return router
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		token := usecases.UnsubscribeToken("a@example.com", unsubscribeSecret)
+
+		email, err := usecases.VerifyUnsubscribeToken(token, unsubscribeSecret)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "a@example.com", email)
+	})
+
+	t.Run("NormalizesEmail", func(t *testing.T) {
+		token := usecases.UnsubscribeToken(" Buyer@Example.com", unsubscribeSecret)
+
+		email, err := usecases.VerifyUnsubscribeToken(token, unsubscribeSecret)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "buyer@example.com", email)
+		assert.Equal(t, usecases.UnsubscribeToken("buyer@example.com", unsubscribeSecret), token)
+	})
+
+	t.Run("InvalidTokens", func(t *testing.T) {
+		token := usecases.UnsubscribeToken("a@example.com", unsubscribeSecret)
+		encodedEmail, _, _ := strings.Cut(usecases.UnsubscribeToken("b@example.com", unsubscribeSecret), ".")
+		_, signature, _ := strings.Cut(token, ".")
+		otherEmail := encodedEmail + "." + signature
+
+		tests := []struct {
+			name   string
+			token  string
+			secret string
+		}{
+			{"WrongSecret", token, "other-secret"},
+			{"EmptySecret", usecases.UnsubscribeToken("a@example.com", ""), ""},
+			{"OtherEmail", otherEmail, unsubscribeSecret},
+			{"Malformed", "not-a-token", unsubscribeSecret},
+			{"BadEncoding", "a@example.com.signature", unsubscribeSecret},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := usecases.VerifyUnsubscribeToken(tt.token, tt.secret)
+
+				assert.EqualError(t, err, "invalid unsubscribe token")
+			})
+		}
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		links := usecases.UnsubscribeLinks{Secret: unsubscribeSecret, BaseURL: "https://notifier.example.com/"}
+
+		link, err := url.Parse(links.URL("a@example.com", ""))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "https://notifier.example.com/unsubscribe", link.Scheme+"://"+link.Host+link.Path)
+		email, err := usecases.VerifyUnsubscribeToken(link.Query().Get("token"), unsubscribeSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, "a@example.com", email)
+		assert.Empty(t, link.Query().Get("locale"))
+	})
+
+	t.Run("URLWithLocale", func(t *testing.T) {
+		links := usecases.UnsubscribeLinks{Secret: unsubscribeSecret, BaseURL: "https://notifier.example.com"}
+
+		link, err := url.Parse(links.URL("a@example.com", "pt-BR"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "pt-BR", link.Query().Get("locale"))
+	})
+
+	t.Run("URLDisabled", func(t *testing.T) {
+		assert.Empty(t, usecases.UnsubscribeLinks{BaseURL: "https://notifier.example.com"}.URL("a@example.com", ""))
+		assert.Empty(t, usecases.UnsubscribeLinks{Secret: unsubscribeSecret}.URL("a@example.com", ""))
+	})
+}
+
+func TestSubscriptionHandler(t *testing.T) {
+	t.Run("Unsubscribe", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newSubscriptionRouter(t, mockRepo)
+
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockRepo.EXPECT().SaveOptOut(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, optOut domain.OptOut) error {
+			assert.Equal(t, "a@example.com", optOut.Email)
+			assert.Equal(t, domain.OptOutSourceAPI, optOut.Source)
+			return nil
+		})
+
+		req := httptest.NewRequest(http.MethodDelete, "/buyers/a@example.com/subscription", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"subscribed":false,"source":"api"`)
+	})
+
+	t.Run("UnsubscribeMixedCase", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newSubscriptionRouter(t, mockRepo)
+
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "buyer@example.com").Return(nil, nil)
+		mockRepo.EXPECT().SaveOptOut(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, optOut domain.OptOut) error {
+			assert.Equal(t, "buyer@example.com", optOut.Email)
+			return nil
+		})
+
+		req := httptest.NewRequest(http.MethodDelete, "/buyers/Buyer@Example.com/subscription", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"email":"buyer@example.com"`)
+	})
+
+	t.Run("AlreadyUnsubscribed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newSubscriptionRouter(t, mockRepo)
+
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(&domain.OptOut{
+			Email: "a@example.com", Source: domain.OptOutSourceLink, CreatedAt: time.Date(2024, 10, 10, 12, 0, 0, 0, time.UTC),
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/buyers/a@example.com/subscription", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"source":"link","unsubscribed_at":"2024-10-10T12:00:00Z"`)
+	})
+
+	t.Run("Subscribe", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newSubscriptionRouter(t, mockRepo)
+
+		mockRepo.EXPECT().DeleteOptOut(gomock.Any(), "a@example.com").Return(nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/buyers/a@example.com/subscription", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"email":"a@example.com","subscribed":true}`, w.Body.String())
+	})
+
+	t.Run("InvalidEmail", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		router := newSubscriptionRouter(t, mocks.NewMockNotificationRepository(ctrl))
+
+		req := httptest.NewRequest(http.MethodDelete, "/buyers/not-an-email/subscription", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("UnsubscribePage", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		router := newSubscriptionRouter(t, mocks.NewMockNotificationRepository(ctrl))
+		token := usecases.UnsubscribeToken("a@example.com", unsubscribeSecret)
+
+		req := httptest.NewRequest(http.MethodGet, "/unsubscribe?token="+url.QueryEscape(token), nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "a@example.com")
+		assert.Contains(t, w.Body.String(), `<form method="post" action="?token=`+token+`">`)
+	})
+
+	t.Run("UnsubscribePageLocale", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		router := newSubscriptionRouter(t, mocks.NewMockNotificationRepository(ctrl))
+		token := usecases.UnsubscribeToken("a@example.com", unsubscribeSecret)
+
+		req := httptest.NewRequest(http.MethodGet, "/unsubscribe?token="+url.QueryEscape(token), nil)
+		req.Header.Set("Accept-Language", "en-US,en;q=0.9,es;q=0.8")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "Do you want to stop receiving weather delay warnings")
+	})
+
+	t.Run("UnsubscribeOneClick", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		router := newSubscriptionRouter(t, mockRepo)
+
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockRepo.EXPECT().SaveOptOut(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, optOut domain.OptOut) error {
+			assert.Equal(t, domain.OptOutSourceLink, optOut.Source)
+			return nil
+		})
+
+		token := usecases.UnsubscribeToken("a@example.com", unsubscribeSecret)
+		req := httptest.NewRequest(http.MethodPost, "/unsubscribe?token="+url.QueryEscape(token), strings.NewReader("List-Unsubscribe=One-Click"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "ya no enviaremos avisos")
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		router := newSubscriptionRouter(t, mocks.NewMockNotificationRepository(ctrl))
+		token := usecases.UnsubscribeToken("a@example.com", "other-secret")
+
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			req := httptest.NewRequest(method, "/unsubscribe?token="+url.QueryEscape(token), nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), "Invalid token: is not a valid unsubscribe token")
+		}
+	})
+}
+
+func TestSendNotificationOptOut(t *testing.T) {
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	request := usecases.RequestDataNotification{Email: "a@example.com", Location: usecases.Location{Latitude: "1", Longitude: "2"}}
+
+	expectForecast := func(mockForecastService *mocks.MockForecastService, mockRepo *mocks.MockNotificationRepository) {
+		mockForecastService.EXPECT().MaxForecastDays().Return(3)
+		mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1063, Description: "Lluvia"}, nil)
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+	}
+
+	t.Run("Suppressed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		expectForecast(mockForecastService, mockRepo)
+
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(&domain.OptOut{Email: "a@example.com", Source: domain.OptOutSourceAPI}, nil)
+		var saved domain.Notification
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+			saved = notification
+			return nil
+		})
+
+		response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+		assert.True(t, response.BuyerNotification)
+		assert.Equal(t, domain.DeliveryStatusSuppressed, response.DeliveryStatus)
+		assert.Equal(t, saved.ID, response.NotificationID)
+		assert.Equal(t, domain.DeliveryStatusSuppressed, saved.DeliveryStatus)
+		assert.Empty(t, saved.Channels)
+	})
+
+	t.Run("UnsubscribeLink", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		expectForecast(mockForecastService, mockRepo)
+
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(nil, nil)
+		var saved domain.Notification
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+			saved = notification
+			return nil
+		})
+
+		links := usecases.UnsubscribeLinks{Secret: unsubscribeSecret, BaseURL: "https://notifier.example.com"}
+		response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{Unsubscribe: links})
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.DeliveryStatusPending, response.DeliveryStatus)
+		link := links.URL("a@example.com", "")
+		assert.Equal(t, link, saved.Channels[0].UnsubscribeURL)
+		assert.True(t, strings.HasSuffix(saved.Channels[0].Message, "Para dejar de recibir estos avisos: "+link))
+		assert.Contains(t, saved.Channels[0].HTML, `<a href="`+link+`">Dejar de recibir estos avisos</a>`)
+	})
+}
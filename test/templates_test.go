@@ -0,0 +1,276 @@
+package service_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/templates"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMessageRenderer(t *testing.T) *templates.Catalog {
+	catalog, err := templates.NewCatalog(templates.Embedded(), "es")
+	require.NoError(t, err)
+	return catalog
+}
+
+func TestMessageTemplates(t *testing.T) {
+	catalog := newMessageRenderer(t)
+	data := domain.MessageData{DeliveryDate: "2024-10-12", Tomorrow: true, ForecastCode: 1189, ForecastDescription: "Moderate rain"}
+
+	t.Run("Spanish", func(t *testing.T) {
+		message, err := catalog.Render("es", data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "es", message.Locale)
+		assert.Equal(t, "Entrega retrasada por clima", message.Subject)
+		assert.Contains(t, message.Text, "para mañana")
+		assert.Contains(t, message.HTML, "<strong>Lluvia moderada</strong>")
+		assert.Equal(t, "Tu entrega programada para mañana puede retrasarse por clima: Lluvia moderada.", message.SMS)
+	})
+
+	t.Run("PortugueseFromRegion", func(t *testing.T) {
+		message, err := catalog.Render("pt-BR", data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "pt", message.Locale)
+		assert.Contains(t, message.Text, "amanhã")
+		assert.Equal(t, "Sua entrega de amanhã pode atrasar pelo clima: Chuva moderada.", message.SMS)
+	})
+
+	t.Run("English", func(t *testing.T) {
+		data := data
+		data.Tomorrow = false
+		message, err := catalog.Render("en_US", data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "en", message.Locale)
+		assert.Equal(t, "Weather may delay your delivery", message.Subject)
+		assert.Contains(t, message.Text, "on 2024-10-12")
+		assert.Contains(t, message.HTML, "<strong>Moderate rain</strong>")
+	})
+
+	t.Run("OpenMeteoCondition", func(t *testing.T) {
+		data := data
+		data.ForecastCode = 95
+		data.ForecastDescription = "Thunderstorm"
+		message, err := catalog.Render("es", data)
+
+		assert.NoError(t, err)
+		assert.Contains(t, message.SMS, "Tormenta")
+	})
+
+	t.Run("UnknownConditionKeepsDescription", func(t *testing.T) {
+		data := data
+		data.ForecastCode = 9999
+		message, err := catalog.Render("en", data)
+
+		assert.NoError(t, err)
+		assert.Contains(t, message.SMS, "Moderate rain")
+	})
+
+	t.Run("UnknownLocaleUsesDefault", func(t *testing.T) {
+		message, err := catalog.Render("fr", data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "es", message.Locale)
+	})
+
+	t.Run("HTMLIsEscaped", func(t *testing.T) {
+		data := data
+		data.ForecastCode = 9999
+		data.ForecastDescription = "<script>alert(1)</script>"
+		message, err := catalog.Render("es", data)
+
+		assert.NoError(t, err)
+		assert.NotContains(t, message.HTML, "<script>")
+		assert.Contains(t, message.HTML, "&lt;script&gt;")
+	})
+
+	t.Run("SMSTruncated", func(t *testing.T) {
+		data := data
+		data.Tomorrow = false
+		data.ForecastCode = 9999
+		data.ForecastDescription = strings.Repeat("Tormenta muy fuerte ", 10)
+		message, err := catalog.Render("es", data)
+
+		assert.NoError(t, err)
+		length, limit := templates.SMSLength(message.SMS)
+		assert.Equal(t, templates.SMSMaxLength, limit)
+		assert.LessOrEqual(t, length, limit)
+		assert.True(t, strings.HasPrefix(message.SMS, "Tu entrega programada para el 2024-10-12 puede retrasarse por clima: Tormenta"))
+		assert.True(t, strings.HasSuffix(message.SMS, "...."))
+	})
+
+	t.Run("SMSTruncatedUCS2", func(t *testing.T) {
+		data := data
+		data.ForecastCode = 9999
+		data.ForecastDescription = strings.Repeat("Tempestade muito forte ", 10)
+		message, err := catalog.Render("pt", data)
+
+		assert.NoError(t, err)
+		length, limit := templates.SMSLength(message.SMS)
+		assert.Equal(t, templates.SMSMaxLengthUCS2, limit)
+		assert.LessOrEqual(t, length, limit)
+		assert.True(t, strings.HasPrefix(message.SMS, "Sua entrega de amanhã pode atrasar pelo clima: Tempestade"))
+		assert.True(t, strings.HasSuffix(message.SMS, "...."))
+	})
+
+	t.Run("SMSCutWhenDescriptionIsNotEnough", func(t *testing.T) {
+		data := data
+		data.Tomorrow = false
+		data.DeliveryDate = strings.Repeat("2024-10-12 ", 10)
+		data.ForecastDescription = "Chuva"
+		message, err := catalog.Render("pt", data)
+
+		assert.NoError(t, err)
+		length, limit := templates.SMSLength(message.SMS)
+		assert.LessOrEqual(t, length, limit)
+		assert.True(t, strings.HasSuffix(message.SMS, "..."))
+	})
+}
+
+func TestSMSLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		length int
+		limit  int
+	}{
+		{"GSM", "Tu entrega de mañana", 20, templates.SMSMaxLength},
+		{"GSMExtension", "Precio 10€", 11, templates.SMSMaxLength},
+		{"UCS2", "Sua entrega de amanhã", 21, templates.SMSMaxLengthUCS2},
+		{"UCS2SurrogatePair", "Chuva ☔🌧", 9, templates.SMSMaxLengthUCS2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			length, limit := templates.SMSLength(tt.text)
+
+			assert.Equal(t, tt.length, length)
+			assert.Equal(t, tt.limit, limit)
+		})
+	}
+}
+
+func TestMessageTemplatesCatalog(t *testing.T) {
+	t.Run("MissingDefaultLocale", func(t *testing.T) {
+		_, err := templates.NewCatalog(templates.Embedded(), "de")
+
+		assert.EqualError(t, err, `templates for default locale "de" not found`)
+	})
+
+	t.Run("UnsubscribePage", func(t *testing.T) {
+		catalog := newMessageRenderer(t)
+
+		page, err := catalog.RenderUnsubscribePage("pt-BR", domain.UnsubscribePageData{Email: "a@example.com", Token: "token", Locale: "pt-BR"})
+
+		assert.NoError(t, err)
+		assert.Contains(t, page, `<html lang="pt">`)
+		assert.Contains(t, page, "Você quer deixar de receber avisos")
+		assert.Contains(t, page, `action="?token=token&locale=pt-BR"`)
+	})
+
+	t.Run("UnsubscribePageFallsBackToDefault", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"es/subject.txt.tmpl":      {Data: []byte("Asunto")},
+			"es/email.txt.tmpl":        {Data: []byte("Hola")},
+			"es/sms.txt.tmpl":          {Data: []byte("Hola")},
+			"es/unsubscribe.html.tmpl": {Data: []byte("Baja {{.Email}}")},
+			"en/subject.txt.tmpl":      {Data: []byte("Subject")},
+			"en/email.txt.tmpl":        {Data: []byte("Hi")},
+			"en/sms.txt.tmpl":          {Data: []byte("Hi")},
+		}
+		catalog, err := templates.NewCatalog(fsys, "es")
+		require.NoError(t, err)
+
+		page, err := catalog.RenderUnsubscribePage("en", domain.UnsubscribePageData{Email: "a@example.com"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Baja a@example.com", page)
+	})
+
+	t.Run("MissingTemplate", func(t *testing.T) {
+		fsys := fstest.MapFS{"es/subject.txt.tmpl": {Data: []byte("Asunto")}}
+
+		_, err := templates.NewCatalog(fsys, "es")
+
+		assert.ErrorContains(t, err, "es/email.txt.tmpl")
+	})
+
+	t.Run("HotReload", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplates := func(subject string) {
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "es"), 0o755))
+			files := map[string]string{
+				"subject.txt.tmpl": subject,
+				"email.txt.tmpl":   "Hola {{.Email}}",
+				"sms.txt.tmpl":     "Hola",
+			}
+			for name, content := range files {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "es", name), []byte(content), 0o644))
+			}
+		}
+		writeTemplates("Antes")
+
+		catalog, err := templates.NewDirCatalog(dir, "es")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		catalog.Watch(ctx, dir, 10*time.Millisecond)
+
+		time.Sleep(20 * time.Millisecond)
+		writeTemplates("Después")
+		later := time.Now().Add(time.Second)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "es", "subject.txt.tmpl"), later, later))
+
+		assert.Eventually(t, func() bool {
+			message, err := catalog.Render("es", domain.MessageData{Email: "a@example.com"})
+			return err == nil && message.Subject == "Después"
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestSendNotificationLocale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockNotificationRepository(ctrl)
+	mockForecastService := mocks.NewMockForecastService(ctrl)
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	mockForecastService.EXPECT().MaxForecastDays().Return(3)
+	mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1063, Description: "Chuva"}, nil)
+	mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+	mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+	mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+	mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(nil, nil)
+
+	var saved domain.Notification
+	mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+		saved = notification
+		return nil
+	})
+
+	request := usecases.RequestDataNotification{Email: "a@example.com", Locale: "pt-BR", Location: usecases.Location{Latitude: "1", Longitude: "2"}}
+	response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "pt", response.Locale)
+	assert.Equal(t, "pt", saved.Locale)
+	assert.Equal(t, "Sua entrega pode atrasar por causa do clima", saved.Channels[0].Subject)
+	assert.Contains(t, saved.Channels[0].Message, "amanhã")
+	assert.Contains(t, saved.Channels[0].HTML, "<strong>Possibilidade de chuva irregular</strong>")
+}
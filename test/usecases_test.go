@@ -81,7 +81,7 @@ func TestSendNotification(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockNotificationRepository(ctrl)
-		mockSender := mocks.NewMockNotificationSender(ctrl)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil).AnyTimes()
 		mockForecastService := mocks.NewMockForecastService(ctrl)
 
 		requestData := usecases.RequestDataNotification{
@@ -94,21 +94,168 @@ func TestSendNotification(t *testing.T) {
 			Description: "Sunny",
 		}
 
+		tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
 		mockForecastService.EXPECT().FetchForecastByLocation(
-			requestData.Location.Longitude, requestData.Location.Latitude, "2",
+			domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.006}, tomorrow,
 		).Return(expectedForecast, nil).Times(1)
 
 		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"123"}, nil).Times(1)
-		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+			assert.Equal(t, domain.DeliveryStatusPending, notification.DeliveryStatus)
+			assert.NotEmpty(t, notification.ID)
+			assert.Contains(t, notification.Channels[0].Message, "mañana")
+			return nil
+		}).Times(1)
 
-		mockSender.EXPECT().Send(requestData.Email, gomock.Any()).Times(1)
-
-		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, mockSender)
+		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
 
 		assert.NoError(t, err)
 		assert.NotNil(t, response)
+		assert.Equal(t, "pending", response.DeliveryStatus)
+		assert.NotEmpty(t, response.NotificationID)
 		assert.Equal(t, float64(123), response.ForecastCode)
 		assert.Equal(t, "Sunny", response.ForecastDescription)
+		assert.Equal(t, tomorrow, response.DeliveryDate)
+	})
+
+	t.Run("DeliveryDate", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil).AnyTimes()
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		deliveryDate := time.Now().AddDate(0, 0, 4).Format(usecases.DeliveryDateLayout)
+		requestData := usecases.RequestDataNotification{
+			Email:        "test@example.com",
+			Location:     usecases.Location{Latitude: "40.7128", Longitude: "-74.0060"},
+			DeliveryDate: deliveryDate,
+		}
+
+		mockForecastService.EXPECT().MaxForecastDays().Return(6).AnyTimes()
+		mockForecastService.EXPECT().FetchForecastByLocation(
+			domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.006}, deliveryDate,
+		).Return(&third_party.ForecastServiceResponse{Code: 123, Description: "Lluvia"}, nil).Times(1)
+
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"123"}, nil).Times(1)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+			assert.Equal(t, deliveryDate, notification.DeliveryDate)
+			assert.Contains(t, notification.Channels[0].Message, "para el "+deliveryDate)
+			return nil
+		}).Times(1)
+
+		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, deliveryDate, response.DeliveryDate)
+	})
+
+	t.Run("DeliveryWindow", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil).AnyTimes()
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+		requestData := usecases.RequestDataNotification{
+			Email:          "test@example.com",
+			Location:       usecases.Location{Latitude: "40.7128", Longitude: "-74.0060"},
+			DeliveryWindow: &usecases.DeliveryWindow{Start: "14:00", End: "16:00"},
+		}
+
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
+		mockForecastService.EXPECT().FetchForecastByLocation(
+			domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.006}, tomorrow,
+		).Return(&third_party.ForecastServiceResponse{
+			Code:        1000,
+			Description: "Sunny",
+			Hours: []third_party.HourlyForecast{
+				{Time: tomorrow + "T09:00", Code: 1063, Description: "Patchy rain possible"},
+				{Time: tomorrow + "T15:00", Code: 1195, Description: "Heavy rain"},
+			},
+		}, nil).Times(1)
+
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063", "1195"}, nil).Times(1)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+			assert.Equal(t, []string{tomorrow + "T15:00:00Z"}, notification.TriggeredHours)
+			assert.Contains(t, notification.Channels[0].Message, "Lluvia intensa")
+			return nil
+		}).Times(1)
+
+		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+		assert.True(t, response.BuyerNotification)
+		assert.Len(t, response.TriggeredHours, 1)
+		assert.Equal(t, float64(1195), response.TriggeredHours[0].ForecastCode)
+	})
+
+	t.Run("FiredRule", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+
+		tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+		requestData := usecases.RequestDataNotification{
+			Email:    "test@example.com",
+			Location: usecases.Location{Latitude: "40.7128", Longitude: "-74.0060"},
+		}
+
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
+		mockForecastService.EXPECT().FetchForecastByLocation(
+			domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.006}, tomorrow,
+		).Return(&third_party.ForecastServiceResponse{Code: 1000, Description: "Soleado", MaxWindKph: 62}, nil).Times(1)
+
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil).Times(1)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return([]domain.NotificationRule{
+			{Name: "strong-wind", Conditions: []domain.RuleCondition{{Metric: domain.MetricWindKph, Operator: "gte", Value: 50}}},
+			{Name: "heavy-rain", Conditions: []domain.RuleCondition{{Metric: domain.MetricPrecipMm, Operator: "gt", Value: 20}}},
+		}, nil).Times(1)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+			assert.Equal(t, []string{"strong-wind"}, notification.FiredRules)
+			assert.True(t, notification.BuyerNotification)
+			return nil
+		}).Times(1)
+
+		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.NoError(t, err)
+		assert.True(t, response.BuyerNotification)
+		assert.Equal(t, []string{"strong-wind"}, response.FiredRules)
+	})
+
+	t.Run("DeliveryDateBeyondHorizon", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockForecastService := mocks.NewMockForecastService(ctrl)
+		requestData := usecases.RequestDataNotification{
+			Email:        "test@example.com",
+			Location:     usecases.Location{Latitude: "40.7128", Longitude: "-74.0060"},
+			DeliveryDate: time.Now().AddDate(0, 0, 3).Format(usecases.DeliveryDateLayout),
+		}
+
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
+
+		response, err := usecases.SendNotification(requestData, mockForecastService, nil, newMessageRenderer(t), usecases.NotificationSettings{})
+
+		assert.Nil(t, response)
+		assert.IsType(t, &domain.ValidationError{}, err)
+		assert.EqualError(t, err, "Invalid delivery_date: must be within the next 2 day(s)")
 	})
 
 	t.Run("ForecastError", func(t *testing.T) {
@@ -116,7 +263,7 @@ func TestSendNotification(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockNotificationRepository(ctrl)
-		mockSender := mocks.NewMockNotificationSender(ctrl)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil).AnyTimes()
 		mockForecastService := mocks.NewMockForecastService(ctrl)
 
 		requestData := usecases.RequestDataNotification{
@@ -124,11 +271,13 @@ func TestSendNotification(t *testing.T) {
 			Location: usecases.Location{Latitude: "40.7128", Longitude: "-74.0060"},
 		}
 
+		tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
 		mockForecastService.EXPECT().FetchForecastByLocation(
-			requestData.Location.Longitude, requestData.Location.Latitude, "2",
+			domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.006}, tomorrow,
 		).Return(nil, errors.New("failed to fetch forecast")).Times(1)
 
-		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, mockSender)
+		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
 
 		assert.Error(t, err)
 		assert.Nil(t, response)
@@ -141,7 +290,7 @@ func TestSendNotification(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockNotificationRepository(ctrl)
-		mockSender := mocks.NewMockNotificationSender(ctrl)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil).AnyTimes()
 		mockForecastService := mocks.NewMockForecastService(ctrl)
 
 		requestData := usecases.RequestDataNotification{
@@ -154,15 +303,18 @@ func TestSendNotification(t *testing.T) {
 			Description: "Sunny",
 		}
 
+		tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
 		mockForecastService.EXPECT().FetchForecastByLocation(
-			requestData.Location.Longitude, requestData.Location.Latitude, "2",
+			domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.006}, tomorrow,
 		).Return(expectedForecast, nil).Times(1)
 
 		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"123"}, nil).Times(1)
+		mockRepo.EXPECT().GetOptOut(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
 		mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).Return(errors.New("failed to save notification")).Times(1)
-		mockSender.EXPECT().Send(requestData.Email, gomock.Any()).Times(1)
 
-		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, mockSender)
+		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
 
 		assert.Error(t, err)
 		assert.Nil(t, response)
@@ -174,7 +326,7 @@ func TestSendNotification(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockNotificationRepository(ctrl)
-		mockSender := mocks.NewMockNotificationSender(ctrl)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil).AnyTimes()
 		mockForecastService := mocks.NewMockForecastService(ctrl)
 
 		requestData := usecases.RequestDataNotification{
@@ -187,8 +339,10 @@ func TestSendNotification(t *testing.T) {
 			Description: "Sunny",
 		}
 
+		tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
 		mockForecastService.EXPECT().FetchForecastByLocation(
-			requestData.Location.Longitude, requestData.Location.Latitude, "2",
+			domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.006}, tomorrow,
 		).Return(expectedForecast, nil).Times(1)
 
 		monkey.Patch(usecases.RequireBuyerNotification, func(context context.Context, repository domain.NotificationRepository, code float64) (*bool, error) {
@@ -196,7 +350,7 @@ func TestSendNotification(t *testing.T) {
 		})
 		defer monkey.Unpatch(usecases.RequireBuyerNotification)
 
-		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, mockSender)
+		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
 
 		assert.Error(t, err)
 		assert.Nil(t, response)
@@ -208,7 +362,7 @@ func TestSendNotification(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockNotificationRepository(ctrl)
-		mockSender := mocks.NewMockNotificationSender(ctrl)
+		mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil).AnyTimes()
 		mockForecastService := mocks.NewMockForecastService(ctrl)
 
 		requestData := usecases.RequestDataNotification{
@@ -221,8 +375,10 @@ func TestSendNotification(t *testing.T) {
 			Description: "Sunny",
 		}
 
+		tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+		mockForecastService.EXPECT().MaxForecastDays().Return(3).AnyTimes()
 		mockForecastService.EXPECT().FetchForecastByLocation(
-			requestData.Location.Longitude, requestData.Location.Latitude, "2",
+			domain.DeliveryLocation{Latitude: 40.7128, Longitude: -74.006}, tomorrow,
 		).Return(expectedForecast, nil).Times(1)
 
 		expectedRequiredBuyerNotification := true
@@ -236,7 +392,7 @@ func TestSendNotification(t *testing.T) {
 		})
 		defer monkey.Unpatch(usecases.CreateNotification)
 
-		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, mockSender)
+		response, err := usecases.SendNotification(requestData, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
 
 		assert.Error(t, err)
 		assert.Nil(t, response)
@@ -244,6 +400,117 @@ func TestSendNotification(t *testing.T) {
 	})
 }
 
+func TestResolveDeliveryDate(t *testing.T) {
+	now := time.Date(2024, 10, 10, 23, 30, 0, 0, time.UTC)
+
+	t.Run("DefaultsToTomorrow", func(t *testing.T) {
+		date, err := usecases.ResolveDeliveryDate("", 3, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-10-11", date)
+	})
+
+	t.Run("WithinHorizon", func(t *testing.T) {
+		date, err := usecases.ResolveDeliveryDate("2024-10-15", 6, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-10-15", date)
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		_, err := usecases.ResolveDeliveryDate("15/10/2024", 6, now)
+
+		assert.EqualError(t, err, "Invalid delivery_date: must be an ISO date (YYYY-MM-DD)")
+	})
+
+	t.Run("InThePast", func(t *testing.T) {
+		_, err := usecases.ResolveDeliveryDate("2024-10-09", 6, now)
+
+		assert.EqualError(t, err, "Invalid delivery_date: must not be in the past")
+	})
+
+	t.Run("CountsDaysInUTC", func(t *testing.T) {
+		// 22:30 of the 10th in São Paulo is already the 11th in UTC.
+		local := time.Date(2024, 10, 11, 1, 30, 0, 0, time.UTC).In(time.FixedZone("America/Sao_Paulo", -3*60*60))
+
+		date, err := usecases.ResolveDeliveryDate("", 3, local)
+		assert.NoError(t, err)
+		assert.Equal(t, "2024-10-12", date)
+
+		_, err = usecases.ResolveDeliveryDate("2024-10-10", 3, local)
+		assert.EqualError(t, err, "Invalid delivery_date: must not be in the past")
+	})
+}
+
+func TestEvaluateDeliveryWindow(t *testing.T) {
+	data := &third_party.ForecastServiceResponse{
+		Code:     1000,
+		Timezone: "America/Bogota",
+		Hours: []third_party.HourlyForecast{
+			{Time: "2024-10-11T12:00", Code: 1063, Description: "Lluvia"},
+			{Time: "2024-10-11T13:00", Code: 1000, Description: "Soleado"},
+			{Time: "2024-10-11T14:00", Code: 1195, Description: "Lluvia fuerte"},
+			{Time: "2024-10-11T18:00", Code: 1063, Description: "Lluvia"},
+		},
+	}
+
+	t.Run("MatchingHours", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063", "1195"}, nil).Times(1)
+
+		window := usecases.DeliveryWindow{Start: "13:30", End: "18:00"}
+		result, err := usecases.EvaluateDeliveryWindow(context.TODO(), mockRepo, data, window, "2024-10-11")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []usecases.TriggeredHour{
+			{Time: "2024-10-11T14:00:00-05:00", ForecastCode: 1195, ForecastDescription: "Lluvia fuerte"},
+		}, result)
+	})
+
+	t.Run("WindowInAnotherTimezone", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockRepo := mocks.NewMockNotificationRepository(ctrl)
+		mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil).Times(1)
+
+		window := usecases.DeliveryWindow{Start: "17:00", End: "18:00", Timezone: "UTC"}
+		result, err := usecases.EvaluateDeliveryWindow(context.TODO(), mockRepo, data, window, "2024-10-11")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []usecases.TriggeredHour{
+			{Time: "2024-10-11T17:00:00Z", ForecastCode: 1063, ForecastDescription: "Lluvia"},
+		}, result)
+	})
+}
+
+func TestValidateDeliveryWindow(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		err := usecases.ValidateDeliveryWindow(usecases.DeliveryWindow{Start: "14:00", End: "18:00", Timezone: "America/Sao_Paulo"})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("InvalidStart", func(t *testing.T) {
+		err := usecases.ValidateDeliveryWindow(usecases.DeliveryWindow{Start: "2pm", End: "18:00"})
+
+		assert.EqualError(t, err, "Invalid delivery_window.start: must be a time (HH:MM)")
+	})
+
+	t.Run("EndBeforeStart", func(t *testing.T) {
+		err := usecases.ValidateDeliveryWindow(usecases.DeliveryWindow{Start: "18:00", End: "14:00"})
+
+		assert.EqualError(t, err, "Invalid delivery_window.end: must be after start")
+	})
+
+	t.Run("UnknownTimezone", func(t *testing.T) {
+		err := usecases.ValidateDeliveryWindow(usecases.DeliveryWindow{Start: "14:00", End: "18:00", Timezone: "Mars/Olympus"})
+
+		assert.EqualError(t, err, "Invalid delivery_window.timezone: must be an IANA timezone")
+	})
+}
+
 func TestGetBuyerNotification(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -255,16 +522,16 @@ func TestGetBuyerNotification(t *testing.T) {
 			{
 				Email: "test@example.com",
 				DeliveryLocation: domain.DeliveryLocation{
-					Longitude: "-15.6345",
-					Latitude:  "-15.6345",
+					Longitude: -15.6345,
+					Latitude:  -15.6345,
 				},
 				ForecastCode: 12345,
 			},
 			{
 				Email: "Notification 2",
 				DeliveryLocation: domain.DeliveryLocation{
-					Longitude: "-15.6345",
-					Latitude:  "-10.6345",
+					Longitude: -15.6345,
+					Latitude:  -10.6345,
 				},
 				ForecastCode: 15450,
 			},
@@ -305,8 +572,8 @@ func TestNotificationEntityToDTO_Success(t *testing.T) {
 	sampleNotification := domain.Notification{
 		Email: "test@example.com",
 		DeliveryLocation: domain.DeliveryLocation{
-			Longitude: "40.7128",
-			Latitude:  "74.0060",
+			Longitude: 40.7128,
+			Latitude:  74.0060,
 		},
 		ForecastCode: 12345,
 		Created_at:   time.Now(),
@@ -315,7 +582,7 @@ func TestNotificationEntityToDTO_Success(t *testing.T) {
 	result := usecases.NotificationEntityToDTO(sampleNotification)
 
 	assert.Equal(t, sampleNotification.Created_at, result.NotificationSendAt, "Expected the Created_at value to be correctly mapped")
-	assert.Equal(t, sampleNotification.DeliveryLocation.Latitude, result.Location.Latitude, "Expected the Latitude to be correctly mapped")
-	assert.Equal(t, sampleNotification.DeliveryLocation.Longitude, result.Location.Longitude, "Expected the Longitude to be correctly mapped")
+	assert.Equal(t, usecases.Coordinate("74.006"), result.Location.Latitude, "Expected the Latitude to be correctly mapped")
+	assert.Equal(t, usecases.Coordinate("40.7128"), result.Location.Longitude, "Expected the Longitude to be correctly mapped")
 	assert.Equal(t, sampleNotification.ForecastCode, result.ForecastCode, "Expected the Codigo to be correctly mapped to ForecastCode")
 }
@@ -0,0 +1,210 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/juandr89/delivery-notifier-buyer/server"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/domain"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/sender"
+	third_party "github.com/juandr89/delivery-notifier-buyer/src/notification/infrastructure/third_party"
+	"github.com/juandr89/delivery-notifier-buyer/src/notification/usecases"
+	mocks "github.com/juandr89/delivery-notifier-buyer/test/mocks_test"
+	"github.com/juandr89/delivery-notifier-buyer/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	now := time.Unix(1728561600, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"notification_id":"n-1"}`)
+	signature := webhook.Sign("secret", timestamp, body)
+
+	t.Run("Valid", func(t *testing.T) {
+		assert.NoError(t, webhook.Verify("secret", body, timestamp, signature, webhook.DefaultTolerance, now.Add(time.Minute)))
+	})
+
+	t.Run("WrongSecret", func(t *testing.T) {
+		assert.ErrorIs(t, webhook.Verify("other", body, timestamp, signature, webhook.DefaultTolerance, now), webhook.ErrSignatureMismatch)
+	})
+
+	t.Run("TamperedBody", func(t *testing.T) {
+		assert.ErrorIs(t, webhook.Verify("secret", []byte(`{"notification_id":"n-2"}`), timestamp, signature, webhook.DefaultTolerance, now), webhook.ErrSignatureMismatch)
+	})
+
+	t.Run("Replayed", func(t *testing.T) {
+		assert.ErrorIs(t, webhook.Verify("secret", body, timestamp, signature, webhook.DefaultTolerance, now.Add(time.Hour)), webhook.ErrTimestampTolerance)
+	})
+
+	t.Run("MissingHeaders", func(t *testing.T) {
+		assert.ErrorIs(t, webhook.Verify("secret", body, timestamp, "", webhook.DefaultTolerance, now), webhook.ErrMissingSignature)
+		assert.ErrorIs(t, webhook.Verify("secret", body, "yesterday", signature, webhook.DefaultTolerance, now), webhook.ErrInvalidTimestamp)
+	})
+}
+
+func TestWebhookClient(t *testing.T) {
+	payload := `{"notification_id":"n-1","event":"delivery.delay_warning"}`
+
+	t.Run("TenantCallback", func(t *testing.T) {
+		var received []byte
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := webhook.VerifyRequest(r, "tenant-secret", webhook.DefaultTolerance)
+			assert.NoError(t, err)
+			assert.Equal(t, "n-1", r.Header.Get(webhook.NotificationIDHeader))
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			received, _ = io.ReadAll(r.Body)
+			assert.Equal(t, body, received)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer stub.Close()
+
+		client := sender.NewWebhookClient(server.WebhookConfig{
+			Secret:  "default-secret",
+			Tenants: map[string]server.WebhookTenantConfig{"acme": {URL: stub.URL + "/hooks", Secret: "tenant-secret"}},
+		})
+
+		err := client.Send(domain.Message{NotificationID: "n-1", Recipient: "acme", Text: payload})
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, payload, string(received))
+	})
+
+	t.Run("RequestCallbackUsesDefaultSecret", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := webhook.VerifyRequest(r, "default-secret", webhook.DefaultTolerance)
+			assert.NoError(t, err)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer stub.Close()
+
+		client := sender.NewWebhookClient(server.WebhookConfig{Secret: "default-secret", AllowPrivateCallbacks: true})
+
+		assert.NoError(t, client.Send(domain.Message{NotificationID: "n-1", Recipient: stub.URL, Text: payload}))
+	})
+
+	t.Run("MixedCaseTenant", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := webhook.VerifyRequest(r, "tenant-secret", webhook.DefaultTolerance)
+			assert.NoError(t, err)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer stub.Close()
+
+		// viper lowercases the keys of webhook.tenants.
+		client := sender.NewWebhookClient(server.WebhookConfig{
+			Tenants: map[string]server.WebhookTenantConfig{"acmecorp": {URL: stub.URL, Secret: "tenant-secret"}},
+		})
+
+		assert.NoError(t, client.Send(domain.Message{NotificationID: "n-1", Recipient: "AcmeCorp", Text: payload}))
+	})
+
+	t.Run("PrivateCallbackRejected", func(t *testing.T) {
+		called := false
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer stub.Close()
+
+		client := sender.NewWebhookClient(server.WebhookConfig{Secret: "default-secret"})
+
+		err := client.Send(domain.Message{NotificationID: "n-1", Recipient: stub.URL, Text: payload})
+
+		assert.ErrorIs(t, err, server.ErrNonPublicAddress)
+		assert.False(t, called)
+	})
+
+	t.Run("UnknownTenant", func(t *testing.T) {
+		client := sender.NewWebhookClient(server.WebhookConfig{Secret: "default-secret"})
+
+		err := client.Send(domain.Message{NotificationID: "n-1", Recipient: "unknown", Text: payload})
+
+		assert.EqualError(t, err, `webhook tenant "unknown" is not configured`)
+	})
+
+	t.Run("ReceiverRejects", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer stub.Close()
+
+		client := sender.NewWebhookClient(server.WebhookConfig{Secret: "default-secret", AllowPrivateCallbacks: true})
+
+		assert.EqualError(t, client.Send(domain.Message{NotificationID: "n-1", Recipient: stub.URL, Text: payload}), "webhook returned http status 401")
+	})
+}
+
+func TestSendNotificationWithWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockNotificationRepository(ctrl)
+	mockForecastService := mocks.NewMockForecastService(ctrl)
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(usecases.DeliveryDateLayout)
+	mockForecastService.EXPECT().MaxForecastDays().Return(3)
+	mockForecastService.EXPECT().FetchForecastByLocation(domain.DeliveryLocation{Latitude: 1, Longitude: 2}, tomorrow).Return(&third_party.ForecastServiceResponse{Code: 1063, Description: "Lluvia"}, nil)
+	mockRepo.EXPECT().GetNotificationCodes(gomock.Any()).Return([]string{"1063"}, nil)
+	mockRepo.EXPECT().GetNotificationRules(gomock.Any()).Return(nil, nil)
+
+	mockRepo.EXPECT().GetOptOut(gomock.Any(), "a@example.com").Return(nil, nil)
+	mockRepo.EXPECT().GetBuyerPreferences(gomock.Any(), "a@example.com").Return(nil, nil)
+
+	var saved domain.Notification
+	mockRepo.EXPECT().SaveNotification(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, notification domain.Notification) error {
+		saved = notification
+		return nil
+	})
+
+	request := usecases.RequestDataNotification{Email: "a@example.com", Tenant: "acme", Location: usecases.Location{Latitude: "1", Longitude: "2"}}
+	response, err := usecases.SendNotification(request, mockForecastService, mockRepo, newMessageRenderer(t), usecases.NotificationSettings{})
+
+	assert.NoError(t, err)
+	assert.Len(t, saved.Channels, 2)
+	assert.Equal(t, domain.ChannelWebhook, saved.Channels[1].Channel)
+	assert.Equal(t, "acme", saved.Channels[1].Recipient)
+	assert.Equal(t, saved.ID, response.NotificationID)
+
+	var payload usecases.WebhookPayload
+	assert.NoError(t, json.Unmarshal([]byte(saved.Channels[1].Message), &payload))
+	assert.Equal(t, saved.ID, payload.NotificationID)
+	assert.Equal(t, "delivery.delay_warning", payload.Event)
+	assert.Equal(t, tomorrow, payload.DeliveryDate)
+	assert.Equal(t, float64(1063), payload.ForecastCode)
+	assert.Equal(t, "Lluvia", payload.ForecastDescription)
+}
+
+func TestNotifyBuyerInvalidCallbackURL(t *testing.T) {
+	handler := infrastructure.NewNotificationHandler(nil, nil, nil, server.Config{})
+
+	body := `{"email": "a@example.com", "callback_url": "ftp://partner.example.com/hooks", "location": {"latitude": "1", "longitude": "2"}}`
+	req := httptest.NewRequest(http.MethodPost, "/notifications", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	handler.NotifyBuyer(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid callback_url")
+}
+
+func TestIsValidCallbackURL(t *testing.T) {
+	assert.True(t, usecases.IsValidCallbackURL("https://partner.example.com/hooks"))
+	assert.True(t, usecases.IsValidCallbackURL("http://203.0.113.10:8080/hooks"))
+	assert.False(t, usecases.IsValidCallbackURL("ftp://partner.example.com/hooks"))
+	assert.False(t, usecases.IsValidCallbackURL("http://localhost:8080/hooks"))
+	assert.False(t, usecases.IsValidCallbackURL("http://127.0.0.1/hooks"))
+	assert.False(t, usecases.IsValidCallbackURL("http://10.0.0.5/hooks"))
+	assert.False(t, usecases.IsValidCallbackURL("http://192.168.1.1/hooks"))
+	assert.False(t, usecases.IsValidCallbackURL("http://169.254.169.254/latest/meta-data"))
+	assert.False(t, usecases.IsValidCallbackURL("http://[::1]/hooks"))
+	assert.False(t, usecases.IsValidCallbackURL("http://[fd00::1]/hooks"))
+}
@@ -0,0 +1,100 @@
+// Package webhook signs the payloads posted by the webhook channel and lets
+// receivers verify them.
+//
+// Each request carries the headers X-Notifier-Timestamp (unix seconds),
+// X-Notifier-Notification-Id and X-Notifier-Signature, the latter being
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed
+// with the shared secret. A receiver written in Go can use VerifyRequest:
+//
+//	body, err := webhook.VerifyRequest(r, secret, webhook.DefaultTolerance)
+//	if err != nil {
+//		http.Error(w, err.Error(), http.StatusUnauthorized)
+//		return
+//	}
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader      = "X-Notifier-Signature"
+	TimestampHeader      = "X-Notifier-Timestamp"
+	NotificationIDHeader = "X-Notifier-Notification-Id"
+
+	signaturePrefix = "sha256="
+
+	// DefaultTolerance is how far the timestamp may be from the receiver's
+	// clock before the request is rejected as a possible replay.
+	DefaultTolerance = 5 * time.Minute
+)
+
+var (
+	ErrMissingSignature   = errors.New("webhook signature is missing")
+	ErrInvalidTimestamp   = errors.New("webhook timestamp is invalid")
+	ErrTimestampTolerance = errors.New("webhook timestamp is outside the tolerance")
+	ErrSignatureMismatch  = errors.New("webhook signature does not match")
+)
+
+// Sign returns the value of the signature header for body sent at timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks signature against body and timestamp, rejecting timestamps
+// further than tolerance from now. A zero tolerance skips that check.
+func Verify(secret string, body []byte, timestamp, signature string, tolerance time.Duration, now time.Time) error {
+	if signature == "" {
+		return ErrMissingSignature
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	if tolerance > 0 {
+		drift := now.Sub(time.Unix(seconds, 0))
+		if drift > tolerance || drift < -tolerance {
+			return ErrTimestampTolerance
+		}
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrSignatureMismatch
+	}
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature)) {
+		return ErrSignatureMismatch
+	}
+
+	return nil
+}
+
+// VerifyRequest reads and verifies the body of r. The body is returned and
+// also left readable on r for the next handler.
+func VerifyRequest(r *http.Request, secret string, tolerance time.Duration) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading webhook body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := Verify(secret, body, r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), tolerance, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}